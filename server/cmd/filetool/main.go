@@ -0,0 +1,208 @@
+// filetool 文件存储维护工具
+//
+// 用法：
+//
+//	go run ./cmd/filetool migrate -to s3 [-from local] [-delete-source] [-dry-run]
+//	go run ./cmd/filetool backfill-hash [-dry-run]
+//	go run ./cmd/filetool prune-versions
+//	go run ./cmd/filetool purge-trash
+//	go run ./cmd/filetool check [-verify] [-repair]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"log"
+	"os"
+)
+
+// commands 子命令
+var commands = map[string]func(args []string) error{
+	"migrate":        migrate,
+	"backfill-hash":  backfillHash,
+	"prune-versions": pruneVersions,
+	"purge-trash":    purgeTrash,
+	"check":          check,
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		usage()
+		os.Exit(2)
+	}
+
+	// 加载配置文件，与服务端使用相同的配置
+	configPath := os.Getenv("APP_CONFIG")
+	if configPath == "" {
+		configPath = "config.json"
+	}
+	if err := config.LoadConfig(configPath); err != nil {
+		log.Fatalf("配置文件加载失败: %v", err)
+	}
+	if err := config.InitDB(); err != nil {
+		log.Fatalf("数据库初始化失败: %v", err)
+	}
+	if err := service.InitStorage(config.App.Storage); err != nil {
+		log.Fatalf("文件存储初始化失败: %v", err)
+	}
+
+	if err := commands[os.Args[1]](os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法: filetool <命令> [参数]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "命令:")
+	fmt.Fprintln(os.Stderr, "  migrate        将文件迁移到另一个存储后端")
+	fmt.Fprintln(os.Stderr, "  backfill-hash  为历史文件补算SHA-256，内容重复的文件改为共用一份存储")
+	fmt.Fprintln(os.Stderr, "  prune-versions 按版本保留配置清理历史版本")
+	fmt.Fprintln(os.Stderr, "  purge-trash    彻底删除回收站中超过保留天数的文件")
+	fmt.Fprintln(os.Stderr, "  check          检查存储中的对象与文件记录是否一致")
+}
+
+// migrate 将文件从一个存储后端迁移到另一个存储后端
+func migrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	to := fs.String("to", "", "目标存储后端：local、s3")
+	from := fs.String("from", "", "只迁移该后端的文件，默认迁移所有不在目标后端的文件")
+	deleteSource := fs.Bool("delete-source", false, "迁移成功后删除源文件")
+	dryRun := fs.Bool("dry-run", false, "只列出需要迁移的文件")
+	fs.Parse(args)
+
+	if *to == "" {
+		fs.Usage()
+		return fmt.Errorf("请通过 -to 指定目标存储后端")
+	}
+	target, err := service.GetStorage(*to)
+	if err != nil {
+		return err
+	}
+
+	query := config.DB.Unscoped().Where("backend <> ?", target.Name())
+	if *from != "" {
+		query = query.Where("backend = ?", *from)
+	}
+	var files []model.File
+	if err := query.Order("id ASC").Find(&files).Error; err != nil {
+		return err
+	}
+
+	if *dryRun {
+		for _, file := range files {
+			fmt.Printf("%d\t%s\t%s -> %s\n", file.ID, file.ObjectKey, file.Backend, target.Name())
+		}
+		fmt.Printf("共%d个文件需要迁移\n", len(files))
+		return nil
+	}
+
+	fileService := service.NewFileService()
+	var migrated, failed int
+	for i := range files {
+		file := &files[i]
+		if err := fileService.MigrateFile(file, target, *deleteSource); err != nil {
+			failed++
+			log.Printf("文件%d（%s）迁移失败: %v", file.ID, file.FileName, err)
+			continue
+		}
+		migrated++
+	}
+
+	fmt.Printf("共%d个文件，迁移成功%d个，失败%d个\n", len(files), migrated, failed)
+	if failed > 0 {
+		return fmt.Errorf("有%d个文件迁移失败", failed)
+	}
+	return nil
+}
+
+// backfillHash 为历史文件补算SHA-256并去重
+func backfillHash(args []string) error {
+	fs := flag.NewFlagSet("backfill-hash", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "只计算哈希和可去重的文件数，不修改记录和存储")
+	fs.Parse(args)
+
+	result, err := service.NewFileService().BackfillHashes(*dryRun)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Printf("共%d个文件需要补算哈希，其中%d个与已有内容重复，可释放%d字节\n", result.Total, result.Deduped, result.Saved)
+	} else {
+		fmt.Printf("共%d个文件，补算成功%d个，去重%d个（释放%d字节），失败%d个\n",
+			result.Total, result.Hashed, result.Deduped, result.Saved, result.Failed)
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("有%d个文件补算失败", result.Failed)
+	}
+	return nil
+}
+
+// pruneVersions 按配置的保留数量和天数清理历史版本
+func pruneVersions(args []string) error {
+	fs := flag.NewFlagSet("prune-versions", flag.ExitOnError)
+	fs.Parse(args)
+
+	removed, err := service.NewVersionService().PruneAllVersions()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("共清理%d个历史版本\n", removed)
+	return nil
+}
+
+// purgeTrash 彻底删除回收站中超过保留天数的文件
+func purgeTrash(args []string) error {
+	fs := flag.NewFlagSet("purge-trash", flag.ExitOnError)
+	fs.Parse(args)
+
+	purged, err := service.NewTrashService().PurgeExpired()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("共彻底删除%d个文件\n", purged)
+	return nil
+}
+
+// check 检查存储一致性，报告保存后也可在管理后台查看
+func check(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	verify := fs.Bool("verify", false, "读取全部内容校验SHA-256")
+	repair := fs.Bool("repair", false, "孤立对象移入隔离区，缺失或损坏的文件标记为损坏")
+	fs.Parse(args)
+
+	report, err := service.NewIntegrityService().Check(context.Background(), &model.IntegrityCheckRequest{Verify: *verify, Repair: *repair})
+	if err != nil {
+		return err
+	}
+	for _, skipped := range report.Skipped {
+		fmt.Printf("跳过 %s\n", skipped)
+	}
+	for _, issue := range report.Issues {
+		line := fmt.Sprintf("%-13s %s:%s", issue.Type, issue.Backend, issue.ObjectKey)
+		if issue.Expected != "" {
+			line += fmt.Sprintf(" 记录=%s 实际=%s", issue.Expected, issue.Actual)
+		}
+		if issue.Detail != "" {
+			line += " " + issue.Detail
+		}
+		if len(issue.FileIDs) > 0 {
+			line += fmt.Sprintf(" 文件=%v", issue.FileIDs)
+		}
+		if issue.Repair != "" {
+			line += " [" + issue.Repair + "]"
+		}
+		if issue.RepairErr != "" {
+			line += " 修复失败: " + issue.RepairErr
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("报告%d：检查%d个对象、%d个引用，孤立%d个，缺失%d个，不一致%d个，已修复%d个\n",
+		report.ID, report.Objects, report.Records, report.Orphans, report.Missing, report.Mismatches, report.Repaired)
+	return nil
+}
@@ -1,113 +1,154 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"jing_vue_gin_admin/server/config"
-	"jing_vue_gin_admin/server/internal/handler"
+	"jing_vue_gin_admin/server/internal/logsink"
 	"jing_vue_gin_admin/server/internal/middleware"
 	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/route"
+	"jing_vue_gin_admin/server/internal/router"
+	"jing_vue_gin_admin/server/internal/service"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// shutdownTimeout 关闭服务时等待处理中请求完成的最长时间
+const shutdownTimeout = 30 * time.Second
+
 func main() {
+	printRoutes := flag.Bool("routes", false, "输出Markdown格式的路由清单后退出")
+	flag.Parse()
+
+	if *printRoutes {
+		gin.SetMode(gin.ReleaseMode)
+		router.Setup(gin.New())
+		fmt.Print(route.Table())
+		return
+	}
+
+	// 加载配置文件
+	configPath := os.Getenv("APP_CONFIG")
+	if configPath == "" {
+		configPath = "config.json"
+	}
+	if err := config.LoadConfig(configPath); err != nil {
+		log.Fatalf("配置文件加载失败: %v", err)
+	}
+
 	// 初始化数据库
 	if err := config.InitDB(); err != nil {
 		log.Fatalf("数据库初始化失败: %v", err)
 	}
+	if err := service.InitLogSearch(config.App.LogSearch); err != nil {
+		log.Fatalf("日志全文检索初始化失败: %v", err)
+	}
+	if err := service.InitGeoIP(config.App.GeoIP); err != nil {
+		log.Printf("IP地理位置库加载失败: %v", err)
+	}
+	if err := service.InitStorage(config.App.Storage); err != nil {
+		log.Fatalf("文件存储初始化失败: %v", err)
+	}
+	if err := service.InitScanner(config.App.Scan); err != nil {
+		log.Fatalf("病毒扫描初始化失败: %v", err)
+	}
 
 	// 创建默认管理员角色和用户
 	createDefaultAdminRoleAndUser()
+	syncAdminPermissions()
+
+	// 初始化日志转发
+	logSinks, err := logsink.NewDispatcher(config.App.LogSinks)
+	if err != nil {
+		log.Fatalf("日志转发初始化失败: %v", err)
+	}
+	service.AddLogHook(logSinks.Dispatch)
+	service.StartLoginTracker()
+
+	// 启动告警引擎
+	createDefaultAlertRules()
+	service.StartAlertEngine()
+
+	// 定期清理过期的断点续传任务
+	service.StartUploadCleanup()
+
+	// 定期彻底删除超过保留天数的回收站文件
+	service.StartTrashCleanup()
+
+	// 后台扫描上传的文件，并重试扫描失败的文件
+	service.StartScanWorkers()
 
 	// 初始化Gin框架
-	r := gin.Default()
+	r := gin.New()
+
+	// 请求ID、访问日志和异常恢复
+	r.Use(middleware.RequestID(), middleware.Logger(), middleware.Recovery())
 
 	// 允许跨域
 	r.Use(middleware.Cors())
 
-	// 创建各种处理器实例
-	userHandler := handler.NewUserHandler()
-	roleHandler := handler.NewRoleHandler()
-	logHandler := handler.NewLogHandler()
-	fileHandler := handler.NewFileHandler() // 添加文件处理器
-	dashboardHandler := handler.NewDashboardHandler() // 添加仪表盘处理器
-
-	// API路由组
-	api := r.Group("/api")
-
-	// 公共路由组
-	public := api.Group("")
-	public.Use(middleware.LoginLogMiddleware())
-	{
-		public.POST("/login", userHandler.Login)
-	}
-
-	// 需要身份验证的路由组
-	auth := api.Group("")
-	auth.Use(middleware.AuthMiddleware())
-	{
-		// 用户相关路由
-		userRoutes := auth.Group("/users")
-		{
-			userRoutes.GET("", middleware.RequirePermission("user:view"), middleware.OperationLog("user", "view"), userHandler.GetUsers)
-			userRoutes.GET("/:id", middleware.RequirePermission("user:view"), middleware.OperationLog("user", "view"), userHandler.GetUser)
-			userRoutes.POST("", middleware.RequirePermission("user:create"), middleware.OperationLog("user", "create"), userHandler.CreateUser)
-			userRoutes.PUT("/:id", middleware.RequirePermission("user:update"), middleware.OperationLog("user", "update"), userHandler.UpdateUser)
-			userRoutes.DELETE("/:id", middleware.RequirePermission("user:delete"), middleware.OperationLog("user", "delete"), userHandler.DeleteUser)
-			userRoutes.PUT("/:id/status", middleware.RequirePermission("user:update"), middleware.OperationLog("user", "update"), userHandler.UpdateUserStatus)
-			userRoutes.GET("/current", userHandler.GetCurrentUser)
-		}
+	// 注册路由
+	router.Setup(r)
 
-		// 角色相关路由
-		roleRoutes := auth.Group("/roles")
-		{
-			roleRoutes.GET("", middleware.RequirePermission("role:view"), middleware.OperationLog("role", "view"), roleHandler.GetRoles)
-			roleRoutes.GET("/:id", middleware.RequirePermission("role:view"), middleware.OperationLog("role", "view"), roleHandler.GetRole)
-			roleRoutes.POST("", middleware.RequirePermission("role:create"), middleware.OperationLog("role", "create"), roleHandler.CreateRole)
-			roleRoutes.PUT("/:id", middleware.RequirePermission("role:update"), middleware.OperationLog("role", "update"), roleHandler.UpdateRole)
-			roleRoutes.DELETE("/:id", middleware.RequirePermission("role:delete"), middleware.OperationLog("role", "delete"), roleHandler.DeleteRole)
-			roleRoutes.GET("/all/permissions", middleware.RequirePermission("role:view"), roleHandler.GetAllPermissions)
-		}
+	// 启动服务器，收到中断或终止信号后停止接收新请求，等待处理中的请求完成
+	srv := &http.Server{Addr: ":8080", Handler: r}
+	// 实时日志流不会自行结束，关闭时先断开，客户端会自动重连到新进程
+	srv.RegisterOnShutdown(service.CloseLogStreams)
 
-		// 日志相关路由
-		logRoutes := auth.Group("/logs")
-		{
-			logRoutes.GET("", middleware.RequirePermission("log:view"), middleware.OperationLog("system", "view"), logHandler.GetLogList)
-			logRoutes.DELETE("", middleware.RequirePermission("log:delete"), middleware.OperationLog("system", "delete"), logHandler.DeleteLogs)
-			logRoutes.DELETE("/clear", middleware.RequirePermission("log:delete"), middleware.OperationLog("system", "delete"), logHandler.ClearLogs)
-			logRoutes.GET("/modules", middleware.RequirePermission("log:view"), logHandler.GetLogModules)
-			logRoutes.GET("/actions", middleware.RequirePermission("log:view"), logHandler.GetLogActions)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("服务启动失败: %v", err)
 		}
+	}()
+	<-ctx.Done()
+	stop()
 
-		// 文件相关路由
-		fileRoutes := auth.Group("/files")
-		{
-			fileRoutes.POST("", middleware.RequirePermission("file:upload"), middleware.OperationLog("system", "create"), fileHandler.UploadFile)
-			fileRoutes.GET("", middleware.RequirePermission("file:view"), middleware.OperationLog("system", "view"), fileHandler.GetFileList)
-			fileRoutes.GET("/:id", middleware.RequirePermission("file:view"), middleware.OperationLog("system", "view"), fileHandler.GetFile)
-			fileRoutes.PUT("", middleware.RequirePermission("file:update"), middleware.OperationLog("system", "update"), fileHandler.UpdateFile)
-			fileRoutes.DELETE("/:id", middleware.RequirePermission("file:delete"), middleware.OperationLog("system", "delete"), fileHandler.DeleteFile)
-			fileRoutes.POST("/batch/delete", middleware.RequirePermission("file:delete"), middleware.OperationLog("system", "delete"), fileHandler.BatchDeleteFiles)
-			fileRoutes.GET("/download/:id", middleware.OperationLog("system", "view"), fileHandler.DownloadFile)
-			fileRoutes.GET("/stats", middleware.RequirePermission("file:view"), fileHandler.GetFileStats)
-			fileRoutes.GET("/categories", middleware.RequirePermission("file:view"), fileHandler.GetCategories)
-			fileRoutes.GET("/types", middleware.RequirePermission("file:view"), fileHandler.GetFileTypes)
-		}
-
-		// 仪表盘相关路由
-		dashboardRoutes := auth.Group("/dashboard")
-		{
-			dashboardRoutes.GET("/stats", dashboardHandler.GetDashboardStats)
-			dashboardRoutes.GET("/system-info", dashboardHandler.GetSystemInfo)
-			dashboardRoutes.GET("/user-activities", dashboardHandler.GetUserActivities)
-			dashboardRoutes.GET("/file-types", dashboardHandler.GetFileTypeDistribution)
-			dashboardRoutes.GET("/log-types", dashboardHandler.GetLogTypeDistribution)
-			dashboardRoutes.GET("/recent-registrations", dashboardHandler.GetRecentRegistrations)
-		}
+	log.Println("正在关闭服务...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("等待请求完成超时: %v", err)
 	}
+	// 请求处理完后再关闭日志转发，发送队列中剩余的日志
+	logSinks.Close()
+}
 
-	// 启动服务器
-	r.Run(":8080")
+// defaultAdminPermissions 管理员角色默认拥有的权限
+var defaultAdminPermissions = []string{
+	model.PermissionUserView,
+	model.PermissionUserCreate,
+	model.PermissionUserEdit,
+	model.PermissionUserDelete,
+	model.PermissionRoleView,
+	model.PermissionRoleCreate,
+	model.PermissionRoleEdit,
+	model.PermissionRoleDelete,
+	model.PermissionSystemConfig,
+	model.PermissionSystemLog,
+	model.PermissionLogDelete,
+	model.PermissionLogExport,
+	model.PermissionAlertView,
+	model.PermissionAlertManage,
+	model.PermissionFileView,
+	model.PermissionFileUpload,
+	model.PermissionFileUpdate,
+	model.PermissionFileDelete,
+	model.PermissionFileQuota,
+	model.PermissionFileShare,
+	model.PermissionFilePurge,
+	model.PermissionFileCheck,
+	model.PermissionFileScan,
 }
 
 func createDefaultAdminRoleAndUser() {
@@ -120,23 +161,7 @@ func createDefaultAdminRoleAndUser() {
 			Name:        "admin",
 			Description: "系统管理员",
 			Status:      1,
-			Permissions: []string{
-				model.PermissionUserView,
-				model.PermissionUserCreate,
-				model.PermissionUserEdit,
-				model.PermissionUserDelete,
-				model.PermissionRoleView,
-				model.PermissionRoleCreate,
-				model.PermissionRoleEdit,
-				model.PermissionRoleDelete,
-				model.PermissionSystemConfig,
-				model.PermissionSystemLog,
-				model.PermissionLogDelete,
-				model.PermissionFileView,
-				model.PermissionFileUpload,
-				model.PermissionFileUpdate,
-				model.PermissionFileDelete,
-			},
+			Permissions: defaultAdminPermissions,
 		}
 		if err := config.DB.Create(adminRole).Error; err != nil {
 			log.Fatal("Failed to create admin role:", err)
@@ -162,3 +187,72 @@ func createDefaultAdminRoleAndUser() {
 		log.Println("Created test user: admin/123456")
 	}
 }
+
+// createDefaultAlertRules 首次启动时创建默认告警规则：清空日志和同一IP频繁登录失败
+func createDefaultAlertRules() {
+	var count int64
+	config.DB.Model(&model.AlertRule{}).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	failed := 0
+	rules := []model.AlertRule{
+		{
+			Name:        "日志被清空",
+			Description: "有用户清空了系统日志",
+			Enabled:     true,
+			Module:      model.LogModuleLog,
+			Action:      model.LogActionClear,
+			Threshold:   1,
+			Channels:    []string{model.AlertChannelNotify},
+		},
+		{
+			Name:        "登录失败过多",
+			Description: "同一IP在10分钟内登录失败50次",
+			Enabled:     true,
+			Module:      model.LogModuleAuth,
+			Action:      model.LogActionLogin,
+			Status:      &failed,
+			Threshold:   50,
+			Window:      600,
+			GroupBy:     "ip",
+			Cooldown:    3600,
+			Channels:    []string{model.AlertChannelNotify},
+		},
+	}
+	if err := config.DB.Create(&rules).Error; err != nil {
+		log.Printf("创建默认告警规则失败: %v", err)
+		return
+	}
+	log.Println("Created default alert rules")
+}
+
+// syncAdminPermissions 为已存在的管理员角色补齐新增的默认权限
+func syncAdminPermissions() {
+	var adminRole model.Role
+	if err := config.DB.Where("name = ?", "admin").First(&adminRole).Error; err != nil {
+		return
+	}
+
+	owned := make(map[string]bool, len(adminRole.Permissions))
+	for _, perm := range adminRole.Permissions {
+		owned[perm] = true
+	}
+
+	changed := false
+	for _, perm := range defaultAdminPermissions {
+		if !owned[perm] {
+			adminRole.Permissions = append(adminRole.Permissions, perm)
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := config.DB.Save(&adminRole).Error; err != nil {
+			log.Printf("同步管理员权限失败: %v", err)
+			return
+		}
+		log.Println("Synced admin role permissions")
+	}
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// AppConfig 应用配置
+type AppConfig struct {
+	LogSinks  []LogSinkConfig `json:"log_sinks"`  // 日志转发目标
+	SMTP      SMTPConfig      `json:"smtp"`       // 邮件发送配置
+	GeoIP     GeoIPConfig     `json:"geoip"`      // IP地理位置库配置
+	Storage   StorageConfig   `json:"storage"`    // 文件存储配置
+	Upload    UploadConfig    `json:"upload"`     // 上传内容校验配置
+	Thumbnail ThumbnailConfig `json:"thumbnail"`  // 图片缩略图和变换配置
+	Share     ShareConfig     `json:"share"`      // 文件分享链接配置
+	Version   VersionConfig   `json:"version"`    // 文件版本配置
+	Archive   ArchiveConfig   `json:"archive"`    // 批量下载配置
+	Trash     TrashConfig     `json:"trash"`      // 回收站配置
+	Scan      ScanConfig      `json:"scan"`       // 病毒扫描配置
+	LogSearch string          `json:"log_search"` // 日志全文检索：fts5（必须以 -tags sqlite_fts5 编译）、like，为空时自动选择
+}
+
+// ScanConfig 上传文件病毒扫描配置，启用后文件在扫描通过前不能下载，发现病毒的文件被隔离
+type ScanConfig struct {
+	Backend string `json:"backend"`  // 扫描器：clamd、fake（仅用于测试），为空表示不扫描
+	Address string `json:"address"`  // clamd地址，如 tcp://127.0.0.1:3310 或 unix:///run/clamav/clamd.ctl
+	Timeout int    `json:"timeout"`  // 单个文件的扫描超时（秒），默认300
+	Workers int    `json:"workers"`  // 并发扫描数，默认2
+	MaxSize int64  `json:"max_size"` // 扫描的文件大小上限（字节），超过的文件不扫描，默认25MB，-1表示不限制
+
+	AllowOversize bool `json:"allow_oversize"` // 是否允许下载超过大小上限而未扫描的文件，默认不允许
+}
+
+// TrashConfig 回收站配置，删除的文件先移入回收站，超过保留天数后自动彻底删除
+type TrashConfig struct {
+	KeepDays int `json:"keep_days"` // 保留天数，默认30，-1表示不自动清理
+}
+
+// ArchiveConfig 批量下载为ZIP压缩包的限制，按文件记录中的大小在开始传输前检查
+type ArchiveConfig struct {
+	MaxSize  int64 `json:"max_size"`  // 压缩前的总大小上限（字节），默认2GB，-1表示不限制
+	MaxFiles int   `json:"max_files"` // 文件数上限，默认1000，-1表示不限制
+}
+
+// VersionConfig 文件版本保留配置，超出限制的历史版本在上传新版本或恢复版本后清理，当前版本始终保留
+type VersionConfig struct {
+	MaxVersions int `json:"max_versions"` // 每个文件最多保留的版本数（含当前版本），默认20，-1表示不限制
+	KeepDays    int `json:"keep_days"`    // 历史版本保留天数，0表示不按时间清理
+}
+
+// ShareConfig 文件分享链接配置
+type ShareConfig struct {
+	Secret        string `json:"secret"`         // 链接签名密钥，为空时使用secret_file中自动生成的密钥
+	SecretFile    string `json:"secret_file"`    // 自动生成的密钥保存位置，默认share.key
+	DefaultExpiry int    `json:"default_expiry"` // 未指定有效期时的默认有效期（秒），默认7天
+	MaxExpiry     int    `json:"max_expiry"`     // 有效期上限（秒），0表示不限制
+	BaseURL       string `json:"base_url"`       // 生成分享地址时使用的外部访问地址，如 https://admin.example.com
+}
+
+// ThumbnailConfig 图片缩略图和变换配置
+type ThumbnailConfig struct {
+	CacheDir  string `json:"cache_dir"`  // 变换结果缓存目录，默认tmp/variants
+	Sizes     []int  `json:"sizes"`      // 允许的宽高（像素），默认 64、128、256、512、1024
+	Size      int    `json:"size"`       // 上传时生成的缩略图边长，须在sizes内，默认256
+	Quality   int    `json:"quality"`    // JPEG质量，默认85
+	MaxPixels int    `json:"max_pixels"` // 可处理的原图像素数上限，默认4000万
+}
+
+// UploadConfig 上传内容校验配置，按全局、文件分类和角色三级叠加：
+// 任一级的拒绝列表命中即拒绝，每一级的允许列表不为空时都必须命中
+type UploadConfig struct {
+	UploadRule
+	CheckExtension *bool                 `json:"check_extension"` // 拒绝扩展名与实际内容不符的文件，默认开启
+	Categories     map[string]UploadRule `json:"categories"`      // 按文件分类的规则
+	Roles          map[string]UploadRule `json:"roles"`           // 按角色名称的规则
+}
+
+// UploadRule 上传规则，列表项可以是MIME类型（image/png）、通配符（image/*）或扩展名（.exe）
+type UploadRule struct {
+	Allow   []string `json:"allow"`    // 允许的类型，为空表示不限制
+	Deny    []string `json:"deny"`     // 拒绝的类型，全局未配置时使用内置的可执行文件和网页类型
+	MaxSize int64    `json:"max_size"` // 单个文件大小上限（字节），0表示不限制，角色配置优先于全局配置
+}
+
+// StorageConfig 文件存储配置，Backend为新上传文件使用的后端，已有文件按记录中的后端读取
+type StorageConfig struct {
+	Backend           string          `json:"backend"`            // local、s3、memory，默认local
+	Local             LocalStorage    `json:"local"`              // 本地磁盘存储
+	S3                S3StorageConfig `json:"s3"`                 // S3兼容对象存储
+	RedirectDownloads bool            `json:"redirect_downloads"` // 下载时重定向到对象存储的临时签名地址，不经过本服务转发
+	PresignExpiry     int             `json:"presign_expiry"`     // 临时签名地址有效期（秒），默认900
+	Tus               TusConfig       `json:"tus"`                // 断点续传配置
+}
+
+// TusConfig tus断点续传配置
+type TusConfig struct {
+	Dir        string `json:"dir"`        // 未完成上传的临时目录，默认tmp/tus
+	MaxSize    int64  `json:"max_size"`   // 单个文件大小上限（字节），0表示不限制
+	Expiration int    `json:"expiration"` // 上传任务无活动后的过期时间（秒），默认86400
+}
+
+// LocalStorage 本地磁盘存储配置
+type LocalStorage struct {
+	Dir string `json:"dir"` // 存储目录，默认uploads
+}
+
+// S3StorageConfig S3兼容对象存储配置，Endpoint为空表示未启用
+type S3StorageConfig struct {
+	Endpoint  string `json:"endpoint"` // 如 https://s3.amazonaws.com、http://127.0.0.1:9000
+	Region    string `json:"region"`   // 默认us-east-1
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	PathStyle bool   `json:"path_style"` // 路径风格地址，MinIO需开启
+	Prefix    string `json:"prefix"`     // 对象键前缀
+}
+
+// GeoIPConfig IP地理位置库配置，Database为空表示不查询地理位置
+type GeoIPConfig struct {
+	Database  string   `json:"database"`  // MaxMind DB格式（.mmdb）文件路径，如GeoLite2-City.mmdb
+	Languages []string `json:"languages"` // 地名语言优先级，默认 zh-CN、en
+}
+
+// SMTPConfig 邮件发送配置，Host为空表示不发送邮件
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"` // 默认25
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+// LogSinkConfig 日志转发目标配置
+type LogSinkConfig struct {
+	Name          string        `json:"name"`           // 名称，用于日志输出
+	Type          string        `json:"type"`           // 类型：syslog、file、http
+	Disabled      bool          `json:"disabled"`       // 是否停用
+	Filter        LogSinkFilter `json:"filter"`         // 转发筛选条件
+	BufferSize    int           `json:"buffer_size"`    // 待发送队列长度，默认1024
+	BatchSize     int           `json:"batch_size"`     // 每批条数，默认100
+	FlushInterval int           `json:"flush_interval"` // 批次最长等待秒数，默认5
+	Timeout       int           `json:"timeout"`        // 网络超时秒数，默认10
+
+	// syslog
+	Network  string `json:"network"`  // udp或tcp，默认udp
+	Address  string `json:"address"`  // 如 127.0.0.1:514
+	AppName  string `json:"app_name"` // 默认 jing-admin
+	Facility int    `json:"facility"` // 默认13（log audit）
+
+	// file
+	Path       string `json:"path"`        // 日志文件路径
+	MaxSizeMB  int    `json:"max_size_mb"` // 单个文件大小上限，默认100MB
+	MaxBackups int    `json:"max_backups"` // 保留的历史文件数，默认5
+
+	// http
+	URL        string            `json:"url"`         // 接收地址
+	Headers    map[string]string `json:"headers"`     // 附加请求头，如认证信息
+	MaxRetries int               `json:"max_retries"` // 失败重试次数，默认3，负数表示不重试
+}
+
+// LogSinkFilter 日志转发筛选条件，为空表示不限制
+type LogSinkFilter struct {
+	Modules []string `json:"modules"`
+	Actions []string `json:"actions"`
+	Status  *int     `json:"status"`
+}
+
+// App 当前生效的应用配置
+var App = &AppConfig{}
+
+// LoadConfig 加载JSON配置文件，文件不存在时使用默认配置
+func LoadConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	cfg := &AppConfig{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return err
+	}
+	App = cfg
+	return nil
+}
@@ -10,14 +10,23 @@ var DB *gorm.DB
 
 // InitDB 初始化数据库
 func InitDB() error {
+	return OpenDB("admin.db")
+}
+
+// OpenDB 打开指定的SQLite数据库并迁移表结构
+func OpenDB(dsn string) error {
 	var err error
-	DB, err = gorm.Open(sqlite.Open("admin.db"), &gorm.Config{})
+	DB, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	if err != nil {
 		return err
 	}
 
 	// 自动迁移表结构
-	err = DB.AutoMigrate(&model.User{}, &model.Role{}, &model.SystemLog{}, &model.File{})
+	err = DB.AutoMigrate(&model.User{}, &model.Role{}, &model.SystemLog{}, &model.File{},
+		&model.AlertRule{}, &model.AlertHistory{}, &model.Notification{},
+		&model.UserSession{}, &model.UserDevice{}, &model.Upload{}, &model.Blob{}, &model.Quota{},
+		&model.FileShare{}, &model.FileVersion{},
+		&model.Folder{}, &model.FileACL{}, &model.IntegrityReport{})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ACLHandler 文件访问控制处理器
+type ACLHandler struct {
+	aclService *service.ACLService
+}
+
+// NewACLHandler 创建文件访问控制处理器
+func NewACLHandler() *ACLHandler {
+	return &ACLHandler{
+		aclService: service.NewACLService(),
+	}
+}
+
+// GetFileACL 获取文件的访问控制列表
+func (h *ACLHandler) GetFileACL(c *gin.Context) {
+	h.getACL(c, model.ACLResourceFile)
+}
+
+// SetFileACL 设置文件的访问控制列表
+func (h *ACLHandler) SetFileACL(c *gin.Context) {
+	h.setACL(c, model.ACLResourceFile)
+}
+
+// GetFolderACL 获取文件夹的访问控制列表
+func (h *ACLHandler) GetFolderACL(c *gin.Context) {
+	h.getACL(c, model.ACLResourceFolder)
+}
+
+// SetFolderACL 设置文件夹的访问控制列表，对其中所有文件和子文件夹生效
+func (h *ACLHandler) SetFolderACL(c *gin.Context) {
+	h.setACL(c, model.ACLResourceFolder)
+}
+
+func (h *ACLHandler) getACL(c *gin.Context, resourceType string) {
+	id, ok := aclIDParam(c, resourceType)
+	if !ok {
+		return
+	}
+
+	acl, err := h.aclService.GetACL(c.GetUint("userID"), resourceType, id)
+	if err != nil {
+		status := accessErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "获取访问权限失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "获取访问权限成功", "data": acl})
+}
+
+func (h *ACLHandler) setACL(c *gin.Context, resourceType string) {
+	id, ok := aclIDParam(c, resourceType)
+	if !ok {
+		return
+	}
+	var req model.ACLUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+		return
+	}
+
+	acl, err := h.aclService.SetACL(c.GetUint("userID"), resourceType, id, &req)
+	if err != nil {
+		status := accessErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "设置访问权限失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "设置访问权限成功", "data": acl})
+}
+
+// aclIDParam 按资源类型解析路径中的文件或文件夹ID
+func aclIDParam(c *gin.Context, resourceType string) (uint, bool) {
+	if resourceType == model.ACLResourceFolder {
+		return folderIDParam(c)
+	}
+	return fileIDParam(c)
+}
+
+// requireFile 获取文件并检查当前用户的访问权限，不通过时直接返回404或403
+func requireFile(c *gin.Context, id uint, permission string) (*model.File, bool) {
+	file, err := service.NewACLService().CheckFile(c.GetUint("userID"), id, permission)
+	if err != nil {
+		status := accessErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "获取文件失败", "error": err.Error()})
+		return nil, false
+	}
+	return file, true
+}
+
+// requireFolder 检查当前用户对文件夹的访问权限，不通过时直接返回404或403
+func requireFolder(c *gin.Context, id uint, permission string) bool {
+	if err := service.NewACLService().CheckFolder(c.GetUint("userID"), id, permission); err != nil {
+		status := accessErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "获取文件夹失败", "error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// accessErrorStatus 访问控制错误对应的HTTP状态码，其余错误视为资源不存在
+func accessErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAccessDenied), errors.Is(err, service.ErrACLForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrACLSubject):
+		return http.StatusBadRequest
+	}
+	return http.StatusNotFound
+}
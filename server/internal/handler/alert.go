@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlertHandler 告警处理器
+type AlertHandler struct {
+	alertService *service.AlertService
+}
+
+// NewAlertHandler 创建告警处理器
+func NewAlertHandler() *AlertHandler {
+	return &AlertHandler{
+		alertService: service.NewAlertService(),
+	}
+}
+
+// GetRules 获取告警规则列表
+func (h *AlertHandler) GetRules(c *gin.Context) {
+	rules, err := h.alertService.GetRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取告警规则失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetRule 获取告警规则详情
+func (h *AlertHandler) GetRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "规则ID格式错误"})
+		return
+	}
+
+	rule, err := h.alertService.GetRuleByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateRule 创建告警规则
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	var req model.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	rule, err := h.alertService.CreateRule(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Set("resourceID", rule.ID)
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRule 更新告警规则
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "规则ID格式错误"})
+		return
+	}
+
+	var req model.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	rule, err := h.alertService.UpdateRule(uint(id), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule 删除告警规则
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "规则ID格式错误"})
+		return
+	}
+
+	if err := h.alertService.DeleteRule(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除告警规则失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// GetHistory 获取告警记录
+func (h *AlertHandler) GetHistory(c *gin.Context) {
+	var req model.AlertHistoryRequest
+	req.Page = 1
+	req.PageSize = 10
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	result, err := h.alertService.GetHistory(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取告警记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"jing_vue_gin_admin/server/internal/service"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// download 下载响应的元信息
+type download struct {
+	Name         string    // 文件名
+	MimeType     string    // 上传时识别的内容类型
+	Hash         string    // 内容的SHA-256，用作ETag和Digest
+	ModTime      time.Time // 用作Last-Modified
+	CacheControl string    // 默认 private, no-cache，每次使用前向服务器验证
+}
+
+// serveDownload 发送文件内容，由http.ServeContent处理Range、If-Range、If-None-Match和If-Modified-Since，
+// inline=true且类型可安全预览时在浏览器中直接打开，否则作为附件下载。
+// 返回本次请求是否算作一次下载：完整响应或从头开始的范围请求才算，续传和304不算，也不记录操作日志
+func serveDownload(c *gin.Context, content io.ReadSeeker, d download) bool {
+	contentType, disposition := downloadHeaders(c, d)
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", disposition)
+	header.Set("X-Content-Type-Options", "nosniff")
+	if d.CacheControl == "" {
+		d.CacheControl = "private, no-cache"
+	}
+	header.Set("Cache-Control", d.CacheControl)
+	if sum, err := hex.DecodeString(d.Hash); err == nil && len(sum) > 0 {
+		header.Set("ETag", `"`+d.Hash+`"`)
+		header.Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(sum))
+	}
+
+	size, err := content.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = content.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "读取文件失败", "error": err.Error()})
+		return false
+	}
+	http.ServeContent(c.Writer, c.Request, d.Name, d.ModTime, content)
+
+	status := c.Writer.Status()
+	counted := !continuesDownload(c.Request, size, d.Hash) && (status == http.StatusOK || status == http.StatusPartialContent)
+	if !counted {
+		c.Set("skipOperationLog", true)
+	}
+	return counted
+}
+
+// downloadHeaders 下载响应的Content-Type和Content-Disposition，
+// inline=true且类型可安全预览时在浏览器中直接打开，否则作为附件下载
+func downloadHeaders(c *gin.Context, d download) (string, string) {
+	contentType := downloadType(d.MimeType, d.Name)
+	disposition := "attachment"
+	if c.Query("inline") == "true" {
+		if previewType, ok := inlineType(contentType); ok {
+			contentType = previewType
+			disposition = "inline"
+		}
+	}
+	return contentType, contentDisposition(disposition, d.Name)
+}
+
+// scanBlocked 文件未通过病毒扫描时返回错误响应并返回true：发现病毒或超过扫描大小上限返回403，尚未完成扫描返回409
+func scanBlocked(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	status := scanErrorStatus(err)
+	if status == http.StatusConflict {
+		c.Header("Retry-After", "30")
+	}
+	c.JSON(status, gin.H{"code": status, "message": "下载失败", "error": err.Error()})
+	return true
+}
+
+// scanErrorStatus 病毒扫描错误对应的HTTP状态码，不是扫描错误时返回0
+func scanErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrFileInfected), errors.Is(err, service.ErrScanOversize):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrScanPending):
+		return http.StatusConflict
+	}
+	return 0
+}
+
+// continuesDownload 是否为续传或媒体拖动：所有范围都不从第0字节开始，且If-Range为空或与当前内容的ETag一致。
+// 按解析后的起始位置判断，"bytes= 0-"、"bytes=00-"和包含"0-"的多段范围都视为从头下载；
+// 无法解析的Range以及If-Range不一致（服务器会返回完整内容）时也视为完整下载
+func continuesDownload(r *http.Request, size int64, hash string) bool {
+	start, ok := rangeStart(r.Header.Get("Range"), size)
+	if !ok || start == 0 {
+		return false
+	}
+	if ifRange := strings.TrimSpace(r.Header.Get("If-Range")); ifRange != "" && ifRange != `"`+hash+`"` {
+		return false
+	}
+	return true
+}
+
+// rangeStart 解析Range请求头，返回各范围中最小的起始位置，后缀范围"-N"的起始位置为size-N
+func rangeStart(header string, size int64) (int64, bool) {
+	header = strings.TrimSpace(header)
+	if !strings.HasPrefix(header, "bytes=") {
+		return 0, false
+	}
+	spec := strings.TrimPrefix(header, "bytes=")
+	min := int64(-1)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		first, last, ok := strings.Cut(part, "-")
+		if !ok {
+			return 0, false
+		}
+		first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+		var start int64
+		if first == "" {
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return 0, false
+			}
+			if start = size - n; start < 0 {
+				start = 0
+			}
+		} else {
+			n, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || n < 0 {
+				return 0, false
+			}
+			start = n
+		}
+		if min < 0 || start < min {
+			min = start
+		}
+	}
+	if min < 0 {
+		return 0, false
+	}
+	return min, true
+}
+
+// downloadType 文件的内容类型，未识别时按扩展名推断
+func downloadType(mimeType, name string) string {
+	if mimeType != "" && mimeType != "application/octet-stream" {
+		return mimeType
+	}
+	if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); byExt != "" {
+		return byExt
+	}
+	return "application/octet-stream"
+}
+
+// inlineType 可在浏览器中安全预览的类型：图片（SVG除外）、PDF、音视频和文本，文本一律按纯文本显示
+func inlineType(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	switch {
+	case mediaType == "image/svg+xml":
+		return "", false
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		mediaType == "application/pdf":
+		return contentType, true
+	case strings.HasPrefix(mediaType, "text/"):
+		return "text/plain; charset=utf-8", true
+	}
+	return "", false
+}
+
+// contentDisposition 按RFC 6266生成Content-Disposition，
+// filename为ASCII回退名，包含非ASCII字符时另附RFC 5987编码的filename*
+func contentDisposition(disposition, name string) string {
+	fallback := asciiFilename(name)
+	value := fmt.Sprintf(`%s; filename="%s"`, disposition, fallback)
+	if fallback != name {
+		value += "; filename*=UTF-8''" + rfc5987Escape(name)
+	}
+	return value
+}
+
+// asciiFilename 把非ASCII字符、控制字符、引号、反斜杠和路径分隔符替换为下划线
+func asciiFilename(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r < 0x20 || r >= 0x7f || r == '"' || r == '\\' || r == '/' {
+			b.WriteByte('_')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "download"
+	}
+	return b.String()
+}
+
+// rfc5987Escape 按RFC 5987对UTF-8字节做百分号编码，只保留attr-char
+func rfc5987Escape(s string) string {
+	const attrChars = "!#$&+-.^_`|~"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || strings.IndexByte(attrChars, ch) >= 0 {
+			b.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", ch)
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDownloadScanStatus(t *testing.T) {
+	if err := service.InitScanner(config.ScanConfig{Backend: "fake"}); err != nil {
+		t.Fatal(err)
+	}
+	defer service.InitScanner(config.ScanConfig{})
+
+	tests := []struct {
+		name          string
+		status        string
+		rescan        bool
+		allowOversize bool
+		wantStatus    int
+	}{
+		{"clean", model.ScanStatusClean, false, false, http.StatusOK},
+		{"unscanned", model.ScanStatusUnscanned, false, false, http.StatusOK},
+		{"pending", model.ScanStatusPending, false, false, http.StatusConflict},
+		{"infected", model.ScanStatusInfected, false, false, http.StatusForbidden},
+		{"oversize blocked by default", model.ScanStatusOversize, false, false, http.StatusForbidden},
+		{"oversize allowed", model.ScanStatusOversize, false, true, http.StatusOK},
+		{"clean while rescanning", model.ScanStatusClean, true, false, http.StatusOK},
+		{"infected while rescanning", model.ScanStatusInfected, true, false, http.StatusForbidden},
+	}
+	h := NewFileHandler()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.App.Scan.AllowOversize = tt.allowOversize
+			defer func() { config.App.Scan.AllowOversize = false }()
+
+			file := createTestFile(t, "a.txt", fmt.Sprintf("content %d", i), tt.status)
+			if tt.rescan {
+				config.DB.Model(file).UpdateColumn("rescan", true)
+			}
+			w := serveAs(testUserID, http.MethodGet, "/files/download/:id", fmt.Sprintf("/files/download/%d", file.ID), nil, nil, h.DownloadFile)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			switch w.Code {
+			case http.StatusOK:
+				if w.Body.String() != fmt.Sprintf("content %d", i) {
+					t.Errorf("body = %q", w.Body.String())
+				}
+			case http.StatusConflict:
+				if w.Header().Get("Retry-After") == "" {
+					t.Errorf("pending response should set Retry-After")
+				}
+			}
+		})
+	}
+}
+
+func TestRescanAllKeepsVerdict(t *testing.T) {
+	if err := service.InitScanner(config.ScanConfig{Backend: "fake"}); err != nil {
+		t.Fatal(err)
+	}
+	defer service.InitScanner(config.ScanConfig{})
+
+	clean := createTestFile(t, "clean.txt", "rescan clean", model.ScanStatusClean)
+	infected := createTestFile(t, "infected.txt", "rescan infected", model.ScanStatusInfected)
+	if _, err := service.NewScanService().RescanAll(""); err != nil {
+		t.Fatal(err)
+	}
+
+	var files []model.File
+	config.DB.Find(&files, []uint{clean.ID, infected.ID})
+	for _, f := range files {
+		if !f.Rescan {
+			t.Errorf("file %d should be queued for rescan", f.ID)
+		}
+	}
+	h := NewFileHandler()
+	if w := serveAs(testUserID, http.MethodGet, "/files/download/:id", fmt.Sprintf("/files/download/%d", clean.ID), nil, nil, h.DownloadFile); w.Code != http.StatusOK {
+		t.Errorf("clean file should stay downloadable while rescanning, got %d", w.Code)
+	}
+	if w := serveAs(testUserID, http.MethodGet, "/files/download/:id", fmt.Sprintf("/files/download/%d", infected.ID), nil, nil, h.DownloadFile); w.Code != http.StatusForbidden {
+		t.Errorf("infected file should stay blocked while rescanning, got %d", w.Code)
+	}
+}
+
+func TestDownloadRequiresGrant(t *testing.T) {
+	file := createTestFile(t, "private.txt", "private content", model.ScanStatusClean)
+	granted := model.User{Username: "download-granted", Role: "user"}
+	denied := model.User{Username: "download-denied", Role: "user"}
+	config.DB.Create(&granted)
+	config.DB.Create(&denied)
+	// 文件自身设置了条目后，只有被授权的用户可以下载
+	config.DB.Create(&model.FileACL{ResourceType: model.ACLResourceFile, ResourceID: file.ID,
+		SubjectType: model.ACLSubjectUser, SubjectID: granted.ID, Permissions: []string{model.ACLDownload}})
+
+	h := NewFileHandler()
+	target := fmt.Sprintf("/files/download/%d", file.ID)
+	for _, tt := range []struct {
+		name   string
+		userID uint
+		want   int
+	}{
+		{"uploader", testUserID, http.StatusOK},
+		{"granted", granted.ID, http.StatusOK},
+		{"no grant", denied.ID, http.StatusForbidden},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(tt.userID, http.MethodGet, "/files/download/:id", target, nil, nil, h.DownloadFile)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if tt.want == http.StatusForbidden && strings.Contains(w.Body.String(), "private content") {
+				t.Errorf("denied response leaked the file content")
+			}
+		})
+	}
+}
@@ -1,31 +1,42 @@
 package handler
 
 import (
+	"errors"
+	"jing_vue_gin_admin/server/config"
 	"jing_vue_gin_admin/server/internal/model"
 	"jing_vue_gin_admin/server/internal/service"
+	"jing_vue_gin_admin/server/internal/storage"
+	"log"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // FileHandler 文件处理器
 type FileHandler struct {
-	fileService *service.FileService
+	fileService    *service.FileService
+	folderService  *service.FolderService
+	aclService     *service.ACLService
+	archiveService *service.ArchiveService
 }
 
 // NewFileHandler 创建文件处理器
 func NewFileHandler() *FileHandler {
 	return &FileHandler{
-		fileService: service.NewFileService(),
+		fileService:    service.NewFileService(),
+		folderService:  service.NewFolderService(),
+		aclService:     service.NewACLService(),
+		archiveService: service.NewArchiveService(),
 	}
 }
 
 // UploadFile 上传文件
 func (h *FileHandler) UploadFile(c *gin.Context) {
 	// 获取当前用户ID
-	userID, exists := c.Get("user_id")
+	userID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "message": "用户未认证"})
 		return
@@ -48,10 +59,16 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 
 	// 上传文件
 	result, err := h.fileService.UploadFile(req, userID.(uint))
+	var uploadErr *service.UploadError
+	if errors.As(err, &uploadErr) {
+		c.JSON(uploadErr.Status, gin.H{"code": uploadErr.Status, "message": "文件上传失败", "error": uploadErr.Message, "error_code": uploadErr.Code})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "文件上传失败", "error": err.Error()})
 		return
 	}
+	c.Set("resourceID", result.ID)
 
 	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "文件上传成功", "data": result})
 }
@@ -91,6 +108,29 @@ func (h *FileHandler) GetFileList(c *gin.Context) {
 		}
 	}
 
+	// 按文件夹筛选，可传文件夹ID或完整路径，recursive=true时包含子文件夹
+	req.Recursive = c.Query("recursive") == "true"
+	if folderPath, ok := c.GetQuery("folder_path"); ok {
+		folder, err := h.folderService.GetFolderByPath(folderPath)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "获取文件列表失败", "error": err.Error()})
+			return
+		}
+		var folderID uint
+		if folder != nil {
+			folderID = folder.ID
+		}
+		req.FolderID = &folderID
+	} else if folderIDStr := c.Query("folder_id"); folderIDStr != "" {
+		if folderID, err := strconv.ParseUint(folderIDStr, 10, 32); err == nil {
+			id := uint(folderID)
+			req.FolderID = &id
+		}
+	}
+
+	// 只列出当前用户有查看权限的文件
+	req.ViewerID = c.GetUint("userID")
+
 	// 查询文件列表
 	result, err := h.fileService.GetFiles(req)
 	if err != nil {
@@ -110,9 +150,8 @@ func (h *FileHandler) GetFile(c *gin.Context) {
 		return
 	}
 
-	file, err := h.fileService.GetFileByID(uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "获取文件失败", "error": err.Error()})
+	file, ok := requireFile(c, uint(id), model.ACLView)
+	if !ok {
 		return
 	}
 
@@ -127,6 +166,10 @@ func (h *FileHandler) UpdateFile(c *gin.Context) {
 		return
 	}
 
+	if _, ok := requireFile(c, req.ID, model.ACLEdit); !ok {
+		return
+	}
+
 	file, err := h.fileService.UpdateFile(req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "更新文件失败", "error": err.Error()})
@@ -136,7 +179,7 @@ func (h *FileHandler) UpdateFile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "更新文件成功", "data": file})
 }
 
-// DeleteFile 删除文件
+// DeleteFile 删除文件，移入回收站
 func (h *FileHandler) DeleteFile(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -145,7 +188,11 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 		return
 	}
 
-	if err := h.fileService.DeleteFile(uint(id)); err != nil {
+	if _, ok := requireFile(c, uint(id), model.ACLDelete); !ok {
+		return
+	}
+
+	if err := h.fileService.DeleteFile(uint(id), c.GetUint("userID")); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "删除文件失败", "error": err.Error()})
 		return
 	}
@@ -153,7 +200,7 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "删除文件成功"})
 }
 
-// BatchDeleteFiles 批量删除文件
+// BatchDeleteFiles 批量删除文件，移入回收站
 func (h *FileHandler) BatchDeleteFiles(c *gin.Context) {
 	var req struct {
 		IDs []uint `json:"ids" binding:"required"`
@@ -163,8 +210,14 @@ func (h *FileHandler) BatchDeleteFiles(c *gin.Context) {
 		return
 	}
 
+	// 有任一文件没有删除权限时全部不删除
+	if denied := h.deniedFiles(c, req.IDs, model.ACLDelete); len(denied) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"code": http.StatusForbidden, "message": "批量删除文件失败", "error": service.ErrAccessDenied.Error(), "data": denied})
+		return
+	}
+
 	for _, id := range req.IDs {
-		if err := h.fileService.DeleteFile(id); err != nil {
+		if err := h.fileService.DeleteFile(id, c.GetUint("userID")); err != nil {
 			// 记录错误但继续删除
 			continue
 		}
@@ -173,6 +226,68 @@ func (h *FileHandler) BatchDeleteFiles(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "批量删除文件成功"})
 }
 
+// MoveFiles 将文件移动到指定文件夹
+func (h *FileHandler) MoveFiles(c *gin.Context) {
+	var req model.FileMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+		return
+	}
+
+	if !requireFolder(c, req.FolderID, model.ACLEdit) {
+		return
+	}
+	if denied := h.deniedFiles(c, req.IDs, model.ACLEdit); len(denied) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"code": http.StatusForbidden, "message": "移动文件失败", "error": service.ErrAccessDenied.Error(), "data": denied})
+		return
+	}
+
+	if err := h.folderService.MoveFiles(&req); err != nil {
+		status := folderErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"code": status, "message": "移动文件失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "移动文件成功"})
+}
+
+// CopyFiles 将文件复制到指定文件夹，超出配额时返回已复制的部分
+func (h *FileHandler) CopyFiles(c *gin.Context) {
+	var req model.FileMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+		return
+	}
+
+	if !requireFolder(c, req.FolderID, model.ACLEdit) {
+		return
+	}
+	if denied := h.deniedFiles(c, req.IDs, model.ACLDownload); len(denied) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"code": http.StatusForbidden, "message": "复制文件失败", "error": service.ErrAccessDenied.Error(), "data": denied})
+		return
+	}
+
+	files, err := h.folderService.CopyFiles(c.GetUint("userID"), &req)
+	var uploadErr *service.UploadError
+	if errors.As(err, &uploadErr) {
+		c.JSON(uploadErr.Status, gin.H{"code": uploadErr.Status, "message": "复制文件失败", "error": uploadErr.Message, "error_code": uploadErr.Code, "data": files})
+		return
+	}
+	if err != nil {
+		status := folderErrorStatus(err)
+		if status == http.StatusInternalServerError && len(files) == 0 {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"code": status, "message": "复制文件失败", "error": err.Error(), "data": files})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "复制文件成功", "data": files})
+}
+
 // DownloadFile 下载文件
 func (h *FileHandler) DownloadFile(c *gin.Context) {
 	idStr := c.Param("id")
@@ -182,30 +297,118 @@ func (h *FileHandler) DownloadFile(c *gin.Context) {
 		return
 	}
 
-	file, err := h.fileService.GetFileByID(uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "获取文件失败", "error": err.Error()})
+	file, ok := requireFile(c, uint(id), model.ACLDownload)
+	if !ok {
 		return
 	}
+	if scanBlocked(c, service.CheckScan(file)) {
+		return
+	}
+
+	// 对象存储可直接重定向到临时签名地址，由客户端从存储下载；对象键为内容哈希，文件名和内容类型通过签名参数指定
+	if config.App.Storage.RedirectDownloads {
+		expiry := time.Duration(config.App.Storage.PresignExpiry) * time.Second
+		contentType, disposition := downloadHeaders(c, download{Name: file.FileName, MimeType: file.MimeType})
+		opts := &storage.PresignOptions{ContentType: contentType, ContentDisposition: disposition}
+		if url, err := h.fileService.PresignFile(file, expiry, opts); err == nil {
+			if !continuesDownload(c.Request, file.FileSize, file.Hash) {
+				go h.fileService.IncrementDownloadCount(uint(id))
+			}
+			c.Redirect(http.StatusFound, url)
+			return
+		}
+	}
 
-	// 检查文件是否存在
-	if _, err := os.Stat(file.FilePath); os.IsNotExist(err) {
-		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "文件不存在"})
+	content, _, err := h.fileService.OpenContent(file)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "文件不存在", "error": err.Error()})
 		return
 	}
+	defer content.Close()
+
+	// 下载文件，支持范围请求和条件请求，附带内容摘要供客户端校验完整性；inline=true时可在浏览器中预览
+	counted := serveDownload(c, content, download{
+		Name:     file.FileName,
+		MimeType: file.MimeType,
+		Hash:     file.Hash,
+		ModTime:  file.UpdatedAt,
+	})
 
 	// 增加下载次数
-	go h.fileService.IncrementDownloadCount(uint(id))
+	if counted {
+		go h.fileService.IncrementDownloadCount(uint(id))
+	}
+}
+
+// DownloadArchive 批量下载为ZIP压缩包，GET使用查询参数ids、folder_id，POST使用JSON请求体
+// 压缩包边读取边发送，开始发送后出错只能中断传输，客户端会得到不完整的压缩包
+func (h *FileHandler) DownloadArchive(c *gin.Context) {
+	var req model.FileArchiveRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+		return
+	}
+	if len(req.IDs) == 0 && req.FolderID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": "需要指定文件ID或文件夹"})
+		return
+	}
 
-	// 下载文件
-	c.Header("Content-Disposition", "attachment; filename="+file.FileName)
-	c.Header("Content-Type", "application/octet-stream")
-	c.File(file.FilePath)
+	// 按ID下载时有任一文件没有下载权限即拒绝，按文件夹下载时跳过这些文件
+	if req.FolderID == 0 {
+		if denied := h.deniedFiles(c, req.IDs, model.ACLDownload); len(denied) > 0 {
+			c.JSON(http.StatusForbidden, gin.H{"code": http.StatusForbidden, "message": "批量下载失败", "error": service.ErrAccessDenied.Error(), "data": denied})
+			return
+		}
+	}
+
+	name, entries, err := h.archiveService.Prepare(c.GetUint("userID"), &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrArchiveTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, service.ErrAccessDenied):
+			status = http.StatusForbidden
+		case errors.Is(err, service.ErrArchiveEmpty), errors.Is(err, service.ErrFolderNotFound):
+			status = http.StatusNotFound
+		case scanErrorStatus(err) != 0:
+			status = scanErrorStatus(err)
+		}
+		c.JSON(status, gin.H{"code": status, "message": "批量下载失败", "error": err.Error()})
+		return
+	}
+	if req.FolderID != 0 {
+		c.Set("resourceID", req.FolderID)
+	} else {
+		ids := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, strconv.FormatUint(uint64(entry.File.ID), 10))
+		}
+		c.Set("resourceID", strings.Join(ids, ","))
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", contentDisposition("attachment", name))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	if err := h.archiveService.Write(c.Writer, entries); err != nil {
+		log.Printf("批量下载中断: %v", err)
+	}
+}
+
+// deniedFiles 返回当前用户没有指定权限的文件ID，出错时视为全部没有权限
+func (h *FileHandler) deniedFiles(c *gin.Context, ids []uint, permission string) []uint {
+	denied, err := h.aclService.DeniedFiles(c.GetUint("userID"), ids, permission)
+	if err != nil {
+		return ids
+	}
+	return denied
 }
 
 // GetFileStats 获取文件统计信息
 func (h *FileHandler) GetFileStats(c *gin.Context) {
-	stats, err := h.fileService.GetFileStats()
+	stats, err := h.fileService.GetFileStats(c.GetUint("userID"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "获取文件统计失败", "error": err.Error()})
 		return
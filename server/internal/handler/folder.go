@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"errors"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FolderHandler 文件夹处理器
+type FolderHandler struct {
+	folderService *service.FolderService
+}
+
+// NewFolderHandler 创建文件夹处理器
+func NewFolderHandler() *FolderHandler {
+	return &FolderHandler{
+		folderService: service.NewFolderService(),
+	}
+}
+
+// GetTree 获取完整的文件夹树
+func (h *FolderHandler) GetTree(c *gin.Context) {
+	tree, err := h.folderService.GetTree(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "获取文件夹树失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "获取文件夹树成功", "data": tree})
+}
+
+// GetContents 获取文件夹内容，可按ID（/folders/:id）或路径（?path=/a/b）查询，都不传时为根目录
+func (h *FolderHandler) GetContents(c *gin.Context) {
+	var folder *model.Folder
+	var err error
+	if idStr := c.Param("id"); idStr != "" {
+		id, parseErr := strconv.ParseUint(idStr, 10, 32)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "无效的文件夹ID"})
+			return
+		}
+		folder, err = h.folderService.GetFolder(uint(id))
+	} else {
+		folder, err = h.folderService.GetFolderByPath(c.Query("path"))
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "获取文件夹失败", "error": err.Error()})
+		return
+	}
+	if folder != nil && !requireFolder(c, folder.ID, model.ACLView) {
+		return
+	}
+
+	contents, err := h.folderService.GetContents(folder, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "获取文件夹内容失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "获取文件夹内容成功", "data": contents})
+}
+
+// CreateFolder 创建文件夹
+func (h *FolderHandler) CreateFolder(c *gin.Context) {
+	var req model.FolderCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+		return
+	}
+
+	if !requireFolder(c, req.ParentID, model.ACLEdit) {
+		return
+	}
+
+	folder, err := h.folderService.CreateFolder(c.GetUint("userID"), &req)
+	if err != nil {
+		status := folderErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "创建文件夹失败", "error": err.Error()})
+		return
+	}
+	c.Set("resourceID", folder.ID)
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "创建文件夹成功", "data": folder})
+}
+
+// RenameFolder 重命名文件夹
+func (h *FolderHandler) RenameFolder(c *gin.Context) {
+	id, ok := folderIDParam(c)
+	if !ok {
+		return
+	}
+	var req model.FolderRenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+		return
+	}
+
+	if !requireFolder(c, id, model.ACLEdit) {
+		return
+	}
+
+	folder, err := h.folderService.RenameFolder(id, &req)
+	if err != nil {
+		status := folderErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "重命名文件夹失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "重命名文件夹成功", "data": folder})
+}
+
+// MoveFolder 移动文件夹
+func (h *FolderHandler) MoveFolder(c *gin.Context) {
+	id, ok := folderIDParam(c)
+	if !ok {
+		return
+	}
+	var req model.FolderMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+		return
+	}
+
+	// 需要能编辑被移动的文件夹，并能在目标文件夹中新建
+	if !requireFolder(c, id, model.ACLEdit) || !requireFolder(c, req.ParentID, model.ACLEdit) {
+		return
+	}
+
+	folder, err := h.folderService.MoveFolder(id, &req)
+	if err != nil {
+		status := folderErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "移动文件夹失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "移动文件夹成功", "data": folder})
+}
+
+// DeleteFolder 删除文件夹，不为空时返回409和其中的文件夹数、文件数，确认后带recursive=true重新请求
+func (h *FolderHandler) DeleteFolder(c *gin.Context) {
+	id, ok := folderIDParam(c)
+	if !ok {
+		return
+	}
+
+	if !requireFolder(c, id, model.ACLDelete) {
+		return
+	}
+
+	result, err := h.folderService.DeleteFolder(id, c.GetUint("userID"), c.Query("recursive") == "true")
+	if err != nil {
+		status := folderErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "删除文件夹失败", "error": err.Error(), "data": result})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "删除文件夹成功", "data": result})
+}
+
+// folderIDParam 解析路径中的文件夹ID
+func folderIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "无效的文件夹ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// folderErrorStatus 文件夹错误对应的HTTP状态码
+func folderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrFolderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrFolderExists), errors.Is(err, service.ErrFolderNotEmpty):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrFolderName), errors.Is(err, service.ErrFolderCycle):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrAccessDenied):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
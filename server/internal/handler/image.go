@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"jing_vue_gin_admin/server/pkg/imaging"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImageHandler 图片缩略图和变换处理器
+type ImageHandler struct {
+	imageService *service.ImageService
+}
+
+// NewImageHandler 创建图片处理器
+func NewImageHandler() *ImageHandler {
+	return &ImageHandler{
+		imageService: service.NewImageService(),
+	}
+}
+
+// GetThumbnail 获取图片文件的缩略图
+func (h *ImageHandler) GetThumbnail(c *gin.Context) {
+	h.serveVariant(c, nil)
+}
+
+// GetSignedThumbnail 通过文件列表返回的签名地址获取缩略图，不需要登录
+func (h *ImageHandler) GetSignedThumbnail(c *gin.Context) {
+	file, err := h.imageService.ThumbnailFile(c.Param("token"))
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, service.ErrThumbnailURLInvalid) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"code": status, "message": "获取缩略图失败", "error": err.Error()})
+		return
+	}
+	if scanBlocked(c, service.CheckScan(file)) {
+		return
+	}
+	h.writeVariant(c, file, nil)
+}
+
+// GetVariant 按参数缩放、裁剪或转换图片格式，宽高只能取配置中允许的尺寸
+// 参数：w 宽度，h 高度，fit contain或cover，format jpeg、png或gif
+func (h *ImageHandler) GetVariant(c *gin.Context) {
+	var opts service.VariantOptions
+	for name, target := range map[string]*int{"w": &opts.Width, "h": &opts.Height} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "无效的图片尺寸"})
+			return
+		}
+		*target = n
+	}
+	opts.Fit = c.Query("fit")
+	opts.Format = c.Query("format")
+	h.serveVariant(c, &opts)
+}
+
+// serveVariant opts为nil时返回默认缩略图；查看权限只能获取缩略图，其他尺寸和格式需要下载权限
+func (h *ImageHandler) serveVariant(c *gin.Context, opts *service.VariantOptions) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "无效的文件ID"})
+		return
+	}
+
+	permission := model.ACLView
+	if opts != nil {
+		permission = model.ACLDownload
+	}
+	file, ok := requireFile(c, uint(id), permission)
+	if !ok {
+		return
+	}
+	if scanBlocked(c, service.CheckScan(file)) {
+		return
+	}
+	h.writeVariant(c, file, opts)
+}
+
+// writeVariant 生成并返回图片，opts为nil时返回默认缩略图
+func (h *ImageHandler) writeVariant(c *gin.Context, file *model.File, opts *service.VariantOptions) {
+	var path, contentType string
+	var err error
+	if opts == nil {
+		path, contentType, err = h.imageService.Thumbnail(file)
+	} else {
+		path, contentType, err = h.imageService.Variant(file, *opts)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrNotImage), errors.Is(err, imaging.ErrUnsupportedFormat):
+			status = http.StatusUnsupportedMediaType
+		case errors.Is(err, service.ErrInvalidVariant):
+			status = http.StatusBadRequest
+		case errors.Is(err, imaging.ErrTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, gin.H{"code": status, "message": "生成图片失败", "error": err.Error()})
+		return
+	}
+
+	// 缓存文件名由内容哈希和参数决定，内容不变时结果不变
+	etag := `"` + filepath.Base(filepath.Dir(path)) + "-" + filepath.Base(path) + `"`
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Header("Content-Type", contentType)
+	c.File(path)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IntegrityHandler 存储一致性检查处理器
+type IntegrityHandler struct {
+	integrityService *service.IntegrityService
+}
+
+// NewIntegrityHandler 创建存储一致性检查处理器
+func NewIntegrityHandler() *IntegrityHandler {
+	return &IntegrityHandler{
+		integrityService: service.NewIntegrityService(),
+	}
+}
+
+// GetReports 获取最近20次检查的报告摘要
+func (h *IntegrityHandler) GetReports(c *gin.Context) {
+	reports, err := h.integrityService.GetReports(20)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "获取检查报告失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "获取检查报告成功", "data": reports})
+}
+
+// GetReport 获取检查报告及发现的问题
+func (h *IntegrityHandler) GetReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "无效的报告ID"})
+		return
+	}
+
+	report, err := h.integrityService.GetReport(uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrIntegrityReportNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"code": status, "message": "获取检查报告失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "获取检查报告成功", "data": report})
+}
+
+// StartCheck 在后台发起一致性检查，通过报告ID查询进度和结果
+func (h *IntegrityHandler) StartCheck(c *gin.Context) {
+	var req model.IntegrityCheckRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+			return
+		}
+	}
+
+	report, err := h.integrityService.Start(c.GetUint("userID"), &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrIntegrityRunning) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"code": status, "message": "发起一致性检查失败", "error": err.Error()})
+		return
+	}
+	c.Set("resourceID", report.ID)
+
+	c.JSON(http.StatusAccepted, gin.H{"code": http.StatusAccepted, "message": "一致性检查已开始", "data": report})
+}
@@ -1,10 +1,31 @@
 package handler
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/route"
 	"jing_vue_gin_admin/server/internal/service"
+	"jing_vue_gin_admin/server/pkg/xlsx"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// logExportHeader 日志导出的表头
+var logExportHeader = []string{"ID", "时间", "用户ID", "用户名", "模块", "操作", "资源", "详情", "IP", "用户代理", "状态", "请求ID", "方法", "状态码", "耗时(ms)", "浏览器", "浏览器版本", "操作系统", "操作系统版本", "设备", "国家", "地区", "城市"}
+
+// logExportFlushRows 导出时每写入多少行刷新一次响应
+const logExportFlushRows = 500
+
+const (
+	logStreamReplayBatch = 500              // 断线重连时每批补发的日志数
+	logStreamHeartbeat   = 15 * time.Second // 心跳间隔，防止代理断开空闲连接
+	logStreamRetryMillis = 3000             // 建议客户端的重连间隔
 )
 
 type LogHandler struct {
@@ -71,32 +92,282 @@ func (h *LogHandler) ClearLogs(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "清空成功"})
 }
 
-// GetLogModules 获取日志模块列表
+// GetLogModules 获取日志模块列表，包括路由注册的模块和日志中已有的模块
 func (h *LogHandler) GetLogModules(c *gin.Context) {
-	modules := []string{
-		model.LogModuleUser,
-		model.LogModuleRole,
-		model.LogModuleSystem,
-		model.LogModuleAuth,
+	modules, err := h.logService.GetModules(route.Modules())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取日志模块失败"})
+		return
 	}
-	
 	c.JSON(http.StatusOK, modules)
 }
 
-// GetLogActions 获取日志操作类型列表
+// GetLogActions 获取日志操作类型列表，包括路由注册的操作类型和日志中已有的操作类型
 func (h *LogHandler) GetLogActions(c *gin.Context) {
-	actions := []string{
-		model.LogActionLogin,
-		model.LogActionLogout,
-		model.LogActionCreate,
-		model.LogActionUpdate,
-		model.LogActionDelete,
-		model.LogActionView,
-		model.LogActionExport,
-		model.LogActionImport,
-		model.LogActionEnable,
-		model.LogActionDisable,
+	actions, err := h.logService.GetActions(route.Actions())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取日志操作类型失败"})
+		return
 	}
-	
 	c.JSON(http.StatusOK, actions)
-} 
\ No newline at end of file
+}
+
+// ExportLogs 按筛选条件流式导出日志
+func (h *LogHandler) ExportLogs(c *gin.Context) {
+	var req model.LogExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+	if req.Format == "" {
+		req.Format = model.LogExportCSV
+	}
+
+	var contentType string
+	switch req.Format {
+	case model.LogExportCSV:
+		contentType = "text/csv; charset=utf-8"
+	case model.LogExportJSONL:
+		contentType = "application/x-ndjson; charset=utf-8"
+	case model.LogExportXLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的导出格式"})
+		return
+	}
+
+	filename := fmt.Sprintf("logs_%s.%s", time.Now().Format("20060102_150405"), req.Format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Status(http.StatusOK)
+
+	// 响应头在第一次写入时发送，此后出错只能中断连接
+	var (
+		writeRow func(log *model.SystemLog) error
+		flush    func() error
+		finish   = func() error { return nil }
+	)
+	switch req.Format {
+	case model.LogExportCSV:
+		// 写入BOM，保证Excel打开时中文不乱码
+		if _, err := c.Writer.WriteString("\xEF\xBB\xBF"); err != nil {
+			abortExport(err)
+		}
+		w := csv.NewWriter(c.Writer)
+		if err := w.Write(logExportHeader); err != nil {
+			abortExport(err)
+		}
+		writeRow = func(log *model.SystemLog) error {
+			return w.Write(logExportRecord(log))
+		}
+		flush = func() error {
+			w.Flush()
+			return w.Error()
+		}
+	case model.LogExportJSONL:
+		enc := json.NewEncoder(c.Writer)
+		writeRow = func(log *model.SystemLog) error {
+			return enc.Encode(log)
+		}
+		flush = func() error { return nil }
+	case model.LogExportXLSX:
+		w, err := xlsx.NewWriter(c.Writer, "系统日志")
+		if err != nil {
+			abortExport(err)
+		}
+		if err := w.WriteRow(logExportHeader); err != nil {
+			abortExport(err)
+		}
+		writeRow = func(log *model.SystemLog) error {
+			return w.WriteRow(logExportRecord(log))
+		}
+		flush = w.Flush
+		// 全部写入成功后才结束压缩包，中途出错时不能输出看似完整的文件
+		finish = w.Close
+	}
+
+	rows := 0
+	err := h.logService.ExportLogs(&req.LogFilter, func(log *model.SystemLog) error {
+		if err := writeRow(log); err != nil {
+			return err
+		}
+		rows++
+		if rows%logExportFlushRows == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err == nil {
+		err = finish()
+	}
+	if err != nil {
+		abortExport(err)
+	}
+}
+
+// abortExport 导出中途失败时记录错误并中断连接，客户端会收到不完整的响应而不是截断的文件
+func abortExport(err error) {
+	log.Printf("导出日志失败: %v", err)
+	panic(http.ErrAbortHandler)
+}
+
+// logExportRecord 将日志转换为导出行，CSV和XLSX共用
+func logExportRecord(log *model.SystemLog) []string {
+	status := "成功"
+	if log.Status != 1 {
+		status = "失败"
+	}
+	record := []string{
+		strconv.FormatUint(uint64(log.ID), 10),
+		log.CreatedAt.Format("2006-01-02 15:04:05"),
+		strconv.FormatUint(uint64(log.UserID), 10),
+		log.Username,
+		log.Module,
+		log.Action,
+		log.Resource,
+		log.Detail,
+		log.IP,
+		log.UserAgent,
+		status,
+		log.RequestID,
+		log.Method,
+		strconv.Itoa(log.StatusCode),
+		strconv.FormatInt(log.Duration, 10),
+		log.Browser,
+		log.BrowserVersion,
+		log.OS,
+		log.OSVersion,
+		log.Device,
+		log.Country,
+		log.Region,
+		log.City,
+	}
+	for i, cell := range record {
+		record[i] = spreadsheetText(cell)
+	}
+	return record
+}
+
+// spreadsheetText 用户名、详情、用户代理等字段可被请求方控制，以=、+、-、@、制表符或回车开头时
+// 会被电子表格当作公式执行，前面加单引号按文本显示
+func spreadsheetText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// CreateStreamToken 签发实时日志流令牌，用于EventSource连接
+func (h *LogHandler) CreateStreamToken(c *gin.Context) {
+	token, expiresAt, err := h.logService.IssueStreamToken(c.GetString("sessionID"), c.GetUint("userID"), c.GetString("username"), c.GetString("role"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "签发令牌失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "签发成功",
+		"data": gin.H{
+			"token":      token,
+			"expires_at": expiresAt,
+		},
+	})
+}
+
+// StreamLogs 通过Server-Sent Events实时推送新日志
+func (h *LogHandler) StreamLogs(c *gin.Context) {
+	var req model.LogStreamRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+	if lastEventID := c.GetHeader("Last-Event-ID"); lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Last-Event-ID格式错误"})
+			return
+		}
+		req.LastEventID = uint(id)
+	}
+
+	// 先订阅再读取游标，保证读取与通知之间写入的日志不会遗漏
+	sub := h.logService.SubscribeLogs(req.LogFilter)
+	defer sub.Close()
+
+	lastID := req.LastEventID
+	if lastID == 0 {
+		latest, err := h.logService.LatestLogID()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取日志失败"})
+			return
+		}
+		lastID = latest
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", logStreamRetryMillis)
+	c.Writer.Flush()
+
+	// send 从数据库按ID顺序发送游标之后的日志，并发写入的日志即使通知先后颠倒也不会遗漏
+	send := func() bool {
+		for {
+			logs, err := h.logService.GetLogsAfter(&req.LogFilter, lastID, logStreamReplayBatch)
+			if err != nil {
+				log.Printf("读取新日志失败: %v", err)
+				return false
+			}
+			for i := range logs {
+				if err := writeLogEvent(c, &logs[i]); err != nil {
+					return false
+				}
+				lastID = logs[i].ID
+			}
+			c.Writer.Flush()
+			if len(logs) < logStreamReplayBatch {
+				return true
+			}
+		}
+	}
+	if req.LastEventID > 0 && !send() {
+		return
+	}
+
+	heartbeat := time.NewTicker(logStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case _, ok := <-sub.C:
+			if !ok || !send() {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeLogEvent 以SSE格式写出一条日志，事件ID即日志ID
+func writeLogEvent(c *gin.Context, entry *model.SystemLog) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: log\ndata: %s\n\n", entry.ID, data)
+	return err
+}
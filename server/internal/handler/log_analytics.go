@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogAnalyticsHandler 日志分析处理器
+type LogAnalyticsHandler struct {
+	analyticsService *service.LogAnalyticsService
+}
+
+// NewLogAnalyticsHandler 创建日志分析处理器
+func NewLogAnalyticsHandler() *LogAnalyticsHandler {
+	return &LogAnalyticsHandler{
+		analyticsService: service.NewLogAnalyticsService(),
+	}
+}
+
+// GetFailedLogins 登录失败趋势及按用户、IP的排行
+func (h *LogAnalyticsHandler) GetFailedLogins(c *gin.Context) {
+	var req model.LogAnalyticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	result, err := h.analyticsService.FailedLogins(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取登录失败统计失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetTopUsers 最活跃用户
+func (h *LogAnalyticsHandler) GetTopUsers(c *gin.Context) {
+	var req model.LogAnalyticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	result, err := h.analyticsService.TopUsers(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取活跃用户统计失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetTopEndpoints 调用最多的接口
+func (h *LogAnalyticsHandler) GetTopEndpoints(c *gin.Context) {
+	var req model.LogAnalyticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	result, err := h.analyticsService.TopEndpoints(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取接口调用统计失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetErrorRates 按接口统计错误率
+func (h *LogAnalyticsHandler) GetErrorRates(c *gin.Context) {
+	var req model.LogAnalyticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	result, err := h.analyticsService.ErrorRates(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取接口错误率失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetUnusualHours 非工作时间活动
+func (h *LogAnalyticsHandler) GetUnusualHours(c *gin.Context) {
+	var req model.UnusualHourRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	result, err := h.analyticsService.UnusualHours(&req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidWorkHours) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetFirstSeen 用户首次使用的IP或用户代理
+func (h *LogAnalyticsHandler) GetFirstSeen(c *gin.Context) {
+	var req model.FirstSeenRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	result, err := h.analyticsService.FirstSeen(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取首次出现记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
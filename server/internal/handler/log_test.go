@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/route"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLogModulesIncludeLoggedValues(t *testing.T) {
+	// 注册两个路由，模拟路由表中的模块和操作类型
+	group := gin.New().Group("/api")
+	route.Handle(group, route.Meta{Method: http.MethodPost, Path: "/files", Module: model.LogModuleFile, Action: model.LogActionUpload})
+	route.Handle(group, route.Meta{Method: http.MethodPost, Path: "/login", Module: model.LogModuleAuth, Action: model.LogActionLogin})
+	config.DB.Create(&[]model.SystemLog{
+		{Module: "legacy", Action: "import", Status: 1},
+		{Module: model.LogModuleAuth, Action: model.LogActionLogin, Status: 1},
+	})
+
+	h := NewLogHandler()
+	tests := []struct {
+		name       string
+		handler    func() []string
+		registered []string
+		logged     []string
+	}{
+		{"modules", func() []string { return getStrings(t, h.GetLogModules) }, route.Modules(), []string{"legacy", model.LogModuleAuth}},
+		{"actions", func() []string { return getStrings(t, h.GetLogActions) }, route.Actions(), []string{"import", model.LogActionLogin}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.handler()
+			count := map[string]int{}
+			for _, v := range got {
+				count[v]++
+			}
+			for _, v := range append(tt.registered, tt.logged...) {
+				if count[v] != 1 {
+					t.Errorf("%q appears %d times in %v", v, count[v], got)
+				}
+			}
+			// 已注册的值在前并保持注册顺序
+			if len(tt.registered) < 2 {
+				t.Fatalf("registered = %v", tt.registered)
+			}
+			for i, v := range tt.registered {
+				if got[i] != v {
+					t.Errorf("got[%d] = %q, want registered %q", i, got[i], v)
+					break
+				}
+			}
+		})
+	}
+}
+
+func getStrings(t *testing.T, handler func(c *gin.Context)) []string {
+	t.Helper()
+	w := serveAs(testAdminID, http.MethodGet, "/", "/", nil, nil, handler)
+	var values []string
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &values) != nil {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	return values
+}
+
+// failingWriter 写入失败的响应，模拟客户端断开
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func (w failingWriter) WriteString(string) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestExportLogsAbortsOnWriteError(t *testing.T) {
+	config.DB.Create(&model.SystemLog{Module: model.LogModuleAuth, Action: model.LogActionLogin, Status: 1})
+
+	for _, format := range []string{model.LogExportCSV, model.LogExportJSONL, model.LogExportXLSX} {
+		t.Run(format, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(failingWriter{httptest.NewRecorder()})
+			c.Request = httptest.NewRequest(http.MethodGet, "/logs/export?format="+format, nil)
+			defer func() {
+				if err := recover(); err != http.ErrAbortHandler {
+					t.Errorf("recovered %v, want http.ErrAbortHandler", err)
+				}
+			}()
+			NewLogHandler().ExportLogs(c)
+		})
+	}
+}
+
+func TestExportLogsXLSX(t *testing.T) {
+	config.DB.Create(&model.SystemLog{Module: model.LogModuleAuth, Action: model.LogActionLogin, Status: 1})
+
+	w := serveAs(testAdminID, http.MethodGet, "/logs/export", "/logs/export?format=xlsx", nil, nil, NewLogHandler().ExportLogs)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if _, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len())); err != nil {
+		t.Errorf("export is not a complete xlsx file: %v", err)
+	}
+}
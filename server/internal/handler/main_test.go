@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"jing_vue_gin_admin/server/internal/storage"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 测试用户
+const (
+	testAdminID uint = 1
+	testUserID  uint = 2
+)
+
+// TestMain 使用临时数据库和内存存储运行处理器测试
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	dir, err := os.MkdirTemp("", "handler-test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	code := func() int {
+		defer os.RemoveAll(dir)
+		if err := config.OpenDB(filepath.Join(dir, "test.db")); err != nil {
+			log.Fatal(err)
+		}
+		if err := service.InitStorage(config.StorageConfig{Backend: storage.BackendMemory, Local: config.LocalStorage{Dir: filepath.Join(dir, "uploads")}}); err != nil {
+			log.Fatal(err)
+		}
+		// 分享链接使用固定的签名密钥，不在工作目录中生成密钥文件
+		config.App.Share.Secret = "handler-test-secret"
+		config.DB.Create(&[]model.User{
+			{Base: model.Base{ID: testAdminID}, Username: "admin", Role: "admin"},
+			{Base: model.Base{ID: testUserID}, Username: "user", Role: "user"},
+		})
+		return m.Run()
+	}()
+	os.Exit(code)
+}
+
+// createTestFile 把内容写入内存存储并创建文件记录
+func createTestFile(t *testing.T, name, content, scanStatus string) *model.File {
+	t.Helper()
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+	if err := service.DefaultStorage().Put(context.Background(), hash, bytes.NewReader([]byte(content)), int64(len(content)), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	file := &model.File{
+		FileName:   name,
+		FileSize:   int64(len(content)),
+		FileType:   "txt",
+		MimeType:   "text/plain",
+		FilePath:   hash,
+		Backend:    storage.BackendMemory,
+		ObjectKey:  hash,
+		Hash:       hash,
+		UploadedBy: testUserID,
+		ScanStatus: scanStatus,
+	}
+	if err := config.DB.Create(file).Error; err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// serveAs 以指定用户身份调用处理器
+func serveAs(userID uint, method, route, target string, body io.Reader, header http.Header, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Handle(method, route, func(c *gin.Context) {
+		c.Set("userID", userID)
+		c.Next()
+	}, handler)
+	req := httptest.NewRequest(method, target, body)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
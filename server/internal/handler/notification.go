@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler 站内通知处理器
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+// NewNotificationHandler 创建站内通知处理器
+func NewNotificationHandler() *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: service.NewNotificationService(),
+	}
+}
+
+// GetNotifications 获取当前用户的通知列表
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	var req model.NotificationListRequest
+	req.Page = 1
+	req.PageSize = 10
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	result, err := h.notificationService.GetNotifications(c.GetUint("userID"), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetUnreadCount 获取当前用户的未读通知数
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	count, err := h.notificationService.GetUnreadCount(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取未读通知数失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
+
+// MarkRead 标记通知为已读
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "通知ID格式错误"})
+		return
+	}
+
+	if err := h.notificationService.MarkRead(c.GetUint("userID"), uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已读"})
+}
+
+// MarkAllRead 标记全部通知为已读
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	if err := h.notificationService.MarkAllRead(c.GetUint("userID")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "操作失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已全部标记为已读"})
+}
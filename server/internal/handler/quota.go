@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuotaHandler 存储配额处理器
+type QuotaHandler struct {
+	quotaService *service.QuotaService
+}
+
+// NewQuotaHandler 创建存储配额处理器
+func NewQuotaHandler() *QuotaHandler {
+	return &QuotaHandler{
+		quotaService: service.NewQuotaService(),
+	}
+}
+
+// GetQuotas 获取配额设置列表
+func (h *QuotaHandler) GetQuotas(c *gin.Context) {
+	quotas, err := h.quotaService.GetQuotas()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取配额列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, quotas)
+}
+
+// SetQuota 设置角色或用户的配额
+func (h *QuotaHandler) SetQuota(c *gin.Context) {
+	var req model.QuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	quota, err := h.quotaService.SetQuota(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Set("resourceID", quota.ID)
+
+	c.JSON(http.StatusOK, quota)
+}
+
+// DeleteQuota 删除配额设置
+func (h *QuotaHandler) DeleteQuota(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "配额ID格式错误"})
+		return
+	}
+
+	if err := h.quotaService.DeleteQuota(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// GetReport 列出接近配额上限的用户，threshold为用量比例（0~1），默认0.8
+func (h *QuotaHandler) GetReport(c *gin.Context) {
+	threshold, err := strconv.ParseFloat(c.DefaultQuery("threshold", "0.8"), 64)
+	if err != nil || threshold < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "threshold格式错误"})
+		return
+	}
+
+	items, err := h.quotaService.GetReport(threshold)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取配额报告失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// GetMyUsage 获取当前用户的存储用量和配额
+func (h *QuotaHandler) GetMyUsage(c *gin.Context) {
+	usage, err := h.quotaService.GetUsage(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
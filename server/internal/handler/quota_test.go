@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"mime/multipart"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// createQuotaUser 创建设置了配额的用户
+func createQuotaUser(t *testing.T, username string, maxBytes, maxFiles int64) uint {
+	t.Helper()
+	user := model.User{Username: username, Role: "user"}
+	if err := config.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.NewQuotaService().SetQuota(&model.QuotaRequest{UserID: user.ID, MaxBytes: maxBytes, MaxFiles: maxFiles}); err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+// runConcurrently 并发执行n次fn，返回成功次数，失败只允许是超出配额
+func runConcurrently(t *testing.T, n int, code string, fn func() error) int {
+	t.Helper()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := fn()
+			mu.Lock()
+			defer mu.Unlock()
+			var uploadErr *service.UploadError
+			switch {
+			case err == nil:
+				succeeded++
+			case !errors.As(err, &uploadErr) || uploadErr.Code != code:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	return succeeded
+}
+
+func TestQuotaConcurrentUploads(t *testing.T) {
+	dir := config.App.Storage.Tus.Dir
+	config.App.Storage.Tus.Dir = t.TempDir()
+	defer func() { config.App.Storage.Tus.Dir = dir }()
+
+	// 两个用户同时上传，各自的配额互不影响，也不会因数据库写锁冲突而失败
+	users := []uint{createQuotaUser(t, "quota-files-a", 0, 3), createQuotaUser(t, "quota-files-b", 0, 3)}
+	metadata := "filename " + base64.StdEncoding.EncodeToString([]byte("q.txt"))
+	var n int32
+	got := runConcurrently(t, 20, service.UploadErrQuotaFiles, func() error {
+		userID := users[atomic.AddInt32(&n, 1)%2]
+		_, err := service.NewTusService().CreateUpload(userID, 10, metadata)
+		return err
+	})
+	if got != 6 {
+		t.Errorf("%d uploads created, want 6", got)
+	}
+}
+
+func TestQuotaConcurrentCopies(t *testing.T) {
+	source := createTestFile(t, "source.txt", "0123456789", model.ScanStatusClean)
+	// 管理员没有配额，先复制一次为历史文件登记存储对象
+	if _, err := service.NewFolderService().CopyFiles(testAdminID, &model.FileMoveRequest{IDs: []uint{source.ID}}); err != nil {
+		t.Fatal(err)
+	}
+
+	userID := createQuotaUser(t, "quota-bytes", 25, 0)
+	got := runConcurrently(t, 10, service.UploadErrQuotaBytes, func() error {
+		_, err := service.NewFolderService().CopyFiles(userID, &model.FileMoveRequest{IDs: []uint{source.ID}})
+		return err
+	})
+	if got != 2 {
+		t.Errorf("%d copies created, want 2", got)
+	}
+	usage, err := service.NewQuotaService().GetUsage(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.UsedBytes != 20 || usage.UsedFiles != 2 {
+		t.Errorf("usage = %d bytes, %d files", usage.UsedBytes, usage.UsedFiles)
+	}
+}
+
+func TestQuotaCountsTrash(t *testing.T) {
+	source := createTestFile(t, "trash-source.txt", "abcdefghij", model.ScanStatusClean)
+	if _, err := service.NewFolderService().CopyFiles(testAdminID, &model.FileMoveRequest{IDs: []uint{source.ID}}); err != nil {
+		t.Fatal(err)
+	}
+
+	userID := createQuotaUser(t, "quota-trash", 25, 2)
+	var copies []model.File
+	for i := 0; i < 2; i++ {
+		copied, err := service.NewFolderService().CopyFiles(userID, &model.FileMoveRequest{IDs: []uint{source.ID}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		copies = append(copies, copied...)
+	}
+	if err := service.NewFileService().DeleteFile(copies[0].ID, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 回收站中的文件仍占用空间，删除后不能再上传同样大小的内容
+	_, err := service.NewFolderService().CopyFiles(userID, &model.FileMoveRequest{IDs: []uint{source.ID}})
+	var uploadErr *service.UploadError
+	if !errors.As(err, &uploadErr) || uploadErr.Code != service.UploadErrQuotaBytes {
+		t.Fatalf("copy after delete = %v, want %s", err, service.UploadErrQuotaBytes)
+	}
+	usage, err := service.NewQuotaService().GetUsage(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.UsedBytes != 20 || usage.UsedFiles != 1 {
+		t.Errorf("usage = %d bytes, %d files", usage.UsedBytes, usage.UsedFiles)
+	}
+
+	// 恢复不增加占用空间，只校验文件数
+	if _, err := service.NewTrashService().Restore(userID, copies[0].ID); err != nil {
+		t.Fatalf("restore = %v", err)
+	}
+}
+
+func TestQuotaCountsVersions(t *testing.T) {
+	userID := createQuotaUser(t, "quota-versions", 25, 0)
+	file := createTestFile(t, "versioned.txt", "version 01", model.ScanStatusClean)
+	if err := config.DB.Model(file).Update("uploaded_by", userID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	upload := func(content string) int {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		part, _ := mw.CreateFormFile("file", "versioned.txt")
+		part.Write([]byte(content))
+		mw.Close()
+		w := serveAs(userID, http.MethodPost, "/files/:id/versions", fmt.Sprintf("/files/%d/versions", file.ID),
+			body, http.Header{"Content-Type": {mw.FormDataContentType()}}, NewVersionHandler().UploadVersion)
+		return w.Code
+	}
+
+	// 旧版本保留存储对象，新版本按完整大小计入：10 + 10 = 20
+	if code := upload("version 02"); code != http.StatusOK {
+		t.Fatalf("second version = %d", code)
+	}
+	// 大小相同也不能超出配额：20 + 10 > 25
+	if code := upload("version 03"); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("third version = %d, want 413", code)
+	}
+	usage, err := service.NewQuotaService().GetUsage(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.UsedBytes != 20 || usage.UsedFiles != 1 {
+		t.Errorf("usage = %d bytes, %d files", usage.UsedBytes, usage.UsedFiles)
+	}
+}
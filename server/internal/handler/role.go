@@ -62,6 +62,7 @@ func (h *RoleHandler) CreateRole(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建角色失败"})
 		return
 	}
+	c.Set("resourceID", role.ID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "创建角色成功"})
 }
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"jing_vue_gin_admin/server/internal/route"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteHandler 路由清单处理器
+type RouteHandler struct{}
+
+// NewRouteHandler 创建路由清单处理器
+func NewRouteHandler() *RouteHandler {
+	return &RouteHandler{}
+}
+
+// GetRoutes 获取已注册的路由及其模块、操作类型和所需权限
+func (h *RouteHandler) GetRoutes(c *gin.Context) {
+	c.JSON(http.StatusOK, route.All())
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScanHandler 病毒扫描处理器
+type ScanHandler struct {
+	scanService *service.ScanService
+}
+
+// NewScanHandler 创建病毒扫描处理器
+func NewScanHandler() *ScanHandler {
+	return &ScanHandler{
+		scanService: service.NewScanService(),
+	}
+}
+
+// RescanFile 重新扫描单个文件，扫描在后台进行，完成前按原扫描状态判断能否下载
+func (h *ScanHandler) RescanFile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "无效的文件ID"})
+		return
+	}
+	if _, ok := requireFile(c, uint(id), model.ACLView); !ok {
+		return
+	}
+
+	file, err := h.scanService.Rescan(uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrScanDisabled) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"code": status, "message": "重新扫描失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"code": http.StatusAccepted, "message": "已加入扫描队列", "data": file})
+}
+
+// RescanAll 重新扫描全部文件或指定扫描状态的文件，如病毒库更新后重新扫描已通过的文件
+func (h *ScanHandler) RescanAll(c *gin.Context) {
+	var req model.FileScanRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+			return
+		}
+	}
+
+	count, err := h.scanService.RescanAll(req.Status)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrScanDisabled) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"code": status, "message": "重新扫描失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"code": http.StatusAccepted, "message": "已加入扫描队列", "data": gin.H{"count": count}})
+}
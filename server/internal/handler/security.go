@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHandler 当前用户的账号安全处理器
+type SecurityHandler struct {
+	securityService *service.SecurityService
+}
+
+// NewSecurityHandler 创建账号安全处理器
+func NewSecurityHandler() *SecurityHandler {
+	return &SecurityHandler{
+		securityService: service.NewSecurityService(),
+	}
+}
+
+// GetLoginHistory 获取当前用户的登录记录
+func (h *SecurityHandler) GetLoginHistory(c *gin.Context) {
+	var req model.LoginHistoryRequest
+	req.Page = 1
+	req.PageSize = 10
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	resp, err := h.securityService.GetLoginHistory(c.GetUint("userID"), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取登录记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetSessions 获取当前用户的在线会话
+func (h *SecurityHandler) GetSessions(c *gin.Context) {
+	sessions, err := h.securityService.GetSessions(c.GetUint("userID"), c.GetString("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取会话列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession 注销指定会话
+func (h *SecurityHandler) RevokeSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "会话ID格式错误"})
+		return
+	}
+
+	if err := h.securityService.RevokeSession(c.GetUint("userID"), uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "会话已注销"})
+}
+
+// RevokeOtherSessions 注销除当前会话外的全部会话
+func (h *SecurityHandler) RevokeOtherSessions(c *gin.Context) {
+	count, err := h.securityService.RevokeOtherSessions(c.GetUint("userID"), c.GetString("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注销会话失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "其他会话已注销", "count": count})
+}
+
+// GetDevices 获取当前用户登录过的设备
+func (h *SecurityHandler) GetDevices(c *gin.Context) {
+	devices, err := h.securityService.GetDevices(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取设备列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+// DeleteDevice 移除设备记录
+func (h *SecurityHandler) DeleteDevice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "设备ID格式错误"})
+		return
+	}
+
+	if err := h.securityService.DeleteDevice(c.GetUint("userID"), uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "设备已移除"})
+}
+
+// Logout 退出登录，注销当前会话
+func (h *SecurityHandler) Logout(c *gin.Context) {
+	if err := h.securityService.RevokeCurrentSession(c.GetUint("userID"), c.GetString("sessionID")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "退出登录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已退出登录"})
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"errors"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShareHandler 文件分享链接处理器
+type ShareHandler struct {
+	shareService *service.ShareService
+	fileService  *service.FileService
+}
+
+// NewShareHandler 创建分享链接处理器
+func NewShareHandler() *ShareHandler {
+	return &ShareHandler{
+		shareService: service.NewShareService(),
+		fileService:  service.NewFileService(),
+	}
+}
+
+// CreateShare 创建分享链接
+func (h *ShareHandler) CreateShare(c *gin.Context) {
+	var req model.ShareCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+		return
+	}
+
+	// 分享链接可以下载文件，需要有下载权限
+	if _, ok := requireFile(c, req.FileID, model.ACLDownload); !ok {
+		return
+	}
+
+	share, err := h.shareService.CreateShare(c.GetUint("userID"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "创建分享链接失败", "error": err.Error()})
+		return
+	}
+	c.Set("resourceID", share.ID)
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "创建分享链接成功", "data": share})
+}
+
+// GetShares 获取分享链接列表
+func (h *ShareHandler) GetShares(c *gin.Context) {
+	req := model.ShareListRequest{Page: 1, PageSize: 10}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+		return
+	}
+
+	result, err := h.shareService.GetShares(c.GetUint("userID"), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "获取分享链接列表失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "获取分享链接列表成功", "data": result})
+}
+
+// RevokeShare 撤销分享链接
+func (h *ShareHandler) RevokeShare(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "无效的分享链接ID"})
+		return
+	}
+
+	if err := h.shareService.RevokeShare(c.GetUint("userID"), uint(id)); err != nil {
+		status := shareErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "撤销分享链接失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "撤销分享链接成功"})
+}
+
+// GetShareInfo 查看分享链接对应的文件信息，无需登录
+func (h *ShareHandler) GetShareInfo(c *gin.Context) {
+	info, err := h.shareService.GetShareInfo(c.Param("token"))
+	if err != nil {
+		status := shareErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "分享链接无效", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "获取分享信息成功", "data": info})
+}
+
+// DownloadShare 通过分享链接下载文件，无需登录
+// 访问密码通过X-Share-Password请求头或password查询参数提供
+func (h *ShareHandler) DownloadShare(c *gin.Context) {
+	share, file, err := h.shareService.Resolve(c.Param("token"))
+	if share != nil {
+		c.Set("resourceID", share.ID)
+	}
+	// 次数是否用完由RecordDownload原子地检查，只有持有续传凭证的续传请求可以跳过
+	if errors.Is(err, service.ErrShareExhausted) {
+		err = nil
+	}
+	if err == nil {
+		password := c.GetHeader("X-Share-Password")
+		if password == "" {
+			password = c.Query("password")
+		}
+		err = h.shareService.CheckPassword(share, password)
+	}
+	if err == nil {
+		err = service.CheckScan(file)
+	}
+	// 续传和媒体拖动产生的范围请求需带有之前计数下载时签发的凭证才不重复计数，否则按新下载计数
+	if err == nil {
+		resume := continuesDownload(c.Request, file.FileSize, file.Hash) &&
+			h.shareService.CheckSession(share, file, shareSession(c))
+		if !resume {
+			if err = h.shareService.RecordDownload(share); err == nil {
+				session, maxAge := h.shareService.IssueSession(share, file)
+				c.SetCookie(shareSessionCookie, session, maxAge, "/api/s/"+c.Param("token"), "", c.Request.TLS != nil, true)
+				c.Header("X-Share-Session", session)
+			}
+		}
+	}
+	if err != nil {
+		status := shareErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "下载失败", "error": err.Error()})
+		return
+	}
+
+	content, _, err := h.fileService.OpenContent(file)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "文件不存在", "error": err.Error()})
+		return
+	}
+	defer content.Close()
+
+	serveDownload(c, content, download{
+		Name:         file.FileName,
+		MimeType:     file.MimeType,
+		Hash:         file.Hash,
+		ModTime:      file.UpdatedAt,
+		CacheControl: "no-store",
+	})
+}
+
+// shareSessionCookie 续传凭证的Cookie名称
+const shareSessionCookie = "share_session"
+
+// shareSession 请求携带的续传凭证，浏览器使用Cookie，其他客户端可使用X-Share-Session请求头
+func shareSession(c *gin.Context) string {
+	if session := c.GetHeader("X-Share-Session"); session != "" {
+		return session
+	}
+	session, _ := c.Cookie(shareSessionCookie)
+	return session
+}
+
+// shareErrorStatus 分享链接错误对应的HTTP状态码
+func shareErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrShareNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrShareExpired), errors.Is(err, service.ErrShareRevoked), errors.Is(err, service.ErrShareExhausted):
+		return http.StatusGone
+	case errors.Is(err, service.ErrSharePassword):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrShareForbidden):
+		return http.StatusForbidden
+	case scanErrorStatus(err) != 0:
+		return scanErrorStatus(err)
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"encoding/base64"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createShare 为文件创建分享链接
+func createShare(t *testing.T, req model.ShareCreateRequest) *model.FileShare {
+	t.Helper()
+	share, err := service.NewShareService().CreateShare(testUserID, &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return share
+}
+
+// downloadShare 不登录通过分享链接下载
+func downloadShare(token string, header http.Header) (int, http.Header, string) {
+	w := serveAs(0, http.MethodGet, "/s/:token/download", "/s/"+token+"/download", nil, header, NewShareHandler().DownloadShare)
+	return w.Code, w.Header(), w.Body.String()
+}
+
+func TestShareTokenTampered(t *testing.T) {
+	file := createTestFile(t, "shared.txt", "shared content", model.ScanStatusClean)
+	share := createShare(t, model.ShareCreateRequest{FileID: file.ID})
+	other := createShare(t, model.ShareCreateRequest{FileID: file.ID})
+
+	payload, sig, _ := strings.Cut(share.Token, ".")
+	_, otherSig, _ := strings.Cut(other.Token, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte("1.0000000000000000"))
+	flipped := []byte(sig)
+	if flipped[0] == 'A' {
+		flipped[0] = 'B'
+	} else {
+		flipped[0] = 'A'
+	}
+
+	for name, token := range map[string]string{
+		"modified signature":      payload + "." + string(flipped),
+		"signature of other link": payload + "." + otherSig,
+		"forged payload":          forged + "." + sig,
+		"missing signature":       payload,
+		"garbage":                 "not-a-token",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if code, _, body := downloadShare(token, nil); code != http.StatusNotFound {
+				t.Errorf("status = %d, want 404: %s", code, body)
+			}
+		})
+	}
+	if code, _, body := downloadShare(share.Token, nil); code != http.StatusOK || body != "shared content" {
+		t.Errorf("valid token = %d %q", code, body)
+	}
+}
+
+func TestShareExpiredAndRevoked(t *testing.T) {
+	file := createTestFile(t, "gone.txt", "gone content", model.ScanStatusClean)
+	expired := createShare(t, model.ShareCreateRequest{FileID: file.ID})
+	config.DB.Model(expired).Update("expires_at", time.Now().Add(-time.Minute))
+	revoked := createShare(t, model.ShareCreateRequest{FileID: file.ID})
+	if err := service.NewShareService().RevokeShare(testUserID, revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"expired": expired.Token, "revoked": revoked.Token} {
+		t.Run(name, func(t *testing.T) {
+			if code, _, body := downloadShare(token, nil); code != http.StatusGone {
+				t.Errorf("status = %d, want 410: %s", code, body)
+			}
+		})
+	}
+}
+
+func TestSharePassword(t *testing.T) {
+	file := createTestFile(t, "secret.txt", "secret content", model.ScanStatusClean)
+	share := createShare(t, model.ShareCreateRequest{FileID: file.ID, Password: "open sesame"})
+
+	tests := []struct {
+		name   string
+		query  string
+		header http.Header
+		want   int
+	}{
+		{"missing", "", nil, http.StatusUnauthorized},
+		{"wrong", "", http.Header{"X-Share-Password": {"wrong"}}, http.StatusUnauthorized},
+		{"header", "", http.Header{"X-Share-Password": {"open sesame"}}, http.StatusOK},
+		{"query", "?password=open+sesame", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(0, http.MethodGet, "/s/:token/download", "/s/"+share.Token+"/download"+tt.query,
+				nil, tt.header, NewShareHandler().DownloadShare)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+	// 密码错误不占用下载次数
+	var got model.FileShare
+	config.DB.First(&got, share.ID)
+	if got.Downloads != 2 {
+		t.Errorf("downloads = %d, want 2", got.Downloads)
+	}
+}
+
+func TestShareMaxDownloadsWithResume(t *testing.T) {
+	file := createTestFile(t, "limited.txt", "0123456789", model.ScanStatusClean)
+	share := createShare(t, model.ShareCreateRequest{FileID: file.ID, MaxDownloads: 1})
+
+	code, header, _ := downloadShare(share.Token, http.Header{"Range": {"bytes=0-4"}})
+	if code != http.StatusPartialContent {
+		t.Fatalf("first download = %d", code)
+	}
+	session := header.Get("X-Share-Session")
+	if session == "" {
+		t.Fatal("counted download should issue a resume session")
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		// 带凭证的续传不计数，次数用完后也可继续
+		{"resume with session", http.Header{"Range": {"bytes=5-"}, "X-Share-Session": {session}}, http.StatusPartialContent},
+		{"resume with session cookie", http.Header{"Range": {"bytes=8-"}, "Cookie": {shareSessionCookie + "=" + session}}, http.StatusPartialContent},
+		// 没有凭证的范围请求按新下载计数
+		{"resume without session", http.Header{"Range": {"bytes=5-"}}, http.StatusGone},
+		{"forged session", http.Header{"Range": {"bytes=5-"}, "X-Share-Session": {session + "x"}}, http.StatusGone},
+		// 从头下载即使带有凭证也按新下载计数
+		{"full download with session", http.Header{"X-Share-Session": {session}}, http.StatusGone},
+		{"range from zero with session", http.Header{"Range": {"bytes=0-"}, "X-Share-Session": {session}}, http.StatusGone},
+		{"mismatched If-Range", http.Header{"Range": {"bytes=5-"}, "If-Range": {`"other"`}, "X-Share-Session": {session}}, http.StatusGone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _, body := downloadShare(share.Token, tt.header); code != tt.want {
+				t.Errorf("status = %d, want %d: %s", code, tt.want, body)
+			}
+		})
+	}
+
+	var got model.FileShare
+	config.DB.First(&got, share.ID)
+	if got.Downloads != 1 {
+		t.Errorf("downloads = %d, want 1", got.Downloads)
+	}
+}
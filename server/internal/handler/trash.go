@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashHandler 回收站处理器
+type TrashHandler struct {
+	trashService *service.TrashService
+}
+
+// NewTrashHandler 创建回收站处理器
+func NewTrashHandler() *TrashHandler {
+	return &TrashHandler{
+		trashService: service.NewTrashService(),
+	}
+}
+
+// GetTrash 获取回收站中的文件列表
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+	var req model.TrashListRequest
+	req.Page = 1
+	req.PageSize = 10
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+		return
+	}
+
+	resp, err := h.trashService.GetTrash(c.GetUint("userID"), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "获取回收站失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "获取回收站成功", "data": resp})
+}
+
+// RestoreFile 从回收站恢复文件
+func (h *TrashHandler) RestoreFile(c *gin.Context) {
+	id, ok := trashIDParam(c)
+	if !ok {
+		return
+	}
+
+	file, err := h.trashService.Restore(c.GetUint("userID"), id)
+	var uploadErr *service.UploadError
+	if errors.As(err, &uploadErr) {
+		c.JSON(uploadErr.Status, gin.H{"code": uploadErr.Status, "message": "恢复文件失败", "error": uploadErr.Message, "error_code": uploadErr.Code})
+		return
+	}
+	if err != nil {
+		status := trashErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "恢复文件失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "恢复文件成功", "data": file})
+}
+
+// PurgeFile 彻底删除回收站中的文件，不可恢复
+func (h *TrashHandler) PurgeFile(c *gin.Context) {
+	id, ok := trashIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.trashService.Purge(c.GetUint("userID"), id); err != nil {
+		status := trashErrorStatus(err)
+		c.JSON(status, gin.H{"code": status, "message": "彻底删除文件失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "彻底删除文件成功"})
+}
+
+// EmptyTrash 清空回收站，管理员清空全部文件，其他用户清空自己上传的文件，以及自己删除且仍有查看权限的文件
+func (h *TrashHandler) EmptyTrash(c *gin.Context) {
+	purged, err := h.trashService.Empty(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "清空回收站失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "清空回收站成功", "data": gin.H{"purged": purged}})
+}
+
+// trashIDParam 解析路径中的文件ID
+func trashIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "无效的文件ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// trashErrorStatus 回收站错误对应的HTTP状态码
+func trashErrorStatus(err error) int {
+	if errors.Is(err, service.ErrNotInTrash) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tusContentType PATCH请求体的内容类型
+const tusContentType = "application/offset+octet-stream"
+
+// TusHandler tus断点续传处理器，协议说明见 https://tus.io/protocols/resumable-upload
+type TusHandler struct {
+	tusService *service.TusService
+}
+
+// NewTusHandler 创建断点续传处理器
+func NewTusHandler() *TusHandler {
+	return &TusHandler{
+		tusService: service.NewTusService(),
+	}
+}
+
+// Options 返回tus协议的能力声明，无需登录即可查询
+func (h *TusHandler) Options(c *gin.Context) {
+	c.Header("Tus-Resumable", service.TusVersion)
+	c.Header("Tus-Version", service.TusVersion)
+	c.Header("Tus-Extension", service.TusExtensions)
+	if max := service.TusMaxSize(); max > 0 {
+		c.Header("Tus-Max-Size", strconv.FormatInt(max, 10))
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Resumable 校验Tus-Resumable请求头，所有响应都带上协议版本
+func (h *TusHandler) Resumable(c *gin.Context) {
+	c.Header("Tus-Resumable", service.TusVersion)
+	if c.GetHeader("Tus-Resumable") != service.TusVersion {
+		c.Header("Tus-Version", service.TusVersion)
+		c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"code": http.StatusPreconditionFailed, "message": "不支持的tus协议版本"})
+		return
+	}
+	c.Next()
+}
+
+// CreateUpload 创建上传任务，请求体不为空时同时写入第一个数据块
+func (h *TusHandler) CreateUpload(c *gin.Context) {
+	if c.GetHeader("Upload-Defer-Length") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "不支持延迟声明文件大小"})
+		return
+	}
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "Upload-Length无效"})
+		return
+	}
+
+	upload, err := h.tusService.CreateUpload(c.GetUint("userID"), length, c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		h.uploadError(c, err)
+		return
+	}
+	c.Header("Location", strings.TrimRight(c.Request.URL.Path, "/")+"/"+upload.UploadID)
+
+	var file *model.File
+	if c.ContentType() == tusContentType && c.Request.ContentLength != 0 {
+		upload, file, err = h.tusService.WriteChunk(c.GetUint("userID"), upload.UploadID, 0, c.Request.Body)
+		if err != nil {
+			h.uploadError(c, err)
+			return
+		}
+	} else if length == 0 {
+		// 空文件无需传输数据，创建后立即完成
+		upload, file, err = h.tusService.WriteChunk(c.GetUint("userID"), upload.UploadID, 0, http.NoBody)
+		if err != nil {
+			h.uploadError(c, err)
+			return
+		}
+	}
+
+	h.uploadHeaders(c, upload, file)
+	c.Status(http.StatusCreated)
+}
+
+// GetOffset 查询已接收的字节数，客户端据此续传
+func (h *TusHandler) GetOffset(c *gin.Context) {
+	upload, err := h.tusService.GetUpload(c.GetUint("userID"), c.Param("id"))
+	if err != nil {
+		h.uploadError(c, err)
+		return
+	}
+	h.uploadHeaders(c, upload, nil)
+	if upload.Metadata != "" {
+		c.Header("Upload-Metadata", upload.Metadata)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+// WriteChunk 从Upload-Offset处追加数据块，全部接收后生成文件记录
+func (h *TusHandler) WriteChunk(c *gin.Context) {
+	if c.ContentType() != tusContentType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"code": http.StatusUnsupportedMediaType, "message": "Content-Type必须为" + tusContentType})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "Upload-Offset无效"})
+		return
+	}
+
+	upload, file, err := h.tusService.WriteChunk(c.GetUint("userID"), c.Param("id"), offset, c.Request.Body)
+	if err != nil {
+		if upload != nil {
+			c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		}
+		h.uploadError(c, err)
+		return
+	}
+
+	h.uploadHeaders(c, upload, file)
+	c.Status(http.StatusNoContent)
+}
+
+// Terminate 终止上传任务，删除已接收的数据
+func (h *TusHandler) Terminate(c *gin.Context) {
+	if err := h.tusService.Terminate(c.GetUint("userID"), c.Param("id")); err != nil {
+		h.uploadError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// uploadHeaders 写入上传进度相关的响应头，上传完成时记录生成的文件ID
+func (h *TusHandler) uploadHeaders(c *gin.Context, upload *model.Upload, file *model.File) {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	if upload.FileID != 0 {
+		c.Header("Upload-File-Id", strconv.FormatUint(uint64(upload.FileID), 10))
+	} else {
+		c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+
+	// 只有生成文件记录的请求才记录上传日志，中间的数据块不记录
+	if file != nil {
+		c.Set("resourceID", file.ID)
+	} else {
+		c.Set("skipOperationLog", true)
+	}
+}
+
+// uploadError 将上传错误转换为tus协议约定的状态码
+func (h *TusHandler) uploadError(c *gin.Context, err error) {
+	var uploadErr *service.UploadError
+	if errors.As(err, &uploadErr) {
+		c.JSON(uploadErr.Status, gin.H{"code": uploadErr.Status, "message": "断点续传失败", "error": uploadErr.Message, "error_code": uploadErr.Code})
+		return
+	}
+
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrUploadExpired):
+		status = http.StatusGone
+	case errors.Is(err, service.ErrUploadOffset), errors.Is(err, service.ErrUploadFinished):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrUploadTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUploadMetadata):
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{"code": status, "message": "断点续传失败", "error": err.Error()})
+}
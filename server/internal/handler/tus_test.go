@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/base64"
+	"io"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tusServer 按路由配置注册断点续传接口，请求以testUserID身份处理
+func tusServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	dir := config.App.Storage.Tus.Dir
+	config.App.Storage.Tus.Dir = t.TempDir()
+	t.Cleanup(func() { config.App.Storage.Tus.Dir = dir })
+
+	h := NewTusHandler()
+	r := gin.New()
+	tus := r.Group("/api/files/tus", func(c *gin.Context) { c.Set("userID", testUserID) }, h.Resumable)
+	tus.POST("", h.CreateUpload)
+	tus.HEAD("/:id", h.GetOffset)
+	tus.PATCH("/:id", h.WriteChunk)
+	tus.DELETE("/:id", h.Terminate)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func tusRequest(t *testing.T, method, url string, header map[string]string, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Tus-Resumable", service.TusVersion)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp
+}
+
+// createTusUpload 创建上传任务，返回上传地址
+func createTusUpload(t *testing.T, srv *httptest.Server, name string, length int) string {
+	t.Helper()
+	resp := tusRequest(t, http.MethodPost, srv.URL+"/api/files/tus", map[string]string{
+		"Upload-Length":   strconv.Itoa(length),
+		"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte(name)),
+	}, "")
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Location") == "" {
+		t.Fatalf("create upload: status %d, location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	return srv.URL + resp.Header.Get("Location")
+}
+
+func patchChunk(t *testing.T, url string, offset int, data string) *http.Response {
+	return tusRequest(t, http.MethodPatch, url, map[string]string{
+		"Content-Type":  tusContentType,
+		"Upload-Offset": strconv.Itoa(offset),
+	}, data)
+}
+
+func TestTusResumeUpload(t *testing.T) {
+	srv := tusServer(t)
+	url := createTusUpload(t, srv, "resume.txt", 11)
+
+	if resp := patchChunk(t, url, 0, "hello"); resp.StatusCode != http.StatusNoContent || resp.Header.Get("Upload-Offset") != "5" {
+		t.Fatalf("first chunk: status %d, offset %s", resp.StatusCode, resp.Header.Get("Upload-Offset"))
+	}
+
+	// 偏移量与已接收的字节数不一致时返回409和当前偏移量
+	if resp := patchChunk(t, url, 3, "lo world"); resp.StatusCode != http.StatusConflict || resp.Header.Get("Upload-Offset") != "5" {
+		t.Fatalf("mismatched offset: status %d, offset %s", resp.StatusCode, resp.Header.Get("Upload-Offset"))
+	}
+
+	// 连接中断后通过HEAD查询偏移量续传
+	resp := tusRequest(t, http.MethodHead, url, nil, "")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Upload-Offset") != "5" || resp.Header.Get("Upload-Length") != "11" {
+		t.Fatalf("head: status %d, offset %s, length %s", resp.StatusCode, resp.Header.Get("Upload-Offset"), resp.Header.Get("Upload-Length"))
+	}
+	if resp.Header.Get("Cache-Control") != "no-store" || resp.Header.Get("Upload-Expires") == "" {
+		t.Errorf("head should not be cached and should report expiry")
+	}
+
+	resp = patchChunk(t, url, 5, " world")
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Upload-Offset") != "11" {
+		t.Fatalf("last chunk: status %d, offset %s", resp.StatusCode, resp.Header.Get("Upload-Offset"))
+	}
+	fileID, err := strconv.ParseUint(resp.Header.Get("Upload-File-Id"), 10, 32)
+	if err != nil {
+		t.Fatalf("Upload-File-Id missing: %v", err)
+	}
+	file, err := service.NewFileService().GetFileByID(uint(fileID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, _, err := service.NewFileService().OpenFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello world" || file.FileName != "resume.txt" || file.UploadedBy != testUserID {
+		t.Errorf("file %+v with content %q", file, data)
+	}
+
+	// 已完成的任务不再接收数据
+	if resp := patchChunk(t, url, 11, "!"); resp.StatusCode != http.StatusConflict {
+		t.Errorf("patch after finish: status %d", resp.StatusCode)
+	}
+}
+
+func TestTusExpiredUpload(t *testing.T) {
+	srv := tusServer(t)
+	url := createTusUpload(t, srv, "expired.txt", 10)
+	if resp := patchChunk(t, url, 0, "abc"); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("chunk: status %d", resp.StatusCode)
+	}
+	uploadID := url[strings.LastIndex(url, "/")+1:]
+	config.DB.Model(&model.Upload{}).Where("upload_id = ?", uploadID).Update("expires_at", time.Now().Add(-time.Minute))
+
+	if resp := tusRequest(t, http.MethodHead, url, nil, ""); resp.StatusCode != http.StatusGone {
+		t.Errorf("head expired: status %d", resp.StatusCode)
+	}
+	if resp := patchChunk(t, url, 3, "def"); resp.StatusCode != http.StatusGone {
+		t.Errorf("patch expired: status %d", resp.StatusCode)
+	}
+
+	// 未过期的任务不受清理影响
+	active := createTusUpload(t, srv, "active.txt", 10)
+	n, err := service.NewTusService().CleanupExpiredUploads()
+	if err != nil || n != 1 {
+		t.Fatalf("cleanup removed %d uploads: %v", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(config.App.Storage.Tus.Dir, uploadID)); !os.IsNotExist(err) {
+		t.Errorf("temporary file should be removed")
+	}
+	if resp := tusRequest(t, http.MethodHead, url, nil, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("head after cleanup: status %d", resp.StatusCode)
+	}
+	if resp := tusRequest(t, http.MethodHead, active, nil, ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("active upload after cleanup: status %d", resp.StatusCode)
+	}
+}
+
+func TestTusTerminateAndVersion(t *testing.T) {
+	srv := tusServer(t)
+	url := createTusUpload(t, srv, "terminate.txt", 10)
+
+	req, _ := http.NewRequest(http.MethodHead, url, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("missing Tus-Resumable: status %d", resp.StatusCode)
+	}
+
+	if resp := tusRequest(t, http.MethodDelete, url, nil, ""); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("terminate: status %d", resp.StatusCode)
+	}
+	if resp := tusRequest(t, http.MethodHead, url, nil, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("head after terminate: status %d", resp.StatusCode)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"jing_vue_gin_admin/server/internal/model"
 	"jing_vue_gin_admin/server/internal/service"
 	"net/http"
+	"net/mail"
 	"strconv"
 )
 
@@ -25,11 +26,13 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.userService.Login(&req)
+	resp, err := h.userService.Login(&req, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
+	// 供登录日志记录用户ID
+	c.Set("userID", resp.User.ID)
 
 	c.JSON(http.StatusOK, resp)
 }
@@ -46,6 +49,8 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		Password: req.Password,
 		Nickname: req.Nickname,
 		Role:     req.Role,
+		Email:    req.Email,
+		Department: req.Department,
 		Status:   1, // 默认启用
 	}
 
@@ -53,6 +58,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败"})
 		return
 	}
+	c.Set("resourceID", user.ID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "创建用户成功"})
 }
@@ -98,6 +104,10 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
 		return
 	}
+	if !validEmail(req.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "邮箱格式错误"})
+		return
+	}
 
 	if err := h.userService.UpdateUser(uint(id), &req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -137,15 +147,20 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	var profileData struct {
 		Nickname string `json:"nickname" binding:"required"`
 		Avatar   string `json:"avatar"`
+		Email    *string `json:"email"` // 未提供时保持不变，空字符串表示清除
 	}
 
 	if err := c.ShouldBindJSON(&profileData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
 		return
 	}
+	if !validEmail(profileData.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "邮箱格式错误"})
+		return
+	}
 
 	// 更新用户个人信息
-	if err := h.userService.UpdateProfile(userID.(uint), profileData.Nickname, profileData.Avatar); err != nil {
+	if err := h.userService.UpdateProfile(userID.(uint), profileData.Nickname, profileData.Avatar, profileData.Email); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新个人信息失败"})
 		return
 	}
@@ -258,4 +273,13 @@ func (h *UserHandler) GetCurrentUser(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, user)
-} 
\ No newline at end of file
+} 
+
+// validEmail 未提供或为空字符串（清除邮箱）时通过，否则必须是不带显示名称的邮箱地址
+func validEmail(email *string) bool {
+	if email == nil || *email == "" {
+		return true
+	}
+	addr, err := mail.ParseAddress(*email)
+	return err == nil && addr.Address == *email
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpdateEmail(t *testing.T) {
+	user := model.User{Username: "email-user", Nickname: "email", Role: "user", Email: "old@example.com"}
+	if err := config.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	h := NewUserHandler()
+
+	tests := []struct {
+		name      string
+		body      string
+		wantCode  int
+		wantEmail string
+	}{
+		{"set", `{"nickname":"email","role":"user","email":"new@example.com"}`, http.StatusOK, "new@example.com"},
+		{"invalid", `{"nickname":"email","role":"user","email":"not-an-email"}`, http.StatusBadRequest, "new@example.com"},
+		{"omitted keeps the email", `{"nickname":"email","role":"user"}`, http.StatusOK, "new@example.com"},
+		{"empty clears the email", `{"nickname":"email","role":"user","email":""}`, http.StatusOK, ""},
+	}
+	endpoints := []struct {
+		name    string
+		method  string
+		route   string
+		target  string
+		handler gin.HandlerFunc
+	}{
+		{"profile", http.MethodPut, "/profile", "/profile", h.UpdateProfile},
+		{"admin", http.MethodPut, "/users/:id", fmt.Sprintf("/users/%d", user.ID), h.UpdateUser},
+	}
+	for _, ep := range endpoints {
+		config.DB.Model(&user).Update("email", "old@example.com")
+		for _, tt := range tests {
+			t.Run(ep.name+"/"+tt.name, func(t *testing.T) {
+				w := serveAs(user.ID, ep.method, ep.route, ep.target, strings.NewReader(tt.body), header, ep.handler)
+				if w.Code != tt.wantCode {
+					t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+				}
+				var got model.User
+				config.DB.First(&got, user.ID)
+				if got.Email != tt.wantEmail {
+					t.Errorf("email = %q, want %q", got.Email, tt.wantEmail)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"errors"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionHandler 文件版本处理器
+type VersionHandler struct {
+	versionService *service.VersionService
+}
+
+// NewVersionHandler 创建文件版本处理器
+func NewVersionHandler() *VersionHandler {
+	return &VersionHandler{
+		versionService: service.NewVersionService(),
+	}
+}
+
+// GetVersions 获取文件的版本列表
+func (h *VersionHandler) GetVersions(c *gin.Context) {
+	id, ok := fileIDParam(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := requireFile(c, id, model.ACLView); !ok {
+		return
+	}
+
+	versions, err := h.versionService.GetVersions(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "获取版本列表失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "获取版本列表成功", "data": versions})
+}
+
+// UploadVersion 上传文件的新版本
+func (h *VersionHandler) UploadVersion(c *gin.Context) {
+	id, ok := fileIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req model.FileVersionUploadRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "请求参数错误", "error": err.Error()})
+		return
+	}
+
+	if _, ok := requireFile(c, id, model.ACLEdit); !ok {
+		return
+	}
+
+	file, err := h.versionService.UploadVersion(id, c.GetUint("userID"), &req)
+	var uploadErr *service.UploadError
+	if errors.As(err, &uploadErr) {
+		c.JSON(uploadErr.Status, gin.H{"code": uploadErr.Status, "message": "上传新版本失败", "error": uploadErr.Message, "error_code": uploadErr.Code})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "上传新版本失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "上传新版本成功", "data": file})
+}
+
+// DownloadVersion 下载指定版本
+func (h *VersionHandler) DownloadVersion(c *gin.Context) {
+	id, number, ok := versionParams(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := requireFile(c, id, model.ACLDownload); !ok {
+		return
+	}
+
+	content, _, version, err := h.versionService.OpenVersion(id, number)
+	if scanBlocked(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": http.StatusNotFound, "message": "获取文件版本失败", "error": err.Error()})
+		return
+	}
+	defer content.Close()
+
+	serveDownload(c, content, download{
+		Name:     version.FileName,
+		MimeType: version.MimeType,
+		Hash:     version.Hash,
+		ModTime:  version.CreatedAt,
+	})
+}
+
+// RestoreVersion 将历史版本恢复为当前版本
+func (h *VersionHandler) RestoreVersion(c *gin.Context) {
+	id, number, ok := versionParams(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := requireFile(c, id, model.ACLEdit); !ok {
+		return
+	}
+
+	file, err := h.versionService.RestoreVersion(id, number, c.GetUint("userID"))
+	var uploadErr *service.UploadError
+	if errors.As(err, &uploadErr) {
+		c.JSON(uploadErr.Status, gin.H{"code": uploadErr.Status, "message": "恢复版本失败", "error": uploadErr.Message, "error_code": uploadErr.Code})
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrVersionNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"code": status, "message": "恢复版本失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "恢复版本成功", "data": file})
+}
+
+// DeleteVersion 删除历史版本
+func (h *VersionHandler) DeleteVersion(c *gin.Context) {
+	id, number, ok := versionParams(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := requireFile(c, id, model.ACLDelete); !ok {
+		return
+	}
+
+	if err := h.versionService.DeleteVersion(id, number); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrVersionNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrVersionCurrent):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"code": status, "message": "删除版本失败", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "删除版本成功"})
+}
+
+// fileIDParam 解析路径中的文件ID，无效时直接返回400
+func fileIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "无效的文件ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// versionParams 解析路径中的文件ID和版本号
+func versionParams(c *gin.Context) (uint, int, bool) {
+	id, ok := fileIDParam(c)
+	if !ok {
+		return 0, 0, false
+	}
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": http.StatusBadRequest, "message": "无效的版本号"})
+		return 0, 0, false
+	}
+	return id, number, true
+}
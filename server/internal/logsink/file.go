@@ -0,0 +1,120 @@
+package logsink
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"os"
+	"path/filepath"
+)
+
+// FileSink 以JSON Lines格式写入本地文件，超过大小上限时按 path.1、path.2... 轮转
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewFileSink 创建文件转发目标
+func NewFileSink(cfg config.LogSinkConfig) (*FileSink, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("文件转发目标缺少path")
+	}
+	maxSizeMB := cfg.MaxSizeMB
+	if maxSizeMB <= 0 {
+		maxSizeMB = 100
+	}
+	maxBackups := cfg.MaxBackups
+	if maxBackups <= 0 {
+		maxBackups = 5
+	}
+
+	s := &FileSink{
+		path:       cfg.Path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write 写入一批日志
+func (s *FileSink) Write(logs []model.SystemLog) error {
+	w := bufio.NewWriter(s.file)
+	for i := range logs {
+		line, err := json.Marshal(&logs[i])
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+
+		if s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			if err := s.rotate(); err != nil {
+				return err
+			}
+			w.Reset(s.file)
+		}
+
+		n, err := w.Write(line)
+		s.size += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Close 关闭文件
+func (s *FileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建日志目录失败: %w", err)
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate 将当前文件依次重命名为 .1、.2...，超出保留数量的最旧文件被删除
+func (s *FileSink) rotate() error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", s.path, s.maxBackups))
+	for i := s.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+	}
+	if err := os.Rename(s.path, s.path+".1"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return s.open()
+}
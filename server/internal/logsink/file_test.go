@@ -0,0 +1,95 @@
+package logsink
+
+import (
+	"bufio"
+	"encoding/json"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readLogIDs 读取JSON Lines文件中的日志ID
+func readLogIDs(t *testing.T, path string) []uint {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var ids []uint
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry model.SystemLog
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid line in %s: %v", path, err)
+		}
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "audit.log")
+	sink, err := NewFileSink(config.LogSinkConfig{Path: path, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	line, _ := json.Marshal(testLog(1, "x"))
+	// 每个文件恰好容纳两行
+	sink.maxSize = int64(len(line)+1) * 2
+
+	var logs []model.SystemLog
+	for id := uint(1); id <= 7; id++ {
+		logs = append(logs, testLog(id, "x"))
+	}
+	if err := sink.Write(logs[:3]); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(logs[3:]); err != nil {
+		t.Fatal(err)
+	}
+
+	// 1-2、3-4、5-6、7：最旧的1-2超出保留数量被删除
+	want := map[string][]uint{
+		path:        {7},
+		path + ".1": {5, 6},
+		path + ".2": {3, 4},
+	}
+	for file, ids := range want {
+		got := readLogIDs(t, file)
+		if len(got) != len(ids) {
+			t.Errorf("%s: got ids %v, want %v", filepath.Base(file), got, ids)
+			continue
+		}
+		for i := range ids {
+			if got[i] != ids[i] {
+				t.Errorf("%s: got ids %v, want %v", filepath.Base(file), got, ids)
+				break
+			}
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("backup beyond max_backups should not exist")
+	}
+}
+
+func TestFileSinkAppendsAfterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for id := uint(1); id <= 2; id++ {
+		sink, err := NewFileSink(config.LogSinkConfig{Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Write([]model.SystemLog{testLog(id, "")}); err != nil {
+			t.Fatal(err)
+		}
+		sink.Close()
+	}
+	if got := readLogIDs(t, path); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("got ids %v, want [1 2]", got)
+	}
+}
@@ -0,0 +1,100 @@
+package logsink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"net/http"
+	"time"
+)
+
+// HTTPSink 以JSON数组批量POST日志，网络错误、429和5xx响应会按指数退避重试
+type HTTPSink struct {
+	url        string
+	headers    map[string]string
+	maxRetries int
+	client     *http.Client
+}
+
+// httpRetryBaseDelay 首次重试前的等待时间，之后每次翻倍
+var httpRetryBaseDelay = 500 * time.Millisecond
+
+// NewHTTPSink 创建HTTP转发目标
+func NewHTTPSink(cfg config.LogSinkConfig) (*HTTPSink, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("HTTP转发目标缺少url")
+	}
+	maxRetries := cfg.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	} else if maxRetries == 0 {
+		maxRetries = 3
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &HTTPSink{
+		url:        cfg.URL,
+		headers:    cfg.Headers,
+		maxRetries: maxRetries,
+		client:     &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Write 发送一批日志
+func (s *HTTPSink) Write(logs []model.SystemLog) error {
+	body, err := json.Marshal(logs)
+	if err != nil {
+		return err
+	}
+
+	delay := httpRetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		retryable, err := s.post(body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= s.maxRetries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// Close HTTP目标无需释放资源
+func (s *HTTPSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// post 发送一次请求，返回错误是否值得重试
+func (s *HTTPSink) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("日志接收端返回状态码%d", resp.StatusCode)
+}
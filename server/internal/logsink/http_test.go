@@ -0,0 +1,83 @@
+package logsink
+
+import (
+	"encoding/json"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func init() {
+	httpRetryBaseDelay = time.Millisecond
+}
+
+func TestHTTPSinkPostsBatch(t *testing.T) {
+	var got []model.SystemLog
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("configured header missing")
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink, err := NewHTTPSink(config.LogSinkConfig{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if err := sink.Write([]model.SystemLog{testLog(1, "a"), testLog(2, "b")}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != 1 || got[1].Detail != "b" {
+		t.Errorf("unexpected batch: %+v", got)
+	}
+}
+
+func TestHTTPSinkRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		maxRetries int
+		wantErr    bool
+		wantCalls  int32
+	}{
+		{"retry 5xx then succeed", []int{500, 503, 200}, 3, false, 3},
+		{"retry 429", []int{429, 200}, 3, false, 2},
+		{"give up after max retries", []int{500, 500, 500}, 2, true, 3},
+		{"no retry on 4xx", []int{400, 200}, 3, true, 1},
+		{"negative disables retry", []int{500, 200}, -1, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.statuses[int(n)-1])
+			}))
+			defer srv.Close()
+
+			sink, err := NewHTTPSink(config.LogSinkConfig{URL: srv.URL, MaxRetries: tt.maxRetries})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = sink.Write([]model.SystemLog{testLog(1, "")})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
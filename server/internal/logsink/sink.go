@@ -0,0 +1,216 @@
+package logsink
+
+import (
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"log"
+	"sync"
+	"time"
+)
+
+// Sink 日志转发目标
+type Sink interface {
+	// Write 写入一批日志，返回错误时该批日志被丢弃
+	Write(logs []model.SystemLog) error
+	// Close 释放连接、文件等资源
+	Close() error
+}
+
+// New 根据配置创建转发目标
+func New(cfg config.LogSinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case "syslog":
+		return NewSyslogSink(cfg)
+	case "file":
+		return NewFileSink(cfg)
+	case "http":
+		return NewHTTPSink(cfg)
+	default:
+		return nil, fmt.Errorf("未知的日志转发类型: %s", cfg.Type)
+	}
+}
+
+// Filter 判断日志是否需要转发
+type Filter struct {
+	modules map[string]bool
+	actions map[string]bool
+	status  *int
+}
+
+// NewFilter 根据配置创建筛选器
+func NewFilter(cfg config.LogSinkFilter) *Filter {
+	f := &Filter{status: cfg.Status}
+	if len(cfg.Modules) > 0 {
+		f.modules = make(map[string]bool, len(cfg.Modules))
+		for _, m := range cfg.Modules {
+			f.modules[m] = true
+		}
+	}
+	if len(cfg.Actions) > 0 {
+		f.actions = make(map[string]bool, len(cfg.Actions))
+		for _, a := range cfg.Actions {
+			f.actions[a] = true
+		}
+	}
+	return f
+}
+
+// Match 判断日志是否满足筛选条件
+func (f *Filter) Match(log *model.SystemLog) bool {
+	if f.modules != nil && !f.modules[log.Module] {
+		return false
+	}
+	if f.actions != nil && !f.actions[log.Action] {
+		return false
+	}
+	if f.status != nil && log.Status != *f.status {
+		return false
+	}
+	return true
+}
+
+// Dispatcher 将日志异步分发到各个转发目标，每个目标独立排队，互不阻塞
+type Dispatcher struct {
+	mu      sync.RWMutex
+	workers []*worker
+	closed  bool
+}
+
+type worker struct {
+	name          string
+	sink          Sink
+	filter        *Filter
+	queue         chan model.SystemLog
+	batchSize     int
+	flushInterval time.Duration
+	done          chan struct{}
+
+	mu      sync.Mutex
+	dropped int
+}
+
+// NewDispatcher 根据配置创建分发器，停用的目标会被跳过
+func NewDispatcher(configs []config.LogSinkConfig) (*Dispatcher, error) {
+	d := &Dispatcher{}
+	for i, cfg := range configs {
+		if cfg.Disabled {
+			continue
+		}
+		sink, err := New(cfg)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+
+		name := cfg.Name
+		if name == "" {
+			name = fmt.Sprintf("%s#%d", cfg.Type, i)
+		}
+		bufferSize := cfg.BufferSize
+		if bufferSize <= 0 {
+			bufferSize = 1024
+		}
+		batchSize := cfg.BatchSize
+		if batchSize <= 0 {
+			batchSize = 100
+		}
+		flushInterval := time.Duration(cfg.FlushInterval) * time.Second
+		if flushInterval <= 0 {
+			flushInterval = 5 * time.Second
+		}
+
+		w := &worker{
+			name:          name,
+			sink:          sink,
+			filter:        NewFilter(cfg.Filter),
+			queue:         make(chan model.SystemLog, bufferSize),
+			batchSize:     batchSize,
+			flushInterval: flushInterval,
+			done:          make(chan struct{}),
+		}
+		go w.run()
+		d.workers = append(d.workers, w)
+	}
+	return d, nil
+}
+
+// Dispatch 将日志放入各目标的队列，队列已满时丢弃并计数，不阻塞调用方
+func (d *Dispatcher) Dispatch(log *model.SystemLog) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	// 关闭后写入的日志（如后台任务在退出前写入的）不再转发
+	if d.closed {
+		return
+	}
+	for _, w := range d.workers {
+		if !w.filter.Match(log) {
+			continue
+		}
+		select {
+		case w.queue <- *log:
+		default:
+			w.mu.Lock()
+			w.dropped++
+			w.mu.Unlock()
+		}
+	}
+}
+
+// Close 发送队列中剩余的日志并关闭所有目标，可重复调用
+func (d *Dispatcher) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	for _, w := range d.workers {
+		close(w.queue)
+		<-w.done
+		if err := w.sink.Close(); err != nil {
+			log.Printf("关闭日志转发目标%s失败: %v", w.name, err)
+		}
+	}
+	d.workers = nil
+	return nil
+}
+
+func (w *worker) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]model.SystemLog, 0, w.batchSize)
+	flush := func() {
+		w.mu.Lock()
+		dropped := w.dropped
+		w.dropped = 0
+		w.mu.Unlock()
+		if dropped > 0 {
+			log.Printf("日志转发目标%s队列已满，丢弃%d条日志", w.name, dropped)
+		}
+
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.sink.Write(batch); err != nil {
+			log.Printf("日志转发目标%s写入失败，丢弃%d条日志: %v", w.name, len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case entry, ok := <-w.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= w.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package logsink
+
+import (
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"path/filepath"
+	"testing"
+)
+
+func TestDispatcherCloseFlushesQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	failed := 0
+	d, err := NewDispatcher([]config.LogSinkConfig{{
+		Type:          "file",
+		Path:          path,
+		BatchSize:     100,
+		FlushInterval: 3600,
+		Filter:        config.LogSinkFilter{Status: &failed},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id := uint(1); id <= 5; id++ {
+		entry := testLog(id, "")
+		if id == 3 {
+			entry.Status = 1
+		}
+		d.Dispatch(&entry)
+	}
+	// 批次未满且未到刷新间隔，Close时应写出队列中剩余的日志
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readLogIDs(t, path); len(got) != 4 || got[2] != 4 {
+		t.Errorf("got ids %v, want [1 2 4 5]", got)
+	}
+
+	// 关闭后写入的日志被忽略，重复关闭不报错
+	entry := testLog(6, "")
+	d.Dispatch(&entry)
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	success := 1
+	f := NewFilter(config.LogSinkFilter{Modules: []string{model.LogModuleAuth}, Status: &success})
+	entry := testLog(1, "")
+	if f.Match(&entry) {
+		t.Errorf("failed login should not match status filter")
+	}
+	entry.Status = 1
+	if !f.Match(&entry) {
+		t.Errorf("successful login should match")
+	}
+	entry.Module = model.LogModuleFile
+	if f.Match(&entry) {
+		t.Errorf("other modules should not match")
+	}
+}
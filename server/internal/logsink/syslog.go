@@ -0,0 +1,179 @@
+package logsink
+
+import (
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syslogEnterpriseID 结构化数据的私有企业编号（RFC 5612 文档示例编号）
+const syslogEnterpriseID = "32473"
+
+// SyslogSink 按RFC 5424格式通过UDP或TCP发送日志，TCP使用RFC 6587的长度前缀分帧
+type SyslogSink struct {
+	network  string
+	address  string
+	appName  string
+	facility int
+	hostname string
+	timeout  time.Duration
+	conn     net.Conn
+}
+
+// NewSyslogSink 创建syslog转发目标，连接在首次写入时建立
+func NewSyslogSink(cfg config.LogSinkConfig) (*SyslogSink, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("syslog转发目标缺少address")
+	}
+	network := cfg.Network
+	if network == "" {
+		network = "udp"
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("syslog不支持的网络类型: %s", network)
+	}
+	appName := cfg.AppName
+	if appName == "" {
+		appName = "jing-admin"
+	}
+	facility := cfg.Facility
+	if facility <= 0 || facility > 23 {
+		facility = 13 // log audit
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	return &SyslogSink{
+		network:  network,
+		address:  cfg.Address,
+		appName:  appName,
+		facility: facility,
+		hostname: hostname,
+		timeout:  timeout,
+	}, nil
+}
+
+// Write 逐条发送日志，连接异常时重连一次
+func (s *SyslogSink) Write(logs []model.SystemLog) error {
+	for i := range logs {
+		msg := s.format(&logs[i])
+		if err := s.send(msg); err != nil {
+			s.reset()
+			if err := s.send(msg); err != nil {
+				s.reset()
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close 关闭连接
+func (s *SyslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *SyslogSink) send(msg []byte) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if s.network == "tcp" {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+func (s *SyslogSink) reset() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// format 生成RFC 5424格式的消息
+func (s *SyslogSink) format(log *model.SystemLog) []byte {
+	severity := 5 // notice
+	if log.Status != 1 {
+		severity = 4 // warning
+	}
+
+	timestamp := log.CreatedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d %s ",
+		s.facility*8+severity,
+		timestamp.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderField(s.hostname, 255),
+		syslogHeaderField(s.appName, 48),
+		os.Getpid(),
+		syslogHeaderField(log.Module+"."+log.Action, 32),
+	)
+
+	b.WriteString("[audit@" + syslogEnterpriseID)
+	params := []struct{ name, value string }{
+		{"id", strconv.FormatUint(uint64(log.ID), 10)},
+		{"userId", strconv.FormatUint(uint64(log.UserID), 10)},
+		{"user", log.Username},
+		{"module", log.Module},
+		{"action", log.Action},
+		{"resource", log.Resource},
+		{"ip", log.IP},
+		{"status", strconv.Itoa(log.Status)},
+	}
+	for _, p := range params {
+		b.WriteString(" " + p.name + "=\"" + syslogParamValue(p.value) + "\"")
+	}
+	b.WriteString("]")
+
+	if log.Detail != "" {
+		// 消息体为UTF-8时需以BOM开头
+		b.WriteString(" \xEF\xBB\xBF" + log.Detail)
+	}
+	return []byte(b.String())
+}
+
+// syslogHeaderField 头部字段只允许可打印ASCII字符，空值用"-"表示
+func syslogHeaderField(value string, maxLen int) string {
+	var b strings.Builder
+	for i := 0; i < len(value) && b.Len() < maxLen; i++ {
+		if c := value[i]; c > 32 && c < 127 {
+			b.WriteByte(c)
+		}
+	}
+	if b.Len() == 0 {
+		return "-"
+	}
+	return b.String()
+}
+
+// syslogParamValue 转义结构化数据参数值中的 " \ ]
+func syslogParamValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
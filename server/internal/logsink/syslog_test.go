@@ -0,0 +1,142 @@
+package logsink
+
+import (
+	"bufio"
+	"io"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testLog(id uint, detail string) model.SystemLog {
+	return model.SystemLog{
+		Base:     model.Base{ID: id, CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+		UserID:   7,
+		Username: `a"b]c\d`,
+		Module:   model.LogModuleAuth,
+		Action:   model.LogActionLogin,
+		IP:       "10.0.0.1",
+		Status:   0,
+		Detail:   detail,
+	}
+}
+
+func TestSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink(config.LogSinkConfig{Network: "udp", Address: conn.LocalAddr().String(), AppName: "test", Facility: 13})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if err := sink.Write([]model.SystemLog{testLog(1, "密码错误"), testLog(2, "")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// UDP每个数据报是一条消息，不加长度前缀
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	// facility 13、失败为warning(4)：13*8+4=108
+	if !strings.HasPrefix(msg, "<108>1 2026-01-02T03:04:05.000000Z ") {
+		t.Errorf("unexpected header: %q", msg)
+	}
+	if !strings.Contains(msg, " test ") || !strings.Contains(msg, " auth.login [audit@32473 id=\"1\" userId=\"7\" ") {
+		t.Errorf("unexpected fields: %q", msg)
+	}
+	if !strings.Contains(msg, `user="a\"b\]c\\d"`) {
+		t.Errorf("param value not escaped: %q", msg)
+	}
+	if !strings.HasSuffix(msg, "] \xEF\xBB\xBF密码错误") {
+		t.Errorf("message body should start with BOM: %q", msg)
+	}
+
+	n, _, err = conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := string(buf[:n]); !strings.HasSuffix(msg, `status="0"]`) {
+		t.Errorf("empty detail should end after structured data: %q", msg)
+	}
+}
+
+func TestSyslogTCPOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// RFC 6587：MSG-LEN SP SYSLOG-MSG，长度按字节计
+		r := bufio.NewReader(conn)
+		var msgs []string
+		for len(msgs) < 2 {
+			size, err := r.ReadString(' ')
+			if err != nil {
+				break
+			}
+			n, err := strconv.Atoi(strings.TrimSuffix(size, " "))
+			if err != nil {
+				break
+			}
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				break
+			}
+			msgs = append(msgs, string(msg))
+		}
+		received <- msgs
+	}()
+
+	sink, err := NewSyslogSink(config.LogSinkConfig{Network: "tcp", Address: ln.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	if err := sink.Write([]model.SystemLog{testLog(1, "多字节\n详情"), testLog(2, "second")}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msgs := <-received:
+		if len(msgs) != 2 {
+			t.Fatalf("got %d framed messages, want 2", len(msgs))
+		}
+		if !strings.HasSuffix(msgs[0], "\xEF\xBB\xBF多字节\n详情") || !strings.HasSuffix(msgs[1], "second") {
+			t.Errorf("frames split incorrectly: %q", msgs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for messages")
+	}
+}
+
+func TestSyslogHeaderField(t *testing.T) {
+	if got := syslogHeaderField("", 10); got != "-" {
+		t.Errorf("empty field = %q, want -", got)
+	}
+	if got := syslogHeaderField("a b\tc中", 10); got != "abc" {
+		t.Errorf("non-printable characters not removed: %q", got)
+	}
+	if got := syslogHeaderField(strings.Repeat("x", 40), 32); len(got) != 32 {
+		t.Errorf("field not truncated: %d", len(got))
+	}
+}
@@ -5,34 +5,58 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"jing_vue_gin_admin/server/internal/service"
 	"jing_vue_gin_admin/server/pkg/jwt"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
+		urlToken := c.Query("access_token")
+		if authHeader == "" && urlToken == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "未提供认证信息"})
 			c.Abort()
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "认证格式错误"})
-			c.Abort()
-			return
+		var claims *jwt.Claims
+		if authHeader != "" {
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "认证格式错误"})
+				c.Abort()
+				return
+			}
+
+			var err error
+			claims, err = jwt.ValidateToken(parts[1])
+			// 限定接口的短期令牌不能作为登录令牌使用
+			if err != nil || len(claims.Audience) > 0 {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证信息"})
+				c.Abort()
+				return
+			}
+		} else {
+			// 查询参数中的令牌只能访问签发时指定的接口
+			var err error
+			claims, err = jwt.ValidateToken(urlToken)
+			if err != nil || len(claims.Audience) != 1 || claims.Audience[0] != c.FullPath() {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证信息"})
+				c.Abort()
+				return
+			}
 		}
 
-		claims, err := jwt.ValidateToken(parts[1])
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证信息"})
+		// 会话被注销或过期后令牌立即失效
+		if err := service.NewSecurityService().ValidateSession(claims.UserID, claims.ID); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "登录已失效，请重新登录"})
 			c.Abort()
 			return
 		}
 
 		// 将用户信息存储到上下文中
 		c.Set("userID", claims.UserID)
+		c.Set("sessionID", claims.ID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
 
@@ -8,6 +8,7 @@ import (
 	"jing_vue_gin_admin/server/internal/model"
 	"jing_vue_gin_admin/server/internal/service"
 	"strings"
+	"time"
 )
 
 // bodyLogWriter 自定义响应写入器，用于捕获响应内容
@@ -21,26 +22,33 @@ func (w bodyLogWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
+// ResourceIDFunc 在请求处理完成后提取受影响的资源ID
+type ResourceIDFunc func(c *gin.Context) string
+
 // OperationLog 操作日志中间件
 func OperationLog(module string, action string) gin.HandlerFunc {
+	return OperationLogWithResource(module, action, nil)
+}
+
+// OperationLogWithResource 操作日志中间件，resourceID不为空时将提取到的资源ID记录到Resource字段
+func OperationLogWithResource(module string, action string, resourceID ResourceIDFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 获取请求体
+		// 获取请求体（文件上传等二进制请求体不缓存，避免大文件占用内存）
 		var requestBody []byte
-		if c.Request.Body != nil {
+		if c.Request.Body != nil && captureBody(c) {
 			requestBody, _ = io.ReadAll(c.Request.Body)
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
 		}
+		c.Set("requestBody", requestBody)
 
-		// 包装响应写入器
-		blw := &bodyLogWriter{
-			ResponseWriter: c.Writer,
-			body:           bytes.NewBufferString(""),
-		}
-		c.Writer = blw
-
-		// 继续处理请求
+		// 继续处理请求（不缓存响应内容，避免导出等大响应占用内存）
 		c.Next()
 
+		// 处理器可以跳过本次记录，如断点续传中间的数据块
+		if c.GetBool("skipOperationLog") {
+			return
+		}
+
 		// 获取请求信息
 		userID, _ := c.Get("userID")
 		username, _ := c.Get("username")
@@ -50,15 +58,22 @@ func OperationLog(module string, action string) gin.HandlerFunc {
 		userAgent := c.Request.UserAgent()
 		
 		// 获取资源标识
-		resource := c.FullPath()
+		resource := ""
+		if resourceID != nil {
+			resource = resourceID(c)
+		}
+		if len(resource) > 64 {
+			resource = resource[:61] + "..."
+		}
 		
 		// 构建详情
 		detail := ""
 		if len(requestBody) > 0 {
-			if len(requestBody) > 200 {
-				detail = string(requestBody[:200]) + "..."
+			body := maskPasswords(requestBody)
+			if len(body) > 200 {
+				detail = string(body[:200]) + "..."
 			} else {
-				detail = string(requestBody)
+				detail = string(body)
 			}
 		}
 		
@@ -83,20 +98,54 @@ func OperationLog(module string, action string) gin.HandlerFunc {
 		}
 		
 		// 记录日志
-		logService.AddOperationLog(
-			userIDUint,
-			usernameStr,
-			module,
-			action,
-			resource,
-			detail,
-			clientIP,
-			userAgent,
-			status,
-		)
+		entry := &model.SystemLog{
+			UserID:    userIDUint,
+			Username:  usernameStr,
+			Module:    module,
+			Action:    action,
+			Resource:  resource,
+			Detail:    detail,
+			IP:        clientIP,
+			UserAgent: userAgent,
+			Status:    status,
+		}
+		fillRequestInfo(c, entry)
+		logService.CreateLog(entry)
 	}
 }
 
+// captureBody 只缓存JSON和表单请求体用于记录详情
+func captureBody(c *gin.Context) bool {
+	switch c.ContentType() {
+	case gin.MIMEJSON, gin.MIMEPOSTForm:
+		return true
+	}
+	return false
+}
+
+// maskPasswords 隐去JSON请求体中名称包含password的字段，避免密码写入日志
+func maskPasswords(body []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+	masked := false
+	for key := range fields {
+		if strings.Contains(strings.ToLower(key), "password") {
+			fields[key] = json.RawMessage(`"***"`)
+			masked = true
+		}
+	}
+	if !masked {
+		return body
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
 // LoginLogMiddleware 登录日志中间件
 func LoginLogMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -136,9 +185,6 @@ func LoginLogMiddleware() gin.HandlerFunc {
 		clientIP := c.ClientIP()
 		userAgent := c.Request.UserAgent()
 		
-		// 获取资源标识
-		resource := c.FullPath()
-		
 		// 状态判断
 		status := 1
 		if c.Writer.Status() >= 400 {
@@ -149,16 +195,29 @@ func LoginLogMiddleware() gin.HandlerFunc {
 		logService := service.NewLogService()
 		
 		// 添加登录日志
-		logService.AddOperationLog(
-			0, // 未登录用户ID为0
-			username,
-			model.LogModuleAuth,
-			model.LogActionLogin,
-			resource,
-			"",
-			clientIP,
-			userAgent,
-			status,
-		)
+		entry := &model.SystemLog{
+			UserID:    c.GetUint("userID"), // 登录成功时由处理器设置，失败为0
+			Username:  username,
+			Module:    model.LogModuleAuth,
+			Action:    model.LogActionLogin,
+			IP:        clientIP,
+			UserAgent: userAgent,
+			Status:    status,
+		}
+		fillRequestInfo(c, entry)
+		logService.CreateLog(entry)
 	}
-} 
\ No newline at end of file
+}
+
+// fillRequestInfo 填充请求ID、HTTP方法、路由、状态码和处理耗时
+func fillRequestInfo(c *gin.Context, entry *model.SystemLog) {
+	entry.RequestID = c.GetString("requestID")
+	entry.Method = c.Request.Method
+	entry.Path = c.FullPath()
+	entry.StatusCode = c.Writer.Status()
+
+	start, ok := c.Get("requestStart")
+	if startTime, isTime := start.(time.Time); ok && isTime {
+		entry.Duration = time.Since(startTime).Milliseconds()
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery 异常恢复中间件，记录panic并返回500
+//
+// http.ErrAbortHandler会继续向上抛出，由net/http直接断开连接：流式响应发送部分内容后出错时，
+// 处理器以此中断输出，客户端能发现响应不完整，而不是收到正常结束的截断内容
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			log.Printf("[Recovery] panic recovered: %v\n%s", err, debug.Stack())
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 请求ID的请求头和响应头名称
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 允许客户端传入的请求ID最大长度
+const maxRequestIDLength = 64
+
+// RequestID 请求ID中间件，沿用客户端传入的X-Request-ID或生成新的ID，并在响应头中返回
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("requestStart", time.Now())
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// Logger 访问日志中间件，在gin默认格式的基础上输出请求ID，便于与系统日志关联
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		requestID, _ := param.Keys["requestID"].(string)
+		if requestID == "" {
+			requestID = "-"
+		}
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %s | %-7s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency,
+			param.ClientIP,
+			requestID,
+			param.Method,
+			redactQuery(param.Path),
+			param.ErrorMessage,
+		)
+	})
+}
+
+// redactQuery 隐去访问日志中查询参数里的令牌
+func redactQuery(path string) string {
+	i := strings.IndexByte(path, '?')
+	if i < 0 || !strings.Contains(path[i:], "access_token=") {
+		return path
+	}
+	query, err := url.ParseQuery(path[i+1:])
+	if err != nil {
+		return path[:i]
+	}
+	query.Set("access_token", "***")
+	return path[:i+1] + query.Encode()
+}
+
+// validRequestID 只接受长度合理且由字母、数字和 -_.: 组成的请求ID
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成32位十六进制随机请求ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
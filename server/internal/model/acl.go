@@ -0,0 +1,62 @@
+package model
+
+// 访问控制的资源类型
+const (
+	ACLResourceFile   = "file"
+	ACLResourceFolder = "folder"
+)
+
+// 访问控制的授权对象类型
+const (
+	ACLSubjectUser       = "user"
+	ACLSubjectRole       = "role"
+	ACLSubjectDepartment = "department"
+)
+
+// 文件访问权限
+const (
+	ACLView     = "view"     // 查看，拥有其他任一权限时也可查看
+	ACLDownload = "download" // 下载
+	ACLEdit     = "edit"     // 编辑：修改信息、上传新版本、移动，文件夹上表示可在其中新建
+	ACLDelete   = "delete"   // 删除
+)
+
+// FileACL 文件或文件夹的访问控制条目，文件夹上的条目对其中所有文件和子文件夹生效
+// 文件及其所在各级文件夹都没有条目时不做限制，否则只有所有者和被授权的对象可以访问
+type FileACL struct {
+	Base
+	ResourceType string   `gorm:"size:16;uniqueIndex:idx_acl_entry" json:"resource_type"`
+	ResourceID   uint     `gorm:"uniqueIndex:idx_acl_entry" json:"resource_id"`
+	SubjectType  string   `gorm:"size:16;uniqueIndex:idx_acl_entry" json:"subject_type"`
+	SubjectID    uint     `gorm:"uniqueIndex:idx_acl_entry" json:"subject_id"`           // 授权用户ID，subject_type为user时使用
+	SubjectName  string   `gorm:"size:64;uniqueIndex:idx_acl_entry" json:"subject_name"` // 角色名或部门名
+	Permissions  []string `gorm:"serializer:json" json:"permissions"`                    // view/download/edit/delete
+	CreatedBy    uint     `json:"created_by"`
+
+	SubjectLabel string `gorm:"-" json:"subject_label"`           // 授权对象的显示名称
+	InheritedVia string `gorm:"-" json:"inherited_via,omitempty"` // 继承自的文件夹路径
+}
+
+// ACLEntryRequest 访问控制条目
+type ACLEntryRequest struct {
+	SubjectType string   `json:"subject_type" binding:"required,oneof=user role department"`
+	SubjectID   uint     `json:"subject_id"`
+	SubjectName string   `json:"subject_name" binding:"max=64"`
+	Permissions []string `json:"permissions" binding:"required,min=1,dive,oneof=view download edit delete"`
+}
+
+// ACLUpdateRequest 设置访问控制列表，整体替换资源上的条目，为空时取消限制
+type ACLUpdateRequest struct {
+	Entries []ACLEntryRequest `json:"entries" binding:"dive"`
+}
+
+// ACLResponse 资源的访问控制列表
+type ACLResponse struct {
+	ResourceType string    `json:"resource_type"`
+	ResourceID   uint      `json:"resource_id"`
+	OwnerID      uint      `json:"owner_id"`
+	OwnerName    string    `json:"owner_name"`
+	Restricted   bool      `json:"restricted"` // 自身或上级文件夹设置了条目，访问受限
+	Entries      []FileACL `json:"entries"`
+	Inherited    []FileACL `json:"inherited"`
+}
@@ -0,0 +1,96 @@
+package model
+
+import "time"
+
+// AlertRule 告警规则，对新写入的系统日志进行匹配，时间窗口内匹配次数达到阈值时触发告警
+type AlertRule struct {
+	Base
+	Name        string   `gorm:"size:64;not null" json:"name"`        // 规则名称
+	Description string   `gorm:"size:255" json:"description"`         // 规则描述
+	Enabled     bool     `json:"enabled"`                             // 是否启用
+	Module      string   `gorm:"size:32" json:"module"`               // 匹配模块，为空表示不限
+	Action      string   `gorm:"size:32" json:"action"`               // 匹配操作类型，为空表示不限
+	Status      *int     `json:"status"`                              // 匹配操作状态，为空表示不限
+	Username    string   `gorm:"size:32" json:"username"`             // 匹配用户名，为空表示不限
+	IP          string   `gorm:"size:64" json:"ip"`                   // 匹配IP，为空表示不限
+	Threshold   int      `json:"threshold"`                           // 时间窗口内的触发次数
+	Window      int      `json:"window"`                              // 时间窗口（秒），0表示每条日志单独判断
+	GroupBy     string   `gorm:"size:16" json:"group_by"`             // 分组计数字段：ip、username，为空表示不分组
+	Cooldown    int      `json:"cooldown"`                            // 冷却时间（秒），同一分组在冷却期内不重复告警
+	Channels    []string `gorm:"serializer:json" json:"channels"`     // 通知渠道：notify、email、webhook
+	NotifyUsers []uint   `gorm:"serializer:json" json:"notify_users"` // 站内通知接收人，为空时通知所有可查看日志的用户
+	Emails      []string `gorm:"serializer:json" json:"emails"`       // 邮件接收人
+	WebhookURL  string   `gorm:"size:255" json:"webhook_url"`         // Webhook地址
+}
+
+// AlertHistory 告警触发记录
+type AlertHistory struct {
+	Base
+	RuleID        uint   `gorm:"index" json:"rule_id"`            // 规则ID
+	RuleName      string `gorm:"size:64" json:"rule_name"`        // 触发时的规则名称
+	GroupKey      string `gorm:"size:128;index" json:"group_key"` // 分组值，如IP或用户名
+	Count         int64  `json:"count"`                           // 时间窗口内的匹配次数
+	LogID         uint   `json:"log_id"`                          // 触发告警的日志ID
+	Message       string `gorm:"size:500" json:"message"`         // 告警内容
+	DeliveryError string `gorm:"size:500" json:"delivery_error"`  // 通知发送失败原因
+}
+
+// Notification 站内通知
+type Notification struct {
+	Base
+	UserID  uint       `gorm:"index" json:"user_id"`    // 接收人
+	Source  string     `gorm:"size:32" json:"source"`   // 来源：alert、security
+	Level   string     `gorm:"size:16" json:"level"`    // 级别：info、warning
+	Title   string     `gorm:"size:128" json:"title"`   // 标题
+	Content string     `gorm:"size:500" json:"content"` // 内容
+	IsRead  bool       `gorm:"index" json:"is_read"`    // 是否已读
+	ReadAt  *time.Time `json:"read_at"`                 // 阅读时间
+}
+
+// AlertRuleRequest 创建/更新告警规则请求
+type AlertRuleRequest struct {
+	Name        string   `json:"name" binding:"required,max=64"`
+	Description string   `json:"description" binding:"max=255"`
+	Enabled     bool     `json:"enabled"`
+	Module      string   `json:"module"`
+	Action      string   `json:"action"`
+	Status      *int     `json:"status" binding:"omitempty,oneof=0 1"`
+	Username    string   `json:"username"`
+	IP          string   `json:"ip"`
+	Threshold   int      `json:"threshold" binding:"min=0"`
+	Window      int      `json:"window" binding:"min=0"`
+	GroupBy     string   `json:"group_by" binding:"omitempty,oneof=ip username"`
+	Cooldown    int      `json:"cooldown" binding:"min=0"`
+	Channels    []string `json:"channels" binding:"dive,oneof=notify email webhook"`
+	NotifyUsers []uint   `json:"notify_users"`
+	Emails      []string `json:"emails" binding:"dive,email"`
+	WebhookURL  string   `json:"webhook_url" binding:"omitempty,url"`
+}
+
+// AlertHistoryRequest 告警记录查询参数
+type AlertHistoryRequest struct {
+	RuleID   uint `form:"rule_id"`
+	Page     int  `form:"page" binding:"required,min=1"`
+	PageSize int  `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+// NotificationListRequest 通知列表查询参数
+type NotificationListRequest struct {
+	Unread   bool `form:"unread"`
+	Page     int  `form:"page" binding:"required,min=1"`
+	PageSize int  `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+// 告警通知渠道
+const (
+	AlertChannelNotify  = "notify"  // 站内通知
+	AlertChannelEmail   = "email"   // 邮件
+	AlertChannelWebhook = "webhook" // Webhook
+)
+
+// 通知来源
+const (
+	NotificationSourceAlert    = "alert"    // 告警
+	NotificationSourceSecurity = "security" // 账号安全
+	NotificationSourceScan     = "scan"     // 病毒扫描
+)
@@ -0,0 +1,11 @@
+package model
+
+// Blob 按内容寻址的存储对象，内容相同的文件共用一个对象，引用计数归零时才删除物理文件
+type Blob struct {
+	Base
+	Hash      string `gorm:"size:64;uniqueIndex" json:"hash"`     // 内容的SHA-256（十六进制）
+	Size      int64  `json:"size"`                                // 内容大小
+	Backend   string `gorm:"size:16" json:"backend"`              // 存储后端
+	ObjectKey string `gorm:"size:255" json:"object_key"`          // 存储后端中的对象键
+	RefCount  int64  `gorm:"not null;default:0" json:"ref_count"` // 引用该对象的文件记录数
+}
@@ -1,6 +1,9 @@
 package model
 
-import "mime/multipart"
+import (
+	"mime/multipart"
+	"time"
+)
 
 // File 文件模型
 type File struct {
@@ -8,11 +11,42 @@ type File struct {
 	FileName    string `json:"file_name" gorm:"size:255;not null"`     // 文件名
 	FileSize    int64  `json:"file_size" gorm:"not null"`              // 文件大小
 	FileType    string `json:"file_type" gorm:"size:50;not null"`      // 文件类型
+	MimeType    string `json:"mime_type" gorm:"size:128"`              // 按文件头识别的MIME类型
 	FilePath    string `json:"file_path" gorm:"size:255;not null"`     // 文件路径
+	Backend     string `json:"backend" gorm:"size:16;default:'local'"` // 存储后端：local、s3、memory
+	ObjectKey   string `json:"object_key" gorm:"size:255;index"`       // 存储后端中的对象键
+	Hash        string `json:"hash" gorm:"size:64;index"`              // 内容的SHA-256（十六进制），用于完整性校验
+	BlobID      uint   `json:"blob_id" gorm:"index"`                   // 共用的存储对象ID，0表示未去重的历史文件
+	FolderID    uint   `json:"folder_id" gorm:"index;default:0"`      // 所在文件夹ID，0表示根目录
 	Category    string `json:"category" gorm:"size:50;default:'other'"`// 文件分类
 	Description string `json:"description" gorm:"size:500"`            // 文件描述
 	UploadedBy  uint   `json:"uploaded_by" gorm:"not null"`            // 上传者ID
 	Downloads   int    `json:"downloads" gorm:"default:0"`             // 下载次数
+	Version     int    `json:"version" gorm:"default:1"`               // 当前版本号
+	Trashed     bool   `json:"-" gorm:"index;default:false"`           // 是否在回收站中，彻底删除前存储对象保持不变
+	DeletedBy   uint   `json:"deleted_by,omitempty" gorm:"default:0"`  // 移入回收站的用户ID
+	Broken      bool   `json:"broken" gorm:"default:false"`            // 一致性检查发现存储内容缺失或与记录不一致
+
+	ScanStatus string     `json:"scan_status" gorm:"size:16;index;default:'unscanned'"` // 病毒扫描状态
+	ScanResult string     `json:"scan_result,omitempty" gorm:"size:255"`                // 发现的病毒名称、扫描失败原因或未扫描原因
+	ScannedAt  *time.Time `json:"scanned_at,omitempty"`                                 // 最近一次扫描完成时间
+	Rescan     bool       `json:"rescan,omitempty" gorm:"index;default:false"`          // 等待重新扫描，完成前保留原扫描状态
+
+	ThumbnailURL string `json:"thumbnail_url,omitempty" gorm:"-"` // 缩略图地址，仅图片文件有
+}
+
+// 病毒扫描状态
+const (
+	ScanStatusUnscanned = "unscanned" // 未扫描：未启用扫描时上传的文件
+	ScanStatusPending   = "pending"   // 等待扫描或扫描失败待重试，不允许下载
+	ScanStatusClean     = "clean"     // 未发现病毒
+	ScanStatusInfected  = "infected"  // 发现病毒，已隔离，不允许下载
+	ScanStatusOversize  = "oversize"  // 超过扫描大小上限未扫描，除非配置允许，否则不允许下载
+)
+
+// FileScanRequest 重新扫描请求，Status为空时重新扫描全部文件
+type FileScanRequest struct {
+	Status string `json:"status" binding:"omitempty,oneof=unscanned pending clean infected oversize"`
 }
 
 // FileUploadRequest 文件上传请求
@@ -20,6 +54,8 @@ type FileUploadRequest struct {
 	File        *multipart.FileHeader `form:"file" binding:"required"`
 	Category    string                `form:"category"`
 	Description string                `form:"description"`
+	SHA256      string                `form:"sha256"` // 可选，客户端计算的SHA-256，与服务端不一致时拒绝上传
+	FolderID    uint                  `form:"folder_id"` // 可选，上传到的文件夹
 }
 
 // FileListRequest 文件列表请求
@@ -30,8 +66,39 @@ type FileListRequest struct {
 	StartDate   string `form:"start_date"`
 	EndDate     string `form:"end_date"`
 	UploadedBy  uint   `form:"uploaded_by"`
+	FolderID    *uint  `form:"folder_id"` // 为空表示不按文件夹筛选，0表示根目录
+	Recursive   bool   `form:"recursive"` // 按文件夹筛选时是否包含子文件夹中的文件
 	Page        int    `form:"page" binding:"required,min=1"`
 	PageSize    int    `form:"page_size" binding:"required,min=1,max=100"`
+	ViewerID    uint   `form:"-"` // 不为0时只返回该用户有查看权限的文件
+}
+
+// FileArchiveRequest 批量下载请求，指定文件ID列表或文件夹（包含全部子文件夹）
+type FileArchiveRequest struct {
+	IDs      []uint `form:"ids" json:"ids"`
+	FolderID uint   `form:"folder_id" json:"folder_id"`
+}
+
+// TrashListRequest 回收站列表请求
+type TrashListRequest struct {
+	FileName string `form:"file_name"`
+	Page     int    `form:"page" binding:"required,min=1"`
+	PageSize int    `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+// TrashItem 回收站中的文件
+type TrashItem struct {
+	File
+	TrashedAt     time.Time  `json:"deleted_at"`           // 移入回收站的时间
+	DeletedByName string     `json:"deleted_by_name"`      // 移入回收站的用户名
+	FolderPath    string     `json:"folder_path"`          // 原所在文件夹路径，文件夹已删除时为空，恢复到根目录
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"` // 自动彻底删除的时间，不自动清理时为空
+}
+
+// TrashListResponse 回收站列表响应
+type TrashListResponse struct {
+	Total int64       `json:"total"`
+	List  []TrashItem `json:"list"`
 }
 
 // FileListResponse 文件列表响应
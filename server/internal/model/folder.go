@@ -0,0 +1,49 @@
+package model
+
+// Folder 文件夹，Path为从根目录开始的完整路径，如 /项目/设计稿，用于按路径查找和递归查询
+type Folder struct {
+	Base
+	Name      string `gorm:"size:255;not null" json:"name"`             // 文件夹名称，不能包含 /
+	ParentID  uint   `gorm:"index;not null;default:0" json:"parent_id"` // 上级文件夹ID，0表示根目录
+	Path      string `gorm:"size:1024;index" json:"path"`               // 完整路径
+	CreatedBy uint   `gorm:"not null" json:"created_by"`                // 创建者ID
+
+	Children []Folder `gorm:"-" json:"children,omitempty"` // 子文件夹，仅目录树中返回
+}
+
+// FolderCreateRequest 创建文件夹请求
+type FolderCreateRequest struct {
+	Name     string `json:"name" binding:"required,max=255"`
+	ParentID uint   `json:"parent_id"`
+}
+
+// FolderRenameRequest 重命名文件夹请求
+type FolderRenameRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+// FolderMoveRequest 移动文件夹请求
+type FolderMoveRequest struct {
+	ParentID uint `json:"parent_id"` // 目标上级文件夹ID，0表示移动到根目录
+}
+
+// FolderContents 文件夹内容：面包屑导航和子文件夹
+type FolderContents struct {
+	Folder      *Folder  `json:"folder"`      // 当前文件夹，根目录时为空
+	Breadcrumbs []Folder `json:"breadcrumbs"` // 从根目录到当前文件夹的路径
+	Folders     []Folder `json:"folders"`     // 子文件夹
+	FileCount   int64    `json:"file_count"`  // 直接包含的文件数
+}
+
+// FolderDeleteResult 删除文件夹时涉及的内容数量
+type FolderDeleteResult struct {
+	Folders int64  `json:"folders"`          // 文件夹数（含自身）
+	Files   int64  `json:"files"`            // 文件数
+	Denied  []uint `json:"denied,omitempty"` // 当前用户没有删除权限的文件ID
+}
+
+// FileMoveRequest 移动或复制文件请求
+type FileMoveRequest struct {
+	IDs      []uint `json:"ids" binding:"required,min=1"`
+	FolderID uint   `json:"folder_id"` // 目标文件夹ID，0表示根目录
+}
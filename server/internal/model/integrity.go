@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// 存储一致性问题类型
+const (
+	IntegrityOrphan       = "orphan"        // 存储中的对象没有任何记录引用
+	IntegrityMissing      = "missing"       // 记录引用的对象不存在
+	IntegritySizeMismatch = "size_mismatch" // 对象大小与记录不一致
+	IntegrityHashMismatch = "hash_mismatch" // 对象内容的SHA-256与记录不一致
+)
+
+// 一致性检查状态
+const (
+	IntegrityRunning = "running" // 检查中
+	IntegrityDone    = "done"    // 已完成
+	IntegrityFailed  = "failed"  // 检查失败
+)
+
+// 问题的修复结果
+const (
+	IntegrityQuarantined = "quarantined" // 孤立对象已移入隔离区
+	IntegrityMarked      = "marked"      // 引用的文件已标记为损坏
+)
+
+// IntegrityReport 存储一致性检查报告，比较存储后端中的对象与文件、版本和存储对象记录
+type IntegrityReport struct {
+	Base
+	Status      string           `gorm:"size:16;index" json:"status"`             // running、done、failed
+	Verify      bool             `json:"verify"`                                  // 是否读取内容校验SHA-256
+	Repair      bool             `json:"repair"`                                  // 是否自动修复
+	TriggeredBy uint             `json:"triggered_by"`                            // 发起检查的用户ID，0表示命令行
+	Objects     int64            `json:"objects"`                                 // 检查的存储对象数
+	Records     int64            `json:"records"`                                 // 检查的对象引用数
+	Orphans     int              `json:"orphans"`                                 // 孤立对象数
+	Missing     int              `json:"missing"`                                 // 缺失对象数
+	Mismatches  int              `json:"mismatches"`                              // 大小或哈希不一致的对象数
+	Repaired    int              `json:"repaired"`                                // 已修复的问题数
+	Skipped     []string         `gorm:"serializer:json" json:"skipped"`          // 无法检查的存储后端及原因
+	Issues      []IntegrityIssue `gorm:"serializer:json" json:"issues,omitempty"` // 发现的问题，列表中不返回
+	Error       string           `gorm:"size:500" json:"error,omitempty"`         // 检查失败的原因
+	FinishedAt  *time.Time       `json:"finished_at"`                             // 完成时间
+}
+
+// IntegrityIssue 一致性检查发现的问题
+type IntegrityIssue struct {
+	Type      string `json:"type"`                 // 问题类型
+	Backend   string `json:"backend"`              // 存储后端
+	ObjectKey string `json:"object_key"`           // 对象键
+	BlobID    uint   `json:"blob_id,omitempty"`    // 存储对象记录ID，未去重的历史文件为0
+	FileIDs   []uint `json:"file_ids,omitempty"`   // 当前内容为该对象的文件，包括回收站中的文件
+	Expected  string `json:"expected,omitempty"`   // 记录中的大小或哈希
+	Actual    string `json:"actual,omitempty"`     // 实际的大小或哈希
+	Detail    string `json:"detail,omitempty"`     // 说明
+	Repair    string `json:"repair,omitempty"`     // 修复结果：quarantined、marked
+	RepairErr string `json:"repair_err,omitempty"` // 修复失败的原因
+}
+
+// IntegrityCheckRequest 发起一致性检查请求
+type IntegrityCheckRequest struct {
+	Verify bool `json:"verify"` // 读取全部内容校验SHA-256，耗时较长
+	Repair bool `json:"repair"` // 孤立对象移入隔离区，缺失或损坏的文件标记为损坏
+}
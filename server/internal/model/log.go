@@ -7,22 +7,73 @@ type SystemLog struct {
 	Username  string `gorm:"size:32" json:"username"` // 操作用户名
 	Module    string `gorm:"size:32" json:"module"`   // 操作模块
 	Action    string `gorm:"size:32" json:"action"`   // 操作类型
-	Resource  string `gorm:"size:64" json:"resource"` // 操作资源
+	Resource  string `gorm:"size:64" json:"resource"` // 操作资源ID
 	Detail    string `gorm:"size:255" json:"detail"`  // 操作详情
 	IP        string `gorm:"size:32" json:"ip"`       // 操作IP
 	UserAgent string `gorm:"size:255" json:"user_agent"` // 用户代理
-	Status    int    `gorm:"default:1" json:"status"` // 操作状态：1成功，0失败
+	Status    int    `json:"status"`                   // 操作状态：1成功，0失败（不设默认值，避免失败状态被写成1）
+
+	RequestID  string `gorm:"size:64;index" json:"request_id"` // 请求ID，对应X-Request-ID
+	Method     string `gorm:"size:10" json:"method"`           // HTTP方法
+	Path       string `gorm:"size:128;index" json:"path"`      // 路由路径，如 /api/files/:id
+	StatusCode int    `gorm:"index" json:"status_code"`        // HTTP状态码
+	Duration   int64  `gorm:"index" json:"duration"`           // 处理耗时（毫秒）
+
+	Browser        string `gorm:"size:64" json:"browser"`         // 浏览器名称（不含版本），由用户代理解析
+	BrowserVersion string `gorm:"size:32" json:"browser_version"` // 浏览器主版本号
+	OS             string `gorm:"size:64" json:"os"`              // 操作系统名称（不含版本），由用户代理解析
+	OSVersion      string `gorm:"size:32" json:"os_version"`      // 操作系统版本
+	Device         string `gorm:"size:16;index" json:"device"`    // 设备类型：desktop、mobile、tablet、bot、other
+	Country        string `gorm:"size:64;index" json:"country"`   // 国家，由IP地理位置库查询
+	Region         string `gorm:"size:64" json:"region"`          // 省/州
+	City           string `gorm:"size:64" json:"city"`            // 城市
+	NewCountry     bool   `json:"new_country"`                    // 登录成功且该用户此前未从此国家登录过
+	NewDevice      bool   `json:"new_device"`                     // 登录成功且该用户此前未使用此设备登录过
+
+	Highlight map[string]string `gorm:"-" json:"highlight,omitempty"` // 全文检索命中字段的高亮片段
 }
 
-// LogListRequest 日志列表请求参数
-type LogListRequest struct {
+// LogFilter 日志筛选条件
+type LogFilter struct {
 	Module    string `form:"module" json:"module"`
 	Action    string `form:"action" json:"action"`
 	Username  string `form:"username" json:"username"`
 	StartTime string `form:"start_time" json:"start_time"`
 	EndTime   string `form:"end_time" json:"end_time"`
+	Status    *int   `form:"status" json:"status"` // 1成功，0失败，不传则不筛选
+
+	RequestID   string `form:"request_id" json:"request_id"`
+	Method      string `form:"method" json:"method"`
+	StatusCode  int    `form:"status_code" json:"status_code"`
+	MinDuration *int64 `form:"min_duration" json:"min_duration"` // 最小耗时（毫秒）
+	MaxDuration *int64 `form:"max_duration" json:"max_duration"` // 最大耗时（毫秒）
+
+	Country string `form:"country" json:"country"`
+	Device  string `form:"device" json:"device"`
+	Flagged bool   `form:"flagged" json:"flagged"` // 仅显示来自新国家或新设备的登录
+
+	Keyword string `form:"keyword" json:"keyword"` // 全文检索详情、资源、用户代理和IP，多个关键词以空格分隔，"双引号"内为短语
+}
+
+// LogListRequest 日志列表请求参数
+type LogListRequest struct {
+	LogFilter
 	Page      int    `form:"page" json:"page" binding:"required,min=1"`
 	PageSize  int    `form:"page_size" json:"page_size" binding:"required,min=1,max=100"`
+	SortBy    string `form:"sort_by" json:"sort_by" binding:"omitempty,oneof=created_at duration status_code"` // 排序字段，默认created_at
+	SortOrder string `form:"sort_order" json:"sort_order" binding:"omitempty,oneof=asc desc"`                 // 排序方向，默认desc
+}
+
+// LogExportRequest 日志导出请求参数
+type LogExportRequest struct {
+	LogFilter
+	Format string `form:"format" json:"format"` // csv、jsonl、xlsx，默认csv
+}
+
+// LogStreamRequest 实时日志流请求参数
+type LogStreamRequest struct {
+	LogFilter
+	LastEventID uint `form:"last_event_id" json:"last_event_id"` // 断线重连游标，也可通过Last-Event-ID请求头传递
 }
 
 // LogListResponse 日志列表响应
@@ -37,6 +88,9 @@ const (
 	LogModuleRole   = "role"   // 角色模块
 	LogModuleSystem = "system" // 系统模块
 	LogModuleAuth   = "auth"   // 认证模块
+	LogModuleFile   = "file"   // 文件模块
+	LogModuleLog    = "log"    // 日志模块
+	LogModuleAlert  = "alert"  // 告警模块
 )
 
 // 日志操作类型常量
@@ -51,4 +105,20 @@ const (
 	LogActionImport = "import" // 导入
 	LogActionEnable = "enable" // 启用
 	LogActionDisable = "disable" // 禁用
+	LogActionUpload   = "upload"   // 上传
+	LogActionDownload = "download" // 下载
+	LogActionClear    = "clear"    // 清空
+	LogActionRevoke   = "revoke"   // 注销会话
+	LogActionShare    = "share"    // 创建分享链接
+	LogActionShareDownload = "share_download" // 通过分享链接下载
+	LogActionRestore  = "restore"  // 从回收站恢复
+	LogActionPurge    = "purge"    // 彻底删除
+	LogActionScan     = "scan"     // 病毒扫描
+)
+
+// 日志导出格式常量
+const (
+	LogExportCSV   = "csv"   // CSV
+	LogExportJSONL = "jsonl" // JSON Lines
+	LogExportXLSX  = "xlsx"  // Excel
 ) 
\ No newline at end of file
@@ -0,0 +1,72 @@
+package model
+
+// LogAnalyticsRequest 日志分析请求参数
+type LogAnalyticsRequest struct {
+	StartTime   string `form:"start_time" json:"start_time"`                                           // 默认最近7天
+	EndTime     string `form:"end_time" json:"end_time"`                                               // 默认当前时间
+	Granularity string `form:"granularity" json:"granularity" binding:"omitempty,oneof=hour day week"` // 趋势粒度，默认day
+	Limit       int    `form:"limit" json:"limit" binding:"omitempty,min=1,max=100"`                   // 排行数量，默认10
+}
+
+// UnusualHourRequest 非工作时间活动请求参数
+type UnusualHourRequest struct {
+	LogAnalyticsRequest
+	WorkStart int `form:"work_start" json:"work_start" binding:"omitempty,min=0,max=23"` // 工作时间开始（含），默认8
+	WorkEnd   int `form:"work_end" json:"work_end" binding:"omitempty,min=1,max=24"`     // 工作时间结束（不含），默认20
+}
+
+// FirstSeenRequest 首次出现的IP/用户代理请求参数
+type FirstSeenRequest struct {
+	LogAnalyticsRequest
+	Kind     string `form:"kind" json:"kind" binding:"omitempty,oneof=ip user_agent"` // 默认ip
+	Username string `form:"username" json:"username"`
+}
+
+// TrendPoint 趋势数据点
+type TrendPoint struct {
+	Bucket string `json:"bucket"`
+	Count  int64  `json:"count"`
+}
+
+// FailedLoginStat 登录失败统计
+type FailedLoginStat struct {
+	Key    string `json:"key"` // 用户名或IP
+	Count  int64  `json:"count"`
+	LastAt string `json:"last_at"`
+}
+
+// FailedLoginAnalytics 登录失败分析结果
+type FailedLoginAnalytics struct {
+	Trend  []TrendPoint      `json:"trend"`
+	ByUser []FailedLoginStat `json:"by_user"`
+	ByIP   []FailedLoginStat `json:"by_ip"`
+}
+
+// UserActivityStat 用户活跃度统计
+type UserActivityStat struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Count    int64  `json:"count"`
+	Failed   int64  `json:"failed"`
+	LastAt   string `json:"last_at"`
+}
+
+// EndpointStat 接口调用统计
+type EndpointStat struct {
+	Method      string  `json:"method"`
+	Path        string  `json:"path"`
+	Count       int64   `json:"count"`
+	Errors      int64   `json:"errors"`
+	ErrorRate   float64 `json:"error_rate"`
+	AvgDuration float64 `json:"avg_duration"` // 平均耗时（毫秒）
+	MaxDuration int64   `json:"max_duration"` // 最大耗时（毫秒）
+}
+
+// FirstSeenStat 用户首次出现的IP或用户代理
+type FirstSeenStat struct {
+	Username  string `json:"username"`
+	Value     string `json:"value"` // IP或用户代理
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+	Count     int64  `json:"count"`
+}
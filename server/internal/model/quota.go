@@ -0,0 +1,44 @@
+package model
+
+// Quota 存储配额，按角色设置，用户单独设置的配额优先于角色配额
+type Quota struct {
+	Base
+	Role     string `gorm:"size:16;index" json:"role"` // 角色名称，与UserID二选一
+	UserID   uint   `gorm:"index" json:"user_id"`      // 用户ID，与Role二选一
+	MaxBytes int64  `gorm:"not null" json:"max_bytes"` // 文件总大小上限（字节），0表示不限制
+	MaxFiles int64  `gorm:"not null" json:"max_files"` // 文件数量上限，0表示不限制
+}
+
+// QuotaRequest 设置配额请求，Role和UserID二选一
+type QuotaRequest struct {
+	Role     string `json:"role"`
+	UserID   uint   `json:"user_id"`
+	MaxBytes int64  `json:"max_bytes" binding:"min=0"`
+	MaxFiles int64  `json:"max_files" binding:"min=0"`
+}
+
+// QuotaUsage 用户的存储用量和生效的配额
+type QuotaUsage struct {
+	UserID       uint    `json:"user_id"`
+	UsedBytes    int64   `json:"used_bytes"`    // 已用大小，包括回收站中的文件和历史版本，内容相同的文件分别计算
+	UsedFiles    int64   `json:"used_files"`    // 已有文件数，不含回收站中的文件
+	MaxBytes     int64   `json:"max_bytes"`     // 大小上限，0表示不限制
+	MaxFiles     int64   `json:"max_files"`     // 数量上限，0表示不限制
+	Source       string  `json:"source"`        // 配额来源：user、role，空表示未设置配额
+	BytesPercent float64 `json:"bytes_percent"` // 大小用量百分比
+	FilesPercent float64 `json:"files_percent"` // 数量用量百分比
+}
+
+// QuotaReportItem 配额用量报告中的一项
+type QuotaReportItem struct {
+	QuotaUsage
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+	Role     string `json:"role"`
+}
+
+// 配额来源
+const (
+	QuotaSourceUser = "user"
+	QuotaSourceRole = "role"
+)
@@ -35,6 +35,13 @@ const (
 	PermissionSystemConfig = "system:config" // 系统配置
 	PermissionSystemLog    = "log:view"      // 查看日志
 	PermissionLogDelete    = "log:delete"    // 删除日志
+	PermissionLogExport    = "log:export"    // 导出日志
+)
+
+// 告警管理权限
+const (
+	PermissionAlertView   = "alert:view"   // 查看告警
+	PermissionAlertManage = "alert:manage" // 管理告警规则
 )
 
 // 内容管理权限
@@ -82,4 +89,9 @@ const (
 	PermissionFileUpload = "file:upload" // 上传文件
 	PermissionFileUpdate = "file:update" // 更新文件
 	PermissionFileDelete = "file:delete" // 删除文件
+	PermissionFileQuota  = "file:quota"  // 管理存储配额
+	PermissionFileShare  = "file:share"  // 创建分享链接
+	PermissionFilePurge  = "file:purge"  // 彻底删除回收站中的文件
+	PermissionFileCheck  = "file:check"  // 检查和修复存储一致性
+	PermissionFileScan   = "file:scan"   // 重新扫描病毒
 ) 
\ No newline at end of file
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// UserSession 登录会话，每次登录签发的令牌对应一条记录，撤销后令牌立即失效
+type UserSession struct {
+	Base
+	UserID       uint       `gorm:"index" json:"user_id"`         // 用户ID
+	SessionID    string     `gorm:"size:64;uniqueIndex" json:"-"` // 会话ID，对应令牌的jti
+	IP           string     `gorm:"size:64" json:"ip"`            // 登录IP
+	UserAgent    string     `gorm:"size:255" json:"user_agent"`   // 用户代理
+	Browser      string     `gorm:"size:64" json:"browser"`       // 浏览器
+	OS           string     `gorm:"size:64" json:"os"`            // 操作系统
+	Device       string     `gorm:"size:16" json:"device"`        // 设备类型
+	Location     string     `gorm:"size:128" json:"location"`     // 登录地点
+	LastActiveAt time.Time  `json:"last_active_at"`               // 最近活跃时间
+	ExpiresAt    time.Time  `gorm:"index" json:"expires_at"`      // 过期时间
+	RevokedAt    *time.Time `gorm:"index" json:"revoked_at"`      // 撤销时间
+	Current      bool       `gorm:"-" json:"current"`             // 是否为当前请求所用的会话
+}
+
+// UserDevice 用户登录过的设备，按浏览器、操作系统和设备类型区分
+type UserDevice struct {
+	Base
+	UserID      uint      `gorm:"uniqueIndex:idx_user_device" json:"user_id"`             // 用户ID
+	Fingerprint string    `gorm:"size:64;uniqueIndex:idx_user_device" json:"fingerprint"` // 设备标识
+	Browser     string    `gorm:"size:64" json:"browser"`                                 // 浏览器
+	OS          string    `gorm:"size:64" json:"os"`                                      // 操作系统
+	Device      string    `gorm:"size:16" json:"device"`                                  // 设备类型
+	LastIP      string    `gorm:"size:64" json:"last_ip"`                                 // 最近登录IP
+	Location    string    `gorm:"size:128" json:"location"`                               // 最近登录地点
+	LoginCount  int64     `json:"login_count"`                                            // 登录次数
+	LastLoginAt time.Time `json:"last_login_at"`                                          // 最近登录时间
+}
+
+// LoginHistoryRequest 登录记录查询参数
+type LoginHistoryRequest struct {
+	Status   *int `form:"status" binding:"omitempty,oneof=0 1"` // 1成功，0失败，不传则不筛选
+	Page     int  `form:"page" binding:"required,min=1"`
+	PageSize int  `form:"page_size" binding:"required,min=1,max=100"`
+}
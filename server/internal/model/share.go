@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// FileShare 文件分享链接，通过签名令牌免登录下载，可设置有效期、访问密码和下载次数上限
+type FileShare struct {
+	Base
+	FileID       uint       `gorm:"index;not null" json:"file_id"`    // 分享的文件ID
+	CreatedBy    uint       `gorm:"index;not null" json:"created_by"` // 创建者ID
+	Nonce        string     `gorm:"size:32;not null" json:"-"`        // 随机数，参与令牌签名
+	Password     string     `gorm:"size:255" json:"-"`                // 访问密码（bcrypt），为空表示无需密码
+	ExpiresAt    time.Time  `gorm:"index" json:"expires_at"`          // 过期时间
+	MaxDownloads int        `gorm:"default:0" json:"max_downloads"`   // 下载次数上限，0表示不限制
+	Downloads    int        `gorm:"default:0" json:"downloads"`       // 通过该链接下载的次数，不计入文件的下载次数
+	LastAccessAt *time.Time `json:"last_access_at"`                   // 最近一次下载时间
+	RevokedAt    *time.Time `json:"revoked_at"`                       // 撤销时间，为空表示未撤销
+
+	FileName    string `gorm:"-" json:"file_name,omitempty"` // 文件名
+	HasPassword bool   `gorm:"-" json:"has_password"`        // 是否设置了访问密码
+	Token       string `gorm:"-" json:"token,omitempty"`     // 签名令牌
+	URL         string `gorm:"-" json:"url,omitempty"`       // 分享下载地址
+}
+
+// ShareCreateRequest 创建分享链接请求
+type ShareCreateRequest struct {
+	FileID       uint   `json:"file_id" binding:"required"`
+	ExpiresIn    int    `json:"expires_in" binding:"min=0"`    // 有效期（秒），0表示使用默认有效期
+	Password     string `json:"password" binding:"max=64"`     // 访问密码，可选
+	MaxDownloads int    `json:"max_downloads" binding:"min=0"` // 下载次数上限，0表示不限制
+}
+
+// ShareListRequest 分享链接列表请求
+type ShareListRequest struct {
+	FileID   uint `form:"file_id"`
+	All      bool `form:"all"` // 查看所有人创建的链接，需要删除文件权限
+	Page     int  `form:"page" binding:"min=1"`
+	PageSize int  `form:"page_size" binding:"min=1,max=100"`
+}
+
+// ShareListResponse 分享链接列表响应
+type ShareListResponse struct {
+	Total int         `json:"total"`
+	List  []FileShare `json:"list"`
+}
+
+// ShareInfo 分享链接的公开信息，供访问者确认后下载
+type ShareInfo struct {
+	FileName     string    `json:"file_name"`
+	FileSize     int64     `json:"file_size"`
+	FileType     string    `json:"file_type"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	HasPassword  bool      `json:"has_password"`
+	MaxDownloads int       `json:"max_downloads"`
+	Downloads    int       `json:"downloads"`
+}
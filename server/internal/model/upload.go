@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Upload tus断点续传上传任务，数据先写入临时文件，接收完整后转存为文件记录
+type Upload struct {
+	Base
+	UploadID    string    `gorm:"size:64;uniqueIndex" json:"upload_id"` // 上传任务ID，对应上传地址中的标识
+	UserID      uint      `gorm:"index" json:"user_id"`                 // 创建者ID
+	Length      int64     `json:"length"`                               // 文件总大小
+	Offset      int64     `json:"offset"`                               // 已接收的字节数
+	FileName    string    `gorm:"size:255" json:"file_name"`            // 文件名
+	Category    string    `gorm:"size:50" json:"category"`              // 文件分类
+	Description string    `gorm:"size:500" json:"description"`          // 文件描述
+	FolderID    uint      `json:"folder_id"`                            // 上传到的文件夹ID
+	Metadata    string    `gorm:"size:2048" json:"-"`                   // 创建时的Upload-Metadata原文，HEAD时原样返回
+	FileID      uint      `json:"file_id"`                              // 上传完成后生成的文件ID
+	ExpiresAt   time.Time `gorm:"index" json:"expires_at"`              // 过期时间，过期未完成的任务会被清理
+}
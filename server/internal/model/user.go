@@ -11,6 +11,8 @@ type User struct {
 	Nickname string `gorm:"size:32" json:"nickname"`
 	Avatar   string `gorm:"size:256" json:"avatar"`
 	Role     string `gorm:"size:16" json:"role"`
+	Email    string `gorm:"size:128" json:"email"` // 接收账号安全提醒的邮箱，可为空
+	Department string `gorm:"size:64;index" json:"department"` // 所属部门，用于文件访问控制，可为空
 	Status   int    `gorm:"default:1" json:"status"` // 1: 正常, 0: 禁用
 }
 
@@ -30,9 +32,13 @@ type CreateUserRequest struct {
 	Password string `json:"password" binding:"required"`
 	Nickname string `json:"nickname" binding:"required"`
 	Role     string `json:"role" binding:"required"`
+	Email    string `json:"email" binding:"omitempty,email"`
+	Department string `json:"department" binding:"max=64"`
 }
 
 type UpdateUserRequest struct {
 	Nickname string `json:"nickname" binding:"required"`
 	Role     string `json:"role" binding:"required"`
+	Email    *string `json:"email"` // 未提供时保持不变，空字符串表示清除，格式由处理器校验
+	Department *string `json:"department" binding:"omitempty,max=64"` // 未提供时保持不变，空字符串表示清除
 } 
\ No newline at end of file
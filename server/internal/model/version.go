@@ -0,0 +1,30 @@
+package model
+
+import "mime/multipart"
+
+// FileVersion 文件的历史版本，每个版本引用一份存储对象，当前内容也对应其中一个版本
+type FileVersion struct {
+	Base
+	FileID     uint   `gorm:"uniqueIndex:idx_file_version;not null" json:"file_id"` // 所属文件ID
+	Version    int    `gorm:"uniqueIndex:idx_file_version;not null" json:"version"` // 版本号，从1开始递增
+	FileName   string `gorm:"size:255;not null" json:"file_name"`                   // 上传时的文件名
+	FileSize   int64  `gorm:"not null" json:"file_size"`                            // 文件大小
+	FileType   string `gorm:"size:50" json:"file_type"`                             // 文件类型
+	MimeType   string `gorm:"size:128" json:"mime_type"`                            // 按文件头识别的MIME类型
+	Backend    string `gorm:"size:16" json:"backend"`                               // 存储后端
+	ObjectKey  string `gorm:"size:255" json:"object_key"`                           // 存储后端中的对象键
+	Hash       string `gorm:"size:64;index" json:"hash"`                            // 内容的SHA-256
+	BlobID     uint   `gorm:"index" json:"blob_id"`                                 // 引用的存储对象ID
+	UploadedBy uint   `gorm:"not null" json:"uploaded_by"`                          // 上传者ID
+	Comment    string `gorm:"size:255" json:"comment"`                              // 版本说明
+
+	UploaderName string `gorm:"-" json:"uploader_name"` // 上传者用户名
+	Current      bool   `gorm:"-" json:"current"`       // 是否为当前版本
+}
+
+// FileVersionUploadRequest 上传新版本请求
+type FileVersionUploadRequest struct {
+	File    *multipart.FileHeader `form:"file" binding:"required"`
+	Comment string                `form:"comment" binding:"max=255"`
+	SHA256  string                `form:"sha256"` // 可选，客户端计算的SHA-256
+}
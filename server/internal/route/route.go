@@ -0,0 +1,184 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"jing_vue_gin_admin/server/internal/middleware"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Meta 路由元数据，集中声明路由所属模块、操作类型、资源ID提取方式和所需权限
+type Meta struct {
+	Method     string                    `json:"method"`
+	Path       string                    `json:"path"`                 // 注册时补全为完整路径
+	Module     string                    `json:"module,omitempty"`     // 日志模块，为空表示不记录操作日志
+	Action     string                    `json:"action,omitempty"`     // 日志操作类型
+	Permission string                    `json:"permission,omitempty"` // 所需权限，为空表示登录即可访问
+	Resource   middleware.ResourceIDFunc `json:"-"`                    // 资源ID提取函数
+	Summary    string                    `json:"summary"`              // 路由说明
+	Public     bool                      `json:"public"`               // 是否无需登录
+	// SelfLogged 表示日志由处理链中的其他中间件记录（如登录日志），不再追加操作日志
+	SelfLogged bool `json:"-"`
+}
+
+var (
+	mu     sync.RWMutex
+	routes []Meta
+)
+
+// Handle 按元数据注册路由：依次挂载权限校验、操作日志和处理函数，并记录到路由表
+func Handle(group *gin.RouterGroup, meta Meta, handlers ...gin.HandlerFunc) {
+	relativePath := meta.Path
+	meta.Path = joinPath(group.BasePath(), relativePath)
+
+	chain := make([]gin.HandlerFunc, 0, len(handlers)+2)
+	if meta.Permission != "" {
+		chain = append(chain, middleware.RequirePermission(meta.Permission))
+	}
+	if meta.Module != "" && !meta.SelfLogged {
+		chain = append(chain, middleware.OperationLogWithResource(meta.Module, meta.Action, meta.Resource))
+	}
+	chain = append(chain, handlers...)
+
+	group.Handle(meta.Method, relativePath, chain...)
+
+	mu.Lock()
+	routes = append(routes, meta)
+	mu.Unlock()
+}
+
+// All 返回已注册的全部路由
+func All() []Meta {
+	mu.RLock()
+	defer mu.RUnlock()
+	result := make([]Meta, len(routes))
+	copy(result, routes)
+	return result
+}
+
+// Modules 返回已注册路由涉及的日志模块，按注册顺序去重
+func Modules() []string {
+	return distinct(func(m Meta) string { return m.Module })
+}
+
+// Actions 返回已注册路由涉及的日志操作类型，按注册顺序去重
+func Actions() []string {
+	return distinct(func(m Meta) string { return m.Action })
+}
+
+// Table 生成Markdown格式的路由清单
+func Table() string {
+	var b strings.Builder
+	b.WriteString("| 方法 | 路径 | 模块 | 操作 | 权限 | 说明 |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, m := range All() {
+		permission := m.Permission
+		if m.Public {
+			permission = "公开"
+		} else if permission == "" {
+			permission = "登录用户"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+			m.Method, m.Path, dash(m.Module), dash(m.Action), permission, m.Summary)
+	}
+	return b.String()
+}
+
+// Param 从路径参数中提取资源ID
+func Param(name string) middleware.ResourceIDFunc {
+	return func(c *gin.Context) string {
+		return c.Param(name)
+	}
+}
+
+// BodyField 从JSON请求体中提取资源ID，字段可以是数字、字符串或数组
+func BodyField(name string) middleware.ResourceIDFunc {
+	return func(c *gin.Context) string {
+		body, ok := c.Get("requestBody")
+		if !ok {
+			return ""
+		}
+		data, _ := body.([]byte)
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return ""
+		}
+		raw, ok := fields[name]
+		if !ok {
+			return ""
+		}
+
+		var list []json.RawMessage
+		if err := json.Unmarshal(raw, &list); err == nil {
+			ids := make([]string, 0, len(list))
+			for _, item := range list {
+				ids = append(ids, rawString(item))
+			}
+			return strings.Join(ids, ",")
+		}
+		return rawString(raw)
+	}
+}
+
+// Created 提取处理函数通过 c.Set("resourceID", id) 写入的资源ID，用于创建类接口
+func Created() middleware.ResourceIDFunc {
+	return func(c *gin.Context) string {
+		id, ok := c.Get("resourceID")
+		if !ok {
+			return ""
+		}
+		switch v := id.(type) {
+		case uint:
+			return strconv.FormatUint(uint64(v), 10)
+		case string:
+			return v
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+}
+
+func rawString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+func distinct(field func(Meta) string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, m := range All() {
+		value := field(m)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, value)
+	}
+	return result
+}
+
+func joinPath(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+	joined := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(joined, "/") {
+		joined += "/"
+	}
+	return joined
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
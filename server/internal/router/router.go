@@ -0,0 +1,237 @@
+package router
+
+import (
+	"jing_vue_gin_admin/server/internal/handler"
+	"jing_vue_gin_admin/server/internal/middleware"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/route"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Setup 注册全部API路由，每个路由的模块、操作类型、资源ID和权限都在这里声明
+func Setup(r *gin.Engine) {
+	// 创建各种处理器实例
+	userHandler := handler.NewUserHandler()
+	roleHandler := handler.NewRoleHandler()
+	logHandler := handler.NewLogHandler()
+	logAnalyticsHandler := handler.NewLogAnalyticsHandler()
+	fileHandler := handler.NewFileHandler()
+	dashboardHandler := handler.NewDashboardHandler()
+	routeHandler := handler.NewRouteHandler()
+	alertHandler := handler.NewAlertHandler()
+	notificationHandler := handler.NewNotificationHandler()
+	securityHandler := handler.NewSecurityHandler()
+	tusHandler := handler.NewTusHandler()
+	quotaHandler := handler.NewQuotaHandler()
+	imageHandler := handler.NewImageHandler()
+	shareHandler := handler.NewShareHandler()
+	versionHandler := handler.NewVersionHandler()
+	folderHandler := handler.NewFolderHandler()
+	aclHandler := handler.NewACLHandler()
+	trashHandler := handler.NewTrashHandler()
+	integrityHandler := handler.NewIntegrityHandler()
+	scanHandler := handler.NewScanHandler()
+
+	// API路由组
+	api := r.Group("/api")
+
+	// 公共路由组
+	public := api.Group("")
+	{
+		route.Handle(public, route.Meta{Method: http.MethodPost, Path: "/login", Module: model.LogModuleAuth, Action: model.LogActionLogin, Public: true, SelfLogged: true, Summary: "用户登录"},
+			middleware.LoginLogMiddleware(), userHandler.Login)
+		route.Handle(public, route.Meta{Method: http.MethodOptions, Path: "/files/tus", Public: true, Summary: "断点续传能力查询"}, tusHandler.Options)
+		route.Handle(public, route.Meta{Method: http.MethodOptions, Path: "/files/tus/:id", Public: true, Summary: "断点续传能力查询"}, tusHandler.Options)
+		route.Handle(public, route.Meta{Method: http.MethodGet, Path: "/s/:token", Public: true, Summary: "分享链接信息"}, shareHandler.GetShareInfo)
+		route.Handle(public, route.Meta{Method: http.MethodGet, Path: "/thumbnails/:token", Public: true, Summary: "图片缩略图（签名地址）"}, imageHandler.GetSignedThumbnail)
+		route.Handle(public, route.Meta{Method: http.MethodGet, Path: "/s/:token/download", Module: model.LogModuleFile, Action: model.LogActionShareDownload, Public: true, Resource: route.Created(), Summary: "通过分享链接下载"}, shareHandler.DownloadShare)
+	}
+
+	// 需要身份验证的路由组
+	auth := api.Group("")
+	auth.Use(middleware.AuthMiddleware())
+	{
+		route.Handle(auth, route.Meta{Method: http.MethodPost, Path: "/logout", Module: model.LogModuleAuth, Action: model.LogActionLogout, Summary: "退出登录"}, securityHandler.Logout)
+
+		// 当前用户的账号安全路由，只能查看和操作自己的数据
+		securityRoutes := auth.Group("/security")
+		{
+			route.Handle(securityRoutes, route.Meta{Method: http.MethodGet, Path: "/logins", Summary: "我的登录记录"}, securityHandler.GetLoginHistory)
+			route.Handle(securityRoutes, route.Meta{Method: http.MethodGet, Path: "/sessions", Summary: "我的在线会话"}, securityHandler.GetSessions)
+			route.Handle(securityRoutes, route.Meta{Method: http.MethodDelete, Path: "/sessions/:id", Module: model.LogModuleAuth, Action: model.LogActionRevoke, Resource: route.Param("id"), Summary: "注销会话"}, securityHandler.RevokeSession)
+			route.Handle(securityRoutes, route.Meta{Method: http.MethodDelete, Path: "/sessions", Module: model.LogModuleAuth, Action: model.LogActionRevoke, Summary: "注销其他会话"}, securityHandler.RevokeOtherSessions)
+			route.Handle(securityRoutes, route.Meta{Method: http.MethodGet, Path: "/devices", Summary: "我的设备"}, securityHandler.GetDevices)
+			route.Handle(securityRoutes, route.Meta{Method: http.MethodDelete, Path: "/devices/:id", Module: model.LogModuleAuth, Action: model.LogActionDelete, Resource: route.Param("id"), Summary: "移除设备"}, securityHandler.DeleteDevice)
+		}
+
+		// 用户相关路由
+		userRoutes := auth.Group("/users")
+		{
+			route.Handle(userRoutes, route.Meta{Method: http.MethodGet, Path: "", Module: model.LogModuleUser, Action: model.LogActionView, Permission: model.PermissionUserView, Summary: "用户列表"}, userHandler.GetUsers)
+			route.Handle(userRoutes, route.Meta{Method: http.MethodGet, Path: "/:id", Module: model.LogModuleUser, Action: model.LogActionView, Permission: model.PermissionUserView, Resource: route.Param("id"), Summary: "用户详情"}, userHandler.GetUser)
+			route.Handle(userRoutes, route.Meta{Method: http.MethodPost, Path: "", Module: model.LogModuleUser, Action: model.LogActionCreate, Permission: model.PermissionUserCreate, Resource: route.Created(), Summary: "创建用户"}, userHandler.CreateUser)
+			route.Handle(userRoutes, route.Meta{Method: http.MethodPut, Path: "/:id", Module: model.LogModuleUser, Action: model.LogActionUpdate, Permission: model.PermissionUserEdit, Resource: route.Param("id"), Summary: "更新用户"}, userHandler.UpdateUser)
+			route.Handle(userRoutes, route.Meta{Method: http.MethodDelete, Path: "/:id", Module: model.LogModuleUser, Action: model.LogActionDelete, Permission: model.PermissionUserDelete, Resource: route.Param("id"), Summary: "删除用户"}, userHandler.DeleteUser)
+			route.Handle(userRoutes, route.Meta{Method: http.MethodPut, Path: "/:id/status", Module: model.LogModuleUser, Action: model.LogActionUpdate, Permission: model.PermissionUserEdit, Resource: route.Param("id"), Summary: "更新用户状态"}, userHandler.UpdateUserStatus)
+			route.Handle(userRoutes, route.Meta{Method: http.MethodGet, Path: "/current", Summary: "当前登录用户"}, userHandler.GetCurrentUser)
+		}
+
+		// 角色相关路由
+		roleRoutes := auth.Group("/roles")
+		{
+			route.Handle(roleRoutes, route.Meta{Method: http.MethodGet, Path: "", Module: model.LogModuleRole, Action: model.LogActionView, Permission: model.PermissionRoleView, Summary: "角色列表"}, roleHandler.GetRoles)
+			route.Handle(roleRoutes, route.Meta{Method: http.MethodGet, Path: "/:id", Module: model.LogModuleRole, Action: model.LogActionView, Permission: model.PermissionRoleView, Resource: route.Param("id"), Summary: "角色详情"}, roleHandler.GetRole)
+			route.Handle(roleRoutes, route.Meta{Method: http.MethodPost, Path: "", Module: model.LogModuleRole, Action: model.LogActionCreate, Permission: model.PermissionRoleCreate, Resource: route.Created(), Summary: "创建角色"}, roleHandler.CreateRole)
+			route.Handle(roleRoutes, route.Meta{Method: http.MethodPut, Path: "/:id", Module: model.LogModuleRole, Action: model.LogActionUpdate, Permission: model.PermissionRoleEdit, Resource: route.Param("id"), Summary: "更新角色"}, roleHandler.UpdateRole)
+			route.Handle(roleRoutes, route.Meta{Method: http.MethodDelete, Path: "/:id", Module: model.LogModuleRole, Action: model.LogActionDelete, Permission: model.PermissionRoleDelete, Resource: route.Param("id"), Summary: "删除角色"}, roleHandler.DeleteRole)
+			route.Handle(roleRoutes, route.Meta{Method: http.MethodGet, Path: "/all/permissions", Permission: model.PermissionRoleView, Summary: "全部权限"}, roleHandler.GetAllPermissions)
+		}
+
+		// 日志相关路由
+		logRoutes := auth.Group("/logs")
+		{
+			route.Handle(logRoutes, route.Meta{Method: http.MethodGet, Path: "", Module: model.LogModuleLog, Action: model.LogActionView, Permission: model.PermissionSystemLog, Summary: "日志列表"}, logHandler.GetLogList)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodDelete, Path: "", Module: model.LogModuleLog, Action: model.LogActionDelete, Permission: model.PermissionLogDelete, Resource: route.BodyField("ids"), Summary: "批量删除日志"}, logHandler.DeleteLogs)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodDelete, Path: "/clear", Module: model.LogModuleLog, Action: model.LogActionClear, Permission: model.PermissionLogDelete, Summary: "清空日志"}, logHandler.ClearLogs)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodGet, Path: "/modules", Permission: model.PermissionSystemLog, Summary: "日志模块列表"}, logHandler.GetLogModules)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodGet, Path: "/actions", Permission: model.PermissionSystemLog, Summary: "日志操作类型列表"}, logHandler.GetLogActions)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodGet, Path: "/stream", Permission: model.PermissionSystemLog, Summary: "实时日志流（SSE）"}, logHandler.StreamLogs)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodPost, Path: "/stream/token", Permission: model.PermissionSystemLog, Summary: "签发实时日志流令牌"}, logHandler.CreateStreamToken)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodGet, Path: "/analytics/failed-logins", Module: model.LogModuleLog, Action: model.LogActionView, Permission: model.PermissionSystemLog, Summary: "登录失败趋势"}, logAnalyticsHandler.GetFailedLogins)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodGet, Path: "/analytics/top-users", Module: model.LogModuleLog, Action: model.LogActionView, Permission: model.PermissionSystemLog, Summary: "活跃用户排行"}, logAnalyticsHandler.GetTopUsers)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodGet, Path: "/analytics/top-endpoints", Module: model.LogModuleLog, Action: model.LogActionView, Permission: model.PermissionSystemLog, Summary: "接口调用排行"}, logAnalyticsHandler.GetTopEndpoints)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodGet, Path: "/analytics/error-rates", Module: model.LogModuleLog, Action: model.LogActionView, Permission: model.PermissionSystemLog, Summary: "接口错误率"}, logAnalyticsHandler.GetErrorRates)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodGet, Path: "/analytics/unusual-hours", Module: model.LogModuleLog, Action: model.LogActionView, Permission: model.PermissionSystemLog, Summary: "非工作时间活动"}, logAnalyticsHandler.GetUnusualHours)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodGet, Path: "/analytics/first-seen", Module: model.LogModuleLog, Action: model.LogActionView, Permission: model.PermissionSystemLog, Summary: "首次出现的IP/用户代理"}, logAnalyticsHandler.GetFirstSeen)
+			route.Handle(logRoutes, route.Meta{Method: http.MethodGet, Path: "/export", Module: model.LogModuleLog, Action: model.LogActionExport, Permission: model.PermissionLogExport, Summary: "导出日志"}, logHandler.ExportLogs)
+		}
+
+		// 文件相关路由
+		fileRoutes := auth.Group("/files")
+		{
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodPost, Path: "", Module: model.LogModuleFile, Action: model.LogActionUpload, Permission: model.PermissionFileUpload, Resource: route.Created(), Summary: "上传文件"}, fileHandler.UploadFile)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "", Module: model.LogModuleFile, Action: model.LogActionView, Permission: model.PermissionFileView, Summary: "文件列表"}, fileHandler.GetFileList)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "/:id", Module: model.LogModuleFile, Action: model.LogActionView, Permission: model.PermissionFileView, Resource: route.Param("id"), Summary: "文件详情"}, fileHandler.GetFile)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodPut, Path: "", Module: model.LogModuleFile, Action: model.LogActionUpdate, Permission: model.PermissionFileUpdate, Resource: route.BodyField("id"), Summary: "更新文件信息"}, fileHandler.UpdateFile)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodDelete, Path: "/:id", Module: model.LogModuleFile, Action: model.LogActionDelete, Permission: model.PermissionFileDelete, Resource: route.Param("id"), Summary: "删除文件"}, fileHandler.DeleteFile)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodPost, Path: "/batch/delete", Module: model.LogModuleFile, Action: model.LogActionDelete, Permission: model.PermissionFileDelete, Resource: route.BodyField("ids"), Summary: "批量删除文件"}, fileHandler.BatchDeleteFiles)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "/batch/download", Module: model.LogModuleFile, Action: model.LogActionDownload, Permission: model.PermissionFileView, Resource: route.Created(), Summary: "批量下载文件"}, fileHandler.DownloadArchive)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodPost, Path: "/batch/download", Module: model.LogModuleFile, Action: model.LogActionDownload, Permission: model.PermissionFileView, Resource: route.Created(), Summary: "批量下载文件"}, fileHandler.DownloadArchive)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodPost, Path: "/move", Module: model.LogModuleFile, Action: model.LogActionUpdate, Permission: model.PermissionFileUpdate, Resource: route.BodyField("ids"), Summary: "移动文件"}, fileHandler.MoveFiles)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodPost, Path: "/copy", Module: model.LogModuleFile, Action: model.LogActionCreate, Permission: model.PermissionFileUpload, Resource: route.BodyField("ids"), Summary: "复制文件"}, fileHandler.CopyFiles)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "/download/:id", Module: model.LogModuleFile, Action: model.LogActionDownload, Permission: model.PermissionFileView, Resource: route.Param("id"), Summary: "下载文件"}, fileHandler.DownloadFile)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "/:id/thumbnail", Permission: model.PermissionFileView, Summary: "图片缩略图"}, imageHandler.GetThumbnail)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "/:id/variant", Permission: model.PermissionFileView, Summary: "图片缩放裁剪和格式转换"}, imageHandler.GetVariant)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "/:id/versions", Permission: model.PermissionFileView, Summary: "文件版本列表"}, versionHandler.GetVersions)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodPost, Path: "/:id/versions", Module: model.LogModuleFile, Action: model.LogActionUpload, Permission: model.PermissionFileUpdate, Resource: route.Param("id"), Summary: "上传新版本"}, versionHandler.UploadVersion)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "/:id/versions/:version/download", Module: model.LogModuleFile, Action: model.LogActionDownload, Permission: model.PermissionFileView, Resource: route.Param("id"), Summary: "下载文件版本"}, versionHandler.DownloadVersion)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodPost, Path: "/:id/versions/:version/restore", Module: model.LogModuleFile, Action: model.LogActionUpdate, Permission: model.PermissionFileUpdate, Resource: route.Param("id"), Summary: "恢复文件版本"}, versionHandler.RestoreVersion)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodDelete, Path: "/:id/versions/:version", Module: model.LogModuleFile, Action: model.LogActionDelete, Permission: model.PermissionFileDelete, Resource: route.Param("id"), Summary: "删除文件版本"}, versionHandler.DeleteVersion)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodPost, Path: "/:id/scan", Module: model.LogModuleFile, Action: model.LogActionScan, Permission: model.PermissionFileScan, Resource: route.Param("id"), Summary: "重新扫描文件"}, scanHandler.RescanFile)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodPost, Path: "/scan", Module: model.LogModuleFile, Action: model.LogActionScan, Permission: model.PermissionFileScan, Summary: "批量重新扫描文件"}, scanHandler.RescanAll)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "/:id/acl", Permission: model.PermissionFileView, Summary: "文件访问权限"}, aclHandler.GetFileACL)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodPut, Path: "/:id/acl", Module: model.LogModuleFile, Action: model.LogActionUpdate, Permission: model.PermissionFileUpdate, Resource: route.Param("id"), Summary: "设置文件访问权限"}, aclHandler.SetFileACL)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "/stats", Permission: model.PermissionFileView, Summary: "文件统计"}, fileHandler.GetFileStats)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "/categories", Permission: model.PermissionFileView, Summary: "文件分类列表"}, fileHandler.GetCategories)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "/types", Permission: model.PermissionFileView, Summary: "文件类型列表"}, fileHandler.GetFileTypes)
+			route.Handle(fileRoutes, route.Meta{Method: http.MethodGet, Path: "/usage", Summary: "我的存储用量"}, quotaHandler.GetMyUsage)
+		}
+
+		// 文件夹相关路由
+		folderRoutes := auth.Group("/folders")
+		{
+			route.Handle(folderRoutes, route.Meta{Method: http.MethodGet, Path: "/tree", Permission: model.PermissionFileView, Summary: "文件夹树"}, folderHandler.GetTree)
+			route.Handle(folderRoutes, route.Meta{Method: http.MethodGet, Path: "/contents", Permission: model.PermissionFileView, Summary: "按路径获取文件夹内容"}, folderHandler.GetContents)
+			route.Handle(folderRoutes, route.Meta{Method: http.MethodGet, Path: "/:id", Permission: model.PermissionFileView, Summary: "文件夹内容"}, folderHandler.GetContents)
+			route.Handle(folderRoutes, route.Meta{Method: http.MethodPost, Path: "", Module: model.LogModuleFile, Action: model.LogActionCreate, Permission: model.PermissionFileUpload, Resource: route.Created(), Summary: "创建文件夹"}, folderHandler.CreateFolder)
+			route.Handle(folderRoutes, route.Meta{Method: http.MethodPut, Path: "/:id", Module: model.LogModuleFile, Action: model.LogActionUpdate, Permission: model.PermissionFileUpdate, Resource: route.Param("id"), Summary: "重命名文件夹"}, folderHandler.RenameFolder)
+			route.Handle(folderRoutes, route.Meta{Method: http.MethodPost, Path: "/:id/move", Module: model.LogModuleFile, Action: model.LogActionUpdate, Permission: model.PermissionFileUpdate, Resource: route.Param("id"), Summary: "移动文件夹"}, folderHandler.MoveFolder)
+			route.Handle(folderRoutes, route.Meta{Method: http.MethodGet, Path: "/:id/acl", Permission: model.PermissionFileView, Summary: "文件夹访问权限"}, aclHandler.GetFolderACL)
+			route.Handle(folderRoutes, route.Meta{Method: http.MethodPut, Path: "/:id/acl", Module: model.LogModuleFile, Action: model.LogActionUpdate, Permission: model.PermissionFileUpdate, Resource: route.Param("id"), Summary: "设置文件夹访问权限"}, aclHandler.SetFolderACL)
+			route.Handle(folderRoutes, route.Meta{Method: http.MethodDelete, Path: "/:id", Module: model.LogModuleFile, Action: model.LogActionDelete, Permission: model.PermissionFileDelete, Resource: route.Param("id"), Summary: "删除文件夹"}, folderHandler.DeleteFolder)
+		}
+
+		// 回收站路由，删除的文件可恢复，彻底删除需要单独的权限
+		trashRoutes := auth.Group("/files/trash")
+		{
+			route.Handle(trashRoutes, route.Meta{Method: http.MethodGet, Path: "", Permission: model.PermissionFileView, Summary: "回收站文件列表"}, trashHandler.GetTrash)
+			route.Handle(trashRoutes, route.Meta{Method: http.MethodPost, Path: "/:id/restore", Module: model.LogModuleFile, Action: model.LogActionRestore, Permission: model.PermissionFileDelete, Resource: route.Param("id"), Summary: "恢复文件"}, trashHandler.RestoreFile)
+			route.Handle(trashRoutes, route.Meta{Method: http.MethodDelete, Path: "/:id", Module: model.LogModuleFile, Action: model.LogActionPurge, Permission: model.PermissionFilePurge, Resource: route.Param("id"), Summary: "彻底删除文件"}, trashHandler.PurgeFile)
+			route.Handle(trashRoutes, route.Meta{Method: http.MethodDelete, Path: "", Module: model.LogModuleFile, Action: model.LogActionPurge, Permission: model.PermissionFilePurge, Summary: "清空回收站"}, trashHandler.EmptyTrash)
+		}
+
+		// 存储一致性检查路由，检查在后台进行
+		integrityRoutes := auth.Group("/files/integrity")
+		{
+			route.Handle(integrityRoutes, route.Meta{Method: http.MethodGet, Path: "", Permission: model.PermissionFileCheck, Summary: "一致性检查报告列表"}, integrityHandler.GetReports)
+			route.Handle(integrityRoutes, route.Meta{Method: http.MethodGet, Path: "/:id", Permission: model.PermissionFileCheck, Summary: "一致性检查报告"}, integrityHandler.GetReport)
+			route.Handle(integrityRoutes, route.Meta{Method: http.MethodPost, Path: "", Module: model.LogModuleFile, Action: model.LogActionCreate, Permission: model.PermissionFileCheck, Resource: route.Created(), Summary: "发起存储一致性检查"}, integrityHandler.StartCheck)
+		}
+
+		// 断点续传（tus 1.0）路由，只有完成上传的请求记录操作日志
+		tusRoutes := auth.Group("/files/tus")
+		tusRoutes.Use(tusHandler.Resumable)
+		{
+			route.Handle(tusRoutes, route.Meta{Method: http.MethodPost, Path: "", Module: model.LogModuleFile, Action: model.LogActionUpload, Permission: model.PermissionFileUpload, Resource: route.Created(), Summary: "创建断点续传任务"}, tusHandler.CreateUpload)
+			route.Handle(tusRoutes, route.Meta{Method: http.MethodHead, Path: "/:id", Permission: model.PermissionFileUpload, Summary: "查询断点续传进度"}, tusHandler.GetOffset)
+			route.Handle(tusRoutes, route.Meta{Method: http.MethodPatch, Path: "/:id", Module: model.LogModuleFile, Action: model.LogActionUpload, Permission: model.PermissionFileUpload, Resource: route.Created(), Summary: "上传数据块"}, tusHandler.WriteChunk)
+			route.Handle(tusRoutes, route.Meta{Method: http.MethodDelete, Path: "/:id", Permission: model.PermissionFileUpload, Summary: "终止断点续传任务"}, tusHandler.Terminate)
+		}
+
+		// 文件分享链接路由
+		shareRoutes := auth.Group("/shares")
+		{
+			route.Handle(shareRoutes, route.Meta{Method: http.MethodPost, Path: "", Module: model.LogModuleFile, Action: model.LogActionShare, Permission: model.PermissionFileShare, Resource: route.Created(), Summary: "创建分享链接"}, shareHandler.CreateShare)
+			route.Handle(shareRoutes, route.Meta{Method: http.MethodGet, Path: "", Permission: model.PermissionFileShare, Summary: "分享链接列表"}, shareHandler.GetShares)
+			route.Handle(shareRoutes, route.Meta{Method: http.MethodDelete, Path: "/:id", Module: model.LogModuleFile, Action: model.LogActionRevoke, Permission: model.PermissionFileShare, Resource: route.Param("id"), Summary: "撤销分享链接"}, shareHandler.RevokeShare)
+		}
+
+		// 存储配额相关路由
+		quotaRoutes := auth.Group("/quotas")
+		{
+			route.Handle(quotaRoutes, route.Meta{Method: http.MethodGet, Path: "", Permission: model.PermissionFileQuota, Summary: "配额列表"}, quotaHandler.GetQuotas)
+			route.Handle(quotaRoutes, route.Meta{Method: http.MethodPut, Path: "", Module: model.LogModuleFile, Action: model.LogActionUpdate, Permission: model.PermissionFileQuota, Resource: route.Created(), Summary: "设置配额"}, quotaHandler.SetQuota)
+			route.Handle(quotaRoutes, route.Meta{Method: http.MethodDelete, Path: "/:id", Module: model.LogModuleFile, Action: model.LogActionDelete, Permission: model.PermissionFileQuota, Resource: route.Param("id"), Summary: "删除配额"}, quotaHandler.DeleteQuota)
+			route.Handle(quotaRoutes, route.Meta{Method: http.MethodGet, Path: "/report", Permission: model.PermissionFileQuota, Summary: "配额用量报告"}, quotaHandler.GetReport)
+		}
+
+		// 仪表盘相关路由
+		dashboardRoutes := auth.Group("/dashboard")
+		{
+			route.Handle(dashboardRoutes, route.Meta{Method: http.MethodGet, Path: "/stats", Summary: "仪表盘统计"}, dashboardHandler.GetDashboardStats)
+			route.Handle(dashboardRoutes, route.Meta{Method: http.MethodGet, Path: "/system-info", Summary: "系统信息"}, dashboardHandler.GetSystemInfo)
+			route.Handle(dashboardRoutes, route.Meta{Method: http.MethodGet, Path: "/user-activities", Summary: "用户活跃度"}, dashboardHandler.GetUserActivities)
+			route.Handle(dashboardRoutes, route.Meta{Method: http.MethodGet, Path: "/file-types", Summary: "文件类型分布"}, dashboardHandler.GetFileTypeDistribution)
+			route.Handle(dashboardRoutes, route.Meta{Method: http.MethodGet, Path: "/log-types", Summary: "日志类型分布"}, dashboardHandler.GetLogTypeDistribution)
+			route.Handle(dashboardRoutes, route.Meta{Method: http.MethodGet, Path: "/recent-registrations", Summary: "最近注册趋势"}, dashboardHandler.GetRecentRegistrations)
+		}
+
+		// 告警相关路由
+		alertRoutes := auth.Group("/alerts")
+		{
+			route.Handle(alertRoutes, route.Meta{Method: http.MethodGet, Path: "/rules", Permission: model.PermissionAlertView, Summary: "告警规则列表"}, alertHandler.GetRules)
+			route.Handle(alertRoutes, route.Meta{Method: http.MethodGet, Path: "/rules/:id", Permission: model.PermissionAlertView, Summary: "告警规则详情"}, alertHandler.GetRule)
+			route.Handle(alertRoutes, route.Meta{Method: http.MethodPost, Path: "/rules", Module: model.LogModuleAlert, Action: model.LogActionCreate, Permission: model.PermissionAlertManage, Resource: route.Created(), Summary: "创建告警规则"}, alertHandler.CreateRule)
+			route.Handle(alertRoutes, route.Meta{Method: http.MethodPut, Path: "/rules/:id", Module: model.LogModuleAlert, Action: model.LogActionUpdate, Permission: model.PermissionAlertManage, Resource: route.Param("id"), Summary: "更新告警规则"}, alertHandler.UpdateRule)
+			route.Handle(alertRoutes, route.Meta{Method: http.MethodDelete, Path: "/rules/:id", Module: model.LogModuleAlert, Action: model.LogActionDelete, Permission: model.PermissionAlertManage, Resource: route.Param("id"), Summary: "删除告警规则"}, alertHandler.DeleteRule)
+			route.Handle(alertRoutes, route.Meta{Method: http.MethodGet, Path: "/history", Permission: model.PermissionAlertView, Summary: "告警记录"}, alertHandler.GetHistory)
+		}
+
+		// 站内通知相关路由
+		notificationRoutes := auth.Group("/notifications")
+		{
+			route.Handle(notificationRoutes, route.Meta{Method: http.MethodGet, Path: "", Summary: "我的通知"}, notificationHandler.GetNotifications)
+			route.Handle(notificationRoutes, route.Meta{Method: http.MethodGet, Path: "/unread-count", Summary: "未读通知数"}, notificationHandler.GetUnreadCount)
+			route.Handle(notificationRoutes, route.Meta{Method: http.MethodPut, Path: "/:id/read", Summary: "标记通知已读"}, notificationHandler.MarkRead)
+			route.Handle(notificationRoutes, route.Meta{Method: http.MethodPut, Path: "/read-all", Summary: "全部标记已读"}, notificationHandler.MarkAllRead)
+		}
+
+		// 系统相关路由
+		systemRoutes := auth.Group("/system")
+		{
+			route.Handle(systemRoutes, route.Meta{Method: http.MethodGet, Path: "/routes", Permission: model.PermissionSystemConfig, Summary: "路由清单"}, routeHandler.GetRoutes)
+		}
+	}
+}
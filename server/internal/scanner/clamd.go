@@ -0,0 +1,129 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize INSTREAM每个数据块的大小，需小于clamd的StreamMaxLength
+const clamdChunkSize = 64 << 10
+
+// Clamd 通过clamd的INSTREAM命令扫描，内容按块发送，不需要与clamd共享文件系统
+type Clamd struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamd 创建clamd扫描器，address可以是 tcp://host:port、unix:///path/to/clamd.sock 或 host:port
+func NewClamd(address string, timeout time.Duration) (*Clamd, error) {
+	network, addr := "tcp", address
+	switch {
+	case strings.HasPrefix(address, "tcp://"):
+		addr = strings.TrimPrefix(address, "tcp://")
+	case strings.HasPrefix(address, "unix://"):
+		network, addr = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "/"):
+		network = "unix"
+	}
+	if addr == "" {
+		return nil, errors.New("scanner: 未配置clamd地址")
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	return &Clamd{network: network, address: addr, timeout: timeout}, nil
+}
+
+// Name 扫描器名称
+func (c *Clamd) Name() string {
+	return BackendClamd
+}
+
+// Scan 发送zINSTREAM命令，内容以4字节大端长度前缀分块发送，长度为0的块表示结束
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return nil, fmt.Errorf("scanner: 连接clamd失败: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("scanner: 发送扫描命令失败: %w", err)
+	}
+	buf := make([]byte, clamdChunkSize)
+	header := make([]byte, 4)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(header, uint32(n))
+			if _, err := conn.Write(header); err != nil {
+				return c.earlyReply(conn, err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return c.earlyReply(conn, err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("scanner: 读取内容失败: %w", readErr)
+		}
+	}
+	binary.BigEndian.PutUint32(header, 0)
+	if _, err := conn.Write(header); err != nil {
+		return c.earlyReply(conn, err)
+	}
+	return readReply(conn)
+}
+
+// earlyReply 超过StreamMaxLength等情况下clamd会提前回复并关闭连接，优先返回clamd的回复
+func (c *Clamd) earlyReply(conn net.Conn, writeErr error) (*Result, error) {
+	if result, err := readReply(conn); err == nil || !errors.Is(err, errNoReply) {
+		return result, err
+	}
+	return nil, fmt.Errorf("scanner: 发送内容失败: %w", writeErr)
+}
+
+var errNoReply = errors.New("scanner: clamd没有回复")
+
+// readReply 解析回复：stream: OK、stream: <特征> FOUND 或 <说明> ERROR
+func readReply(conn net.Conn) (*Result, error) {
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if len(reply) == 0 {
+		if err == nil || errors.Is(err, io.EOF) {
+			return nil, errNoReply
+		}
+		return nil, fmt.Errorf("scanner: 读取clamd回复失败: %w", err)
+	}
+	line := strings.TrimSpace(string(bytes.TrimRight(reply, "\x00")))
+	line = strings.TrimPrefix(line, "stream: ")
+	switch {
+	case line == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(line, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(line, " FOUND")}, nil
+	case strings.HasSuffix(line, " ERROR"):
+		return nil, fmt.Errorf("scanner: clamd扫描失败: %s", strings.TrimSuffix(line, " ERROR"))
+	}
+	return nil, fmt.Errorf("scanner: 无法识别clamd回复: %q", line)
+}
@@ -0,0 +1,203 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd 在本地监听器上模拟clamd的INSTREAM命令，reply根据收到的内容返回回复，limit不为0时超过该长度提前回复
+type fakeClamd struct {
+	reply func(data []byte) string
+	limit int
+}
+
+func (f *fakeClamd) serve(t *testing.T, ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			cmd, err := r.ReadString(0)
+			if err != nil || cmd != "zINSTREAM\x00" {
+				t.Errorf("unexpected command %q: %v", cmd, err)
+				return
+			}
+			var data []byte
+			header := make([]byte, 4)
+			for {
+				if _, err := io.ReadFull(r, header); err != nil {
+					return
+				}
+				n := binary.BigEndian.Uint32(header)
+				if n == 0 {
+					break
+				}
+				if n > clamdChunkSize {
+					t.Errorf("chunk of %d bytes exceeds %d", n, clamdChunkSize)
+				}
+				chunk := make([]byte, n)
+				if _, err := io.ReadFull(r, chunk); err != nil {
+					return
+				}
+				data = append(data, chunk...)
+				if f.limit > 0 && len(data) > f.limit {
+					conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+					return
+				}
+			}
+			conn.Write([]byte(f.reply(data) + "\x00"))
+		}()
+	}
+}
+
+// startClamd 启动模拟clamd，返回TCP地址
+func startClamd(t *testing.T, f *fakeClamd) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go f.serve(t, ln)
+	return "tcp://" + ln.Addr().String()
+}
+
+func signatureReply(data []byte) string {
+	if bytes.Contains(data, []byte(eicar)) {
+		return "stream: Eicar-Test-Signature FOUND"
+	}
+	return "stream: OK"
+}
+
+func TestClamdScan(t *testing.T) {
+	addr := startClamd(t, &fakeClamd{reply: signatureReply})
+	c, err := NewClamd(addr, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 跨多个数据块的内容
+	clean := strings.Repeat("a", clamdChunkSize*2+10)
+	result, err := c.Scan(context.Background(), strings.NewReader(clean))
+	if err != nil || result.Infected {
+		t.Fatalf("clean content: %+v, %v", result, err)
+	}
+
+	infected := strings.Repeat("b", clamdChunkSize-5) + eicar
+	result, err = c.Scan(context.Background(), strings.NewReader(infected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Infected || result.Signature != "Eicar-Test-Signature" {
+		t.Errorf("infected content: %+v", result)
+	}
+}
+
+func TestClamdErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		clamd *fakeClamd
+		want  string
+	}{
+		{"error reply", &fakeClamd{reply: func([]byte) string { return "Can't allocate memory ERROR" }}, "clamd扫描失败: Can't allocate memory"},
+		{"unknown reply", &fakeClamd{reply: func([]byte) string { return "stream: ???" }}, "无法识别clamd回复"},
+		{"size limit", &fakeClamd{reply: signatureReply, limit: 1024}, "INSTREAM size limit exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClamd(startClamd(t, tt.clamd), 5*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 内容远大于连接缓冲区，超限时clamd在发送过程中回复并关闭连接
+			_, err = c.Scan(context.Background(), strings.NewReader(strings.Repeat("x", 8<<20)))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClamdTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	// 接受连接但从不回复
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(io.Discard, conn)
+		}
+	}()
+
+	c, err := NewClamd(ln.Addr().String(), 200*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := c.Scan(context.Background(), strings.NewReader("data")); err == nil {
+		t.Fatal("scan without reply should fail")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("scan took %v, timeout not applied", elapsed)
+	}
+}
+
+func TestNewClamdAddress(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "clamd.sock")
+	tests := []struct {
+		address, network, addr string
+	}{
+		{"tcp://127.0.0.1:3310", "tcp", "127.0.0.1:3310"},
+		{"127.0.0.1:3310", "tcp", "127.0.0.1:3310"},
+		{"unix://" + sock, "unix", sock},
+		{sock, "unix", sock},
+	}
+	for _, tt := range tests {
+		c, err := NewClamd(tt.address, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.network != tt.network || c.address != tt.addr || c.timeout != 5*time.Minute {
+			t.Errorf("NewClamd(%q) = %s %s %v", tt.address, c.network, c.address, c.timeout)
+		}
+	}
+	if _, err := NewClamd("unix://", 0); err == nil {
+		t.Errorf("empty address should be rejected")
+	}
+}
+
+func TestClamdUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "clamd.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix socket unavailable: %v", err)
+	}
+	defer ln.Close()
+	go (&fakeClamd{reply: signatureReply}).serve(t, ln)
+
+	c, err := NewClamd("unix://"+sock, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := c.Scan(context.Background(), strings.NewReader(eicar))
+	if err != nil || !result.Infected {
+		t.Errorf("unix socket scan: %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+)
+
+// eicar EICAR标准测试文件的特征字符串，各类杀毒软件都会将其识别为病毒
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// Fake 模拟扫描器，用于测试和开发环境：内容包含EICAR测试字符串或Signatures中的任一标记时视为感染
+type Fake struct {
+	Signatures map[string]string // 标记 -> 病毒特征名称
+	Delay      time.Duration     // 模拟扫描耗时
+	Err        error             // 不为nil时扫描返回该错误
+}
+
+// NewFake 创建只识别EICAR测试字符串的模拟扫描器
+func NewFake() *Fake {
+	return &Fake{Signatures: map[string]string{eicar: "Eicar-Test-Signature"}}
+}
+
+// Name 扫描器名称
+func (f *Fake) Name() string {
+	return BackendFake
+}
+
+// Scan 读取全部内容查找标记
+func (f *Fake) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	if f.Delay > 0 {
+		select {
+		case <-time.After(f.Delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	for marker, signature := range f.Signatures {
+		if bytes.Contains(data, []byte(marker)) {
+			return &Result{Infected: true, Signature: signature}, nil
+		}
+	}
+	return &Result{}, nil
+}
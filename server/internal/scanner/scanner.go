@@ -0,0 +1,27 @@
+// Package scanner 上传文件的病毒扫描，提供clamd和用于测试的模拟实现
+package scanner
+
+import (
+	"context"
+	"io"
+)
+
+// 扫描器名称，与配置中的scan.backend一致
+const (
+	BackendClamd = "clamd"
+	BackendFake  = "fake"
+)
+
+// Result 扫描结果
+type Result struct {
+	Infected  bool   // 是否发现病毒
+	Signature string // 命中的病毒特征名称
+}
+
+// Scanner 病毒扫描器，Scan读取完整内容后返回结果，扫描器不可用或扫描失败时返回错误
+type Scanner interface {
+	// Name 扫描器名称
+	Name() string
+	// Scan 扫描内容
+	Scan(ctx context.Context, r io.Reader) (*Result, error)
+}
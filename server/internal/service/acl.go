@@ -0,0 +1,598 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"log"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// UploadErrFolderForbidden 没有在目标文件夹中新建文件的权限
+const UploadErrFolderForbidden = "FOLDER_FORBIDDEN"
+
+var (
+	// ErrAccessDenied 没有访问文件或文件夹的权限
+	ErrAccessDenied = errors.New("没有访问权限")
+	// ErrACLForbidden 只有所有者或管理员可以设置访问控制
+	ErrACLForbidden = errors.New("只有所有者或管理员可以设置访问权限")
+	// ErrACLSubject 授权对象无效
+	ErrACLSubject = errors.New("授权对象不存在")
+)
+
+// ACLService 文件访问控制服务
+type ACLService struct{}
+
+// NewACLService 创建文件访问控制服务实例
+func NewACLService() *ACLService {
+	return &ACLService{}
+}
+
+// Access 用户的文件访问权限，加载了全部文件夹及其访问控制条目，可在一次请求内重复使用
+//
+// 判断规则：管理员和所有者（文件上传者或任一上级文件夹的创建者）拥有全部权限；
+// 文件自身和各级上级文件夹都没有条目时不限制（仍受角色权限约束）；
+// 否则只要自身或任一上级文件夹上有授予当前用户、其角色或部门的条目即可访问
+type Access struct {
+	userID     uint
+	role       string
+	department string
+	admin      bool
+	folders    map[uint]model.Folder
+	folderACL  map[uint][]model.FileACL
+}
+
+// AccessFor 加载用户的文件访问权限
+func (s *ACLService) AccessFor(userID uint) (*Access, error) {
+	var user model.User
+	if config.DB.Select("id", "role", "department").Limit(1).Find(&user, userID).RowsAffected == 0 {
+		return nil, errors.New("用户不存在")
+	}
+
+	access := &Access{
+		userID:     user.ID,
+		role:       user.Role,
+		department: user.Department,
+		admin:      user.Role == "admin",
+	}
+	if err := access.loadFolders(); err != nil {
+		return nil, err
+	}
+	return access, nil
+}
+
+// loadFolders 加载全部文件夹及其访问控制条目
+func (a *Access) loadFolders() error {
+	a.folders = map[uint]model.Folder{}
+	a.folderACL = map[uint][]model.FileACL{}
+
+	var folders []model.Folder
+	if err := config.DB.Select("id", "name", "parent_id", "path", "created_by").Find(&folders).Error; err != nil {
+		return fmt.Errorf("获取文件夹失败: %w", err)
+	}
+	for _, folder := range folders {
+		a.folders[folder.ID] = folder
+	}
+	var entries []model.FileACL
+	if err := config.DB.Where("resource_type = ?", model.ACLResourceFolder).Find(&entries).Error; err != nil {
+		return fmt.Errorf("获取访问控制条目失败: %w", err)
+	}
+	for _, entry := range entries {
+		a.folderACL[entry.ResourceID] = append(a.folderACL[entry.ResourceID], entry)
+	}
+	return nil
+}
+
+// File 判断是否拥有文件的指定权限
+func (a *Access) File(file *model.File, permission string) bool {
+	return len(a.FilterFiles([]model.File{*file}, permission)) == 1
+}
+
+// FilterFiles 过滤出拥有指定权限的文件，文件自身的条目一次查询加载
+func (a *Access) FilterFiles(files []model.File, permission string) []model.File {
+	if a.admin {
+		return files
+	}
+
+	ids := make([]uint, 0, len(files))
+	for _, file := range files {
+		ids = append(ids, file.ID)
+	}
+	entries := map[uint][]model.FileACL{}
+	if len(ids) > 0 {
+		var list []model.FileACL
+		if err := config.DB.Where("resource_type = ? AND resource_id IN ?", model.ACLResourceFile, ids).Find(&list).Error; err != nil {
+			log.Printf("获取文件的访问控制条目失败: %v", err)
+			return nil
+		}
+		for _, entry := range list {
+			entries[entry.ResourceID] = append(entries[entry.ResourceID], entry)
+		}
+	}
+
+	allowed := make([]model.File, 0, len(files))
+	for _, file := range files {
+		if a.fileAllowed(&file, entries[file.ID], permission) {
+			allowed = append(allowed, file)
+		}
+	}
+	return allowed
+}
+
+// fileAllowed 根据文件自身的条目和所在文件夹判断权限
+func (a *Access) fileAllowed(file *model.File, entries []model.FileACL, permission string) bool {
+	if file.UploadedBy == a.userID {
+		return true
+	}
+	granted, restricted := a.chainGrant(file.FolderID, permission)
+	if granted {
+		return true
+	}
+	for _, entry := range entries {
+		if a.grants(entry, permission) {
+			return true
+		}
+	}
+	return !restricted && len(entries) == 0
+}
+
+// Folder 判断是否拥有文件夹的指定权限，根目录不受限制
+func (a *Access) Folder(folderID uint, permission string) bool {
+	if a.admin || folderID == 0 {
+		return true
+	}
+	granted, restricted := a.chainGrant(folderID, permission)
+	return granted || !restricted
+}
+
+// Scope 只保留有查看权限的文件，用于文件列表等查询
+func (a *Access) Scope(db *gorm.DB) *gorm.DB {
+	if a.admin {
+		return db
+	}
+
+	// 按文件夹分为：已授权（其中的文件都可见）和不受限（其中没有自身条目的文件可见）
+	open := []uint{0}
+	var granted []uint
+	for id := range a.folders {
+		ok, restricted := a.chainGrant(id, model.ACLView)
+		if ok {
+			granted = append(granted, id)
+		}
+		if ok || !restricted {
+			open = append(open, id)
+		}
+	}
+
+	fileEntries := config.DB.Model(&model.FileACL{}).Select("resource_id").
+		Where("resource_type = ?", model.ACLResourceFile)
+	matched := config.DB.Model(&model.FileACL{}).Select("resource_id").
+		Where("resource_type = ?", model.ACLResourceFile).
+		Where(a.subjectCondition())
+
+	cond := config.DB.Where("uploaded_by = ?", a.userID).
+		Or("id IN (?)", matched).
+		Or("folder_id IN ? AND id NOT IN (?)", open, fileEntries)
+	if len(granted) > 0 {
+		cond = cond.Or("folder_id IN ?", granted)
+	}
+	return db.Where(cond)
+}
+
+// Folders 过滤出有查看权限的文件夹
+func (a *Access) Folders(folders []model.Folder) []model.Folder {
+	visible := make([]model.Folder, 0, len(folders))
+	for _, folder := range folders {
+		if a.Folder(folder.ID, model.ACLView) {
+			visible = append(visible, folder)
+		}
+	}
+	return visible
+}
+
+// canManage 是否可以设置资源的访问控制，只有管理员和所有者可以
+func (a *Access) canManage(ownerID, parentID uint) bool {
+	if a.admin || ownerID == a.userID {
+		return true
+	}
+	for _, id := range a.chain(parentID) {
+		if a.folders[id].CreatedBy == a.userID {
+			return true
+		}
+	}
+	return false
+}
+
+// chainGrant 检查文件夹及其各级上级文件夹：是否授予了权限，以及是否设置了访问限制
+func (a *Access) chainGrant(folderID uint, permission string) (granted, restricted bool) {
+	for _, id := range a.chain(folderID) {
+		if a.folders[id].CreatedBy == a.userID {
+			return true, restricted
+		}
+		for _, entry := range a.folderACL[id] {
+			restricted = true
+			if a.grants(entry, permission) {
+				return true, true
+			}
+		}
+	}
+	return false, restricted
+}
+
+// chain 文件夹自身及其各级上级文件夹的ID，从近到远
+func (a *Access) chain(folderID uint) []uint {
+	var ids []uint
+	seen := map[uint]bool{}
+	for id := folderID; id != 0 && !seen[id]; {
+		folder, ok := a.folders[id]
+		if !ok {
+			break
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		id = folder.ParentID
+	}
+	return ids
+}
+
+// grants 条目是否授予当前用户指定权限，拥有任一权限即可查看
+func (a *Access) grants(entry model.FileACL, permission string) bool {
+	switch entry.SubjectType {
+	case model.ACLSubjectUser:
+		if entry.SubjectID != a.userID {
+			return false
+		}
+	case model.ACLSubjectRole:
+		if entry.SubjectName != a.role {
+			return false
+		}
+	case model.ACLSubjectDepartment:
+		if a.department == "" || entry.SubjectName != a.department {
+			return false
+		}
+	default:
+		return false
+	}
+	if permission == model.ACLView {
+		return true
+	}
+	for _, p := range entry.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// subjectCondition 匹配授予当前用户、其角色或部门的条目
+func (a *Access) subjectCondition() *gorm.DB {
+	cond := config.DB.Where("subject_type = ? AND subject_id = ?", model.ACLSubjectUser, a.userID).
+		Or("subject_type = ? AND subject_name = ?", model.ACLSubjectRole, a.role)
+	if a.department != "" {
+		cond = cond.Or("subject_type = ? AND subject_name = ?", model.ACLSubjectDepartment, a.department)
+	}
+	return cond
+}
+
+// CheckFile 获取文件并检查当前用户的权限，没有权限时返回ErrAccessDenied
+func (s *ACLService) CheckFile(userID, fileID uint, permission string) (*model.File, error) {
+	file, err := NewFileService().GetFileByID(fileID)
+	if err != nil {
+		return nil, err
+	}
+	access, err := s.AccessFor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !access.File(file, permission) {
+		return nil, ErrAccessDenied
+	}
+	return file, nil
+}
+
+// DeniedFiles 返回用户没有指定权限的文件ID，不存在的文件不计入
+func (s *ACLService) DeniedFiles(userID uint, ids []uint, permission string) ([]uint, error) {
+	access, err := s.AccessFor(userID)
+	if err != nil {
+		return nil, err
+	}
+	var files []model.File
+	if err := config.DB.Where("id IN ?", ids).Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("获取文件失败: %w", err)
+	}
+	allowed := map[uint]bool{}
+	for _, file := range access.FilterFiles(files, permission) {
+		allowed[file.ID] = true
+	}
+	denied := []uint{}
+	for _, file := range files {
+		if !allowed[file.ID] {
+			denied = append(denied, file.ID)
+		}
+	}
+	return denied, nil
+}
+
+// CheckFolder 检查当前用户对文件夹的权限，folderID为0表示根目录
+func (s *ACLService) CheckFolder(userID, folderID uint, permission string) error {
+	access, err := s.AccessFor(userID)
+	if err != nil {
+		return err
+	}
+	if folderID != 0 {
+		if _, ok := access.folders[folderID]; !ok {
+			return ErrFolderNotFound
+		}
+	}
+	if !access.Folder(folderID, permission) {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// GetACL 获取文件或文件夹的访问控制列表，包括从上级文件夹继承的条目
+func (s *ACLService) GetACL(userID uint, resourceType string, id uint) (*model.ACLResponse, error) {
+	access, err := s.AccessFor(userID)
+	if err != nil {
+		return nil, err
+	}
+	ownerID, parentID, err := s.resource(access, resourceType, id)
+	if err != nil {
+		return nil, err
+	}
+	if resourceType == model.ACLResourceFile {
+		file := &model.File{Base: model.Base{ID: id}, UploadedBy: ownerID, FolderID: parentID}
+		if !access.File(file, model.ACLView) {
+			return nil, ErrAccessDenied
+		}
+	} else if !access.Folder(id, model.ACLView) {
+		return nil, ErrAccessDenied
+	}
+
+	resp := &model.ACLResponse{
+		ResourceType: resourceType,
+		ResourceID:   id,
+		OwnerID:      ownerID,
+		Entries:      []model.FileACL{},
+		Inherited:    []model.FileACL{},
+	}
+	if err := config.DB.Where("resource_type = ? AND resource_id = ?", resourceType, id).
+		Order("id ASC").Find(&resp.Entries).Error; err != nil {
+		return nil, fmt.Errorf("获取访问控制条目失败: %w", err)
+	}
+	for _, folderID := range access.chain(parentID) {
+		for _, entry := range access.folderACL[folderID] {
+			entry.InheritedVia = access.folders[folderID].Path
+			resp.Inherited = append(resp.Inherited, entry)
+		}
+	}
+	resp.Restricted = len(resp.Entries) > 0 || len(resp.Inherited) > 0
+
+	userIDs := []uint{ownerID}
+	for _, list := range [][]model.FileACL{resp.Entries, resp.Inherited} {
+		for _, entry := range list {
+			if entry.SubjectType == model.ACLSubjectUser {
+				userIDs = append(userIDs, entry.SubjectID)
+			}
+		}
+	}
+	var users []model.User
+	config.DB.Select("id", "username").Where("id IN ?", userIDs).Find(&users)
+	names := make(map[uint]string, len(users))
+	for _, user := range users {
+		names[user.ID] = user.Username
+	}
+	resp.OwnerName = names[ownerID]
+	for _, list := range [][]model.FileACL{resp.Entries, resp.Inherited} {
+		for i := range list {
+			if list[i].SubjectType == model.ACLSubjectUser {
+				list[i].SubjectLabel = names[list[i].SubjectID]
+			} else {
+				list[i].SubjectLabel = list[i].SubjectName
+			}
+		}
+	}
+	return resp, nil
+}
+
+// SetACL 整体替换文件或文件夹上的访问控制条目，同一授权对象的多个条目会合并
+func (s *ACLService) SetACL(userID uint, resourceType string, id uint, req *model.ACLUpdateRequest) (*model.ACLResponse, error) {
+	access, err := s.AccessFor(userID)
+	if err != nil {
+		return nil, err
+	}
+	ownerID, parentID, err := s.resource(access, resourceType, id)
+	if err != nil {
+		return nil, err
+	}
+	if !access.canManage(ownerID, parentID) {
+		return nil, ErrACLForbidden
+	}
+
+	entries := make([]model.FileACL, 0, len(req.Entries))
+	index := map[string]int{}
+	for _, item := range req.Entries {
+		entry := model.FileACL{
+			ResourceType: resourceType,
+			ResourceID:   id,
+			SubjectType:  item.SubjectType,
+			CreatedBy:    userID,
+		}
+		switch item.SubjectType {
+		case model.ACLSubjectUser:
+			if config.DB.Select("id").Limit(1).Find(&model.User{}, item.SubjectID).RowsAffected == 0 {
+				return nil, fmt.Errorf("%w: 用户%d", ErrACLSubject, item.SubjectID)
+			}
+			entry.SubjectID = item.SubjectID
+		case model.ACLSubjectRole:
+			if config.DB.Select("id").Where("name = ?", item.SubjectName).Limit(1).Find(&model.Role{}).RowsAffected == 0 {
+				return nil, fmt.Errorf("%w: 角色%s", ErrACLSubject, item.SubjectName)
+			}
+			entry.SubjectName = item.SubjectName
+		case model.ACLSubjectDepartment:
+			if item.SubjectName == "" {
+				return nil, fmt.Errorf("%w: 部门名称不能为空", ErrACLSubject)
+			}
+			entry.SubjectName = item.SubjectName
+		}
+
+		key := fmt.Sprintf("%s:%d:%s", entry.SubjectType, entry.SubjectID, entry.SubjectName)
+		i, ok := index[key]
+		if !ok {
+			i = len(entries)
+			index[key] = i
+			entries = append(entries, entry)
+		}
+		for _, p := range item.Permissions {
+			if !containsString(entries[i].Permissions, p) {
+				entries[i].Permissions = append(entries[i].Permissions, p)
+			}
+		}
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("resource_type = ? AND resource_id = ?", resourceType, id).
+			Delete(&model.FileACL{}).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.Create(&entries).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存访问控制条目失败: %w", err)
+	}
+	return s.GetACL(userID, resourceType, id)
+}
+
+// resource 获取资源的所有者和上级文件夹
+func (s *ACLService) resource(access *Access, resourceType string, id uint) (ownerID, parentID uint, err error) {
+	switch resourceType {
+	case model.ACLResourceFile:
+		var file model.File
+		if config.DB.Select("id", "uploaded_by", "folder_id").Limit(1).Find(&file, id).RowsAffected == 0 {
+			return 0, 0, errors.New("文件不存在")
+		}
+		return file.UploadedBy, file.FolderID, nil
+	case model.ACLResourceFolder:
+		folder, ok := access.folders[id]
+		if !ok {
+			return 0, 0, ErrFolderNotFound
+		}
+		return folder.CreatedBy, folder.ParentID, nil
+	}
+	return 0, 0, fmt.Errorf("不支持的资源类型: %s", resourceType)
+}
+
+// deleteACL 删除资源上的访问控制条目，文件或文件夹删除时调用
+func deleteACL(resourceType string, ids ...uint) {
+	if err := deleteACLIn(config.DB, resourceType, ids...); err != nil {
+		log.Printf("%v", err)
+	}
+}
+
+// deleteACLIn 在db上删除资源的访问控制条目，用于需要与其他修改在同一事务中完成的删除
+func deleteACLIn(db *gorm.DB, resourceType string, ids ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := db.Unscoped().Where("resource_type = ? AND resource_id IN ?", resourceType, ids).
+		Delete(&model.FileACL{}).Error; err != nil {
+		return fmt.Errorf("删除访问控制条目失败: %w", err)
+	}
+	return nil
+}
+
+// keepInheritedACL 删除文件夹前在事务tx中把各级文件夹上的条目合并到其中的文件上，
+// 这些文件从回收站恢复到根目录后仍只有原来有权限的用户可以访问
+func keepInheritedACL(tx *gorm.DB, files []model.File) error {
+	access := &Access{}
+	if err := access.loadFolders(); err != nil {
+		return err
+	}
+
+	ids := make([]uint, 0, len(files))
+	for _, file := range files {
+		ids = append(ids, file.ID)
+	}
+	var existing []model.FileACL
+	if len(ids) > 0 {
+		if err := tx.Where("resource_type = ? AND resource_id IN ?", model.ACLResourceFile, ids).
+			Find(&existing).Error; err != nil {
+			return fmt.Errorf("获取访问控制条目失败: %w", err)
+		}
+	}
+	own := map[uint][]model.FileACL{}
+	for _, entry := range existing {
+		own[entry.ResourceID] = append(own[entry.ResourceID], entry)
+	}
+
+	for _, file := range files {
+		var inherited []model.FileACL
+		for _, id := range access.chain(file.FolderID) {
+			inherited = append(inherited, access.folderACL[id]...)
+		}
+		if len(inherited) == 0 {
+			continue
+		}
+
+		entries := make([]model.FileACL, 0, len(own[file.ID])+len(inherited))
+		index := map[string]int{}
+		for _, entry := range append(own[file.ID], inherited...) {
+			key := fmt.Sprintf("%s:%d:%s", entry.SubjectType, entry.SubjectID, entry.SubjectName)
+			i, ok := index[key]
+			if !ok {
+				i = len(entries)
+				index[key] = i
+				entries = append(entries, model.FileACL{
+					ResourceType: model.ACLResourceFile,
+					ResourceID:   file.ID,
+					SubjectType:  entry.SubjectType,
+					SubjectID:    entry.SubjectID,
+					SubjectName:  entry.SubjectName,
+					CreatedBy:    entry.CreatedBy,
+				})
+			}
+			for _, p := range entry.Permissions {
+				if !containsString(entries[i].Permissions, p) {
+					entries[i].Permissions = append(entries[i].Permissions, p)
+				}
+			}
+		}
+
+		if err := tx.Unscoped().Where("resource_type = ? AND resource_id = ?", model.ACLResourceFile, file.ID).
+			Delete(&model.FileACL{}).Error; err != nil {
+			return fmt.Errorf("保存访问控制条目失败: %w", err)
+		}
+		if err := tx.Create(&entries).Error; err != nil {
+			return fmt.Errorf("保存访问控制条目失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// canCreateIn 检查用户能否在文件夹中新建文件，不能时返回*UploadError
+func canCreateIn(userID, folderID uint) error {
+	if folderID == 0 || userID == 0 {
+		return nil
+	}
+	err := NewACLService().CheckFolder(userID, folderID, model.ACLEdit)
+	if errors.Is(err, ErrAccessDenied) {
+		return uploadError(UploadErrFolderForbidden, http.StatusForbidden, "没有在文件夹%d中新建文件的权限", folderID)
+	}
+	return err
+}
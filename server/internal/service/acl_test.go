@@ -0,0 +1,190 @@
+package service
+
+import (
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"sort"
+	"testing"
+)
+
+// aclFixture 权限测试使用的用户、文件夹和文件
+type aclFixture struct {
+	users map[string]uint
+	files map[string]model.File
+}
+
+// newACLFixture 创建以下结构：
+//
+//	/<prefix>-team（folder-creator创建，部门ops可查看和下载）
+//	  team.txt
+//	  /sub
+//	    sub.txt
+//	    sub-own.txt（自身条目：用户bob可查看）
+//	/<prefix>-open（没有条目）
+//	  open.txt
+//	root-open.txt（没有条目）
+//	root-role.txt（自身条目：角色editor可下载）
+//
+// 所有文件都由owner上传
+func newACLFixture(t *testing.T, prefix string) *aclFixture {
+	t.Helper()
+	f := &aclFixture{
+		users: map[string]uint{
+			"owner":          createTestUser(t, prefix+"-owner", "user", ""),
+			"alice":          createTestUser(t, prefix+"-alice", "user", "ops"),
+			"bob":            createTestUser(t, prefix+"-bob", "editor", "sales"),
+			"folder-creator": createTestUser(t, prefix+"-folder-creator", "user", ""),
+			"admin":          createTestUser(t, prefix+"-admin", "admin", ""),
+		},
+		files: map[string]model.File{},
+	}
+	owner := f.users["owner"]
+
+	s := NewFolderService()
+	team, err := s.CreateFolder(f.users["folder-creator"], &model.FolderCreateRequest{Name: prefix + "-team"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := s.CreateFolder(f.users["folder-creator"], &model.FolderCreateRequest{Name: "sub", ParentID: team.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	open, err := s.CreateFolder(owner, &model.FolderCreateRequest{Name: prefix + "-open"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, folderID := range map[string]uint{
+		"team.txt": team.ID, "sub.txt": sub.ID, "sub-own.txt": sub.ID,
+		"open.txt": open.ID, "root-open.txt": 0, "root-role.txt": 0,
+	} {
+		f.files[name] = createTestRecord(t, name, folderID, owner)
+	}
+
+	entries := []model.FileACL{
+		{ResourceType: model.ACLResourceFolder, ResourceID: team.ID, SubjectType: model.ACLSubjectDepartment,
+			SubjectName: "ops", Permissions: []string{model.ACLView, model.ACLDownload}},
+		{ResourceType: model.ACLResourceFile, ResourceID: f.files["sub-own.txt"].ID, SubjectType: model.ACLSubjectUser,
+			SubjectID: f.users["bob"], Permissions: []string{model.ACLView}},
+		{ResourceType: model.ACLResourceFile, ResourceID: f.files["root-role.txt"].ID, SubjectType: model.ACLSubjectRole,
+			SubjectName: "editor", Permissions: []string{model.ACLDownload}},
+	}
+	if err := config.DB.Create(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestACLFilePermissions(t *testing.T) {
+	f := newACLFixture(t, "acl-check")
+	tests := []struct {
+		user       string
+		file       string
+		permission string
+		want       bool
+	}{
+		// 上传者和管理员拥有全部权限
+		{"owner", "root-role.txt", model.ACLDelete, true},
+		{"owner", "sub.txt", model.ACLEdit, true},
+		{"admin", "root-role.txt", model.ACLDelete, true},
+		// 文件和各级文件夹都没有条目时不限制
+		{"alice", "root-open.txt", model.ACLDownload, true},
+		{"bob", "open.txt", model.ACLDelete, true},
+		// 文件自身的角色条目
+		{"bob", "root-role.txt", model.ACLDownload, true},
+		{"bob", "root-role.txt", model.ACLView, true},
+		{"bob", "root-role.txt", model.ACLEdit, false},
+		{"alice", "root-role.txt", model.ACLView, false},
+		// 部门条目在文件夹上，对其中的文件和子文件夹中的文件生效
+		{"alice", "team.txt", model.ACLDownload, true},
+		{"alice", "sub.txt", model.ACLDownload, true},
+		{"alice", "sub.txt", model.ACLEdit, false},
+		{"bob", "sub.txt", model.ACLView, false},
+		// 文件自身的条目与继承的条目同时生效
+		{"bob", "sub-own.txt", model.ACLView, true},
+		{"bob", "sub-own.txt", model.ACLDownload, false},
+		{"alice", "sub-own.txt", model.ACLDownload, true},
+		// 上级文件夹的创建者拥有其中文件的全部权限
+		{"folder-creator", "sub.txt", model.ACLDelete, true},
+		{"folder-creator", "root-role.txt", model.ACLView, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.user+"/"+tt.file+"/"+tt.permission, func(t *testing.T) {
+			_, err := NewACLService().CheckFile(f.users[tt.user], f.files[tt.file].ID, tt.permission)
+			if got := err == nil; got != tt.want {
+				t.Errorf("CheckFile = %v, want allowed %v", err, tt.want)
+			}
+			if err != nil && err != ErrAccessDenied {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestACLFilterAndDeniedFiles(t *testing.T) {
+	f := newACLFixture(t, "acl-filter")
+	all := make([]model.File, 0, len(f.files))
+	ids := make([]uint, 0, len(f.files))
+	names := map[uint]string{}
+	for name, file := range f.files {
+		all = append(all, file)
+		ids = append(ids, file.ID)
+		names[file.ID] = name
+	}
+
+	tests := []struct {
+		user       string
+		permission string
+		denied     []string
+	}{
+		{"owner", model.ACLDelete, nil},
+		{"admin", model.ACLDelete, nil},
+		{"alice", model.ACLView, []string{"root-role.txt"}},
+		{"alice", model.ACLDownload, []string{"root-role.txt"}},
+		{"alice", model.ACLEdit, []string{"root-role.txt", "sub-own.txt", "sub.txt", "team.txt"}},
+		{"bob", model.ACLView, []string{"sub.txt", "team.txt"}},
+		{"bob", model.ACLDownload, []string{"sub-own.txt", "sub.txt", "team.txt"}},
+		{"folder-creator", model.ACLView, []string{"root-role.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.user+"/"+tt.permission, func(t *testing.T) {
+			userID := f.users[tt.user]
+			denied, err := NewACLService().DeniedFiles(userID, ids, tt.permission)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(denied))
+			for _, id := range denied {
+				got = append(got, names[id])
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.denied) {
+				t.Fatalf("denied = %v, want %v", got, tt.denied)
+			}
+			for i := range got {
+				if got[i] != tt.denied[i] {
+					t.Fatalf("denied = %v, want %v", got, tt.denied)
+				}
+			}
+
+			access, err := NewACLService().AccessFor(userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed := access.FilterFiles(all, tt.permission); len(allowed)+len(denied) != len(all) {
+				t.Errorf("FilterFiles allowed %d, DeniedFiles denied %d of %d", len(allowed), len(denied), len(all))
+			}
+
+			// 文件列表的查询条件与逐个判断的查看权限一致
+			if tt.permission == model.ACLView {
+				var visible int64
+				if err := access.Scope(config.DB.Model(&model.File{}).Where("id IN ?", ids)).Count(&visible).Error; err != nil {
+					t.Fatal(err)
+				}
+				if int(visible) != len(all)-len(denied) {
+					t.Errorf("Scope returned %d files, want %d", visible, len(all)-len(denied))
+				}
+			}
+		})
+	}
+}
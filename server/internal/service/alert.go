@@ -0,0 +1,413 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AlertService 告警规则与告警记录服务
+type AlertService struct{}
+
+// NewAlertService 创建告警服务
+func NewAlertService() *AlertService {
+	return &AlertService{}
+}
+
+// GetRules 获取全部告警规则
+func (s *AlertService) GetRules() ([]model.AlertRule, error) {
+	var rules []model.AlertRule
+	if err := config.DB.Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetRuleByID 根据ID获取告警规则
+func (s *AlertService) GetRuleByID(id uint) (*model.AlertRule, error) {
+	var rule model.AlertRule
+	if err := config.DB.First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("告警规则不存在")
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// CreateRule 创建告警规则
+func (s *AlertService) CreateRule(req *model.AlertRuleRequest) (*model.AlertRule, error) {
+	rule := &model.AlertRule{}
+	applyAlertRuleRequest(rule, req)
+	if err := validateAlertRule(rule); err != nil {
+		return nil, err
+	}
+	if err := config.DB.Create(rule).Error; err != nil {
+		return nil, err
+	}
+	alertEngine.reload()
+	return rule, nil
+}
+
+// UpdateRule 更新告警规则
+func (s *AlertService) UpdateRule(id uint, req *model.AlertRuleRequest) (*model.AlertRule, error) {
+	rule, err := s.GetRuleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	applyAlertRuleRequest(rule, req)
+	if err := validateAlertRule(rule); err != nil {
+		return nil, err
+	}
+	if err := config.DB.Save(rule).Error; err != nil {
+		return nil, err
+	}
+	alertEngine.reload()
+	return rule, nil
+}
+
+// DeleteRule 删除告警规则
+func (s *AlertService) DeleteRule(id uint) error {
+	if err := config.DB.Delete(&model.AlertRule{}, id).Error; err != nil {
+		return err
+	}
+	alertEngine.reload()
+	return nil
+}
+
+// GetHistory 分页获取告警记录
+func (s *AlertService) GetHistory(req *model.AlertHistoryRequest) (map[string]interface{}, error) {
+	var total int64
+	var list []model.AlertHistory
+
+	db := config.DB.Model(&model.AlertHistory{})
+	if req.RuleID != 0 {
+		db = db.Where("rule_id = ?", req.RuleID)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"total": total,
+		"list":  list,
+	}, nil
+}
+
+func applyAlertRuleRequest(rule *model.AlertRule, req *model.AlertRuleRequest) {
+	rule.Name = req.Name
+	rule.Description = req.Description
+	rule.Enabled = req.Enabled
+	rule.Module = req.Module
+	rule.Action = req.Action
+	rule.Status = req.Status
+	rule.Username = req.Username
+	rule.IP = req.IP
+	rule.Threshold = req.Threshold
+	rule.Window = req.Window
+	rule.GroupBy = req.GroupBy
+	rule.Cooldown = req.Cooldown
+	rule.Channels = req.Channels
+	rule.NotifyUsers = req.NotifyUsers
+	rule.Emails = req.Emails
+	rule.WebhookURL = req.WebhookURL
+}
+
+func validateAlertRule(rule *model.AlertRule) error {
+	if rule.Threshold <= 0 {
+		rule.Threshold = 1
+	}
+	if rule.Threshold > 1 && rule.Window == 0 {
+		return errors.New("阈值大于1时必须设置时间窗口")
+	}
+	for _, channel := range rule.Channels {
+		if channel == model.AlertChannelEmail && len(rule.Emails) == 0 {
+			return errors.New("邮件通知需要填写接收人")
+		}
+		if channel == model.AlertChannelWebhook && rule.WebhookURL == "" {
+			return errors.New("Webhook通知需要填写地址")
+		}
+	}
+	return nil
+}
+
+// 待评估日志队列和待发送告警队列的长度
+const (
+	alertQueueSize    = 1024
+	alertDeliverySize = 256
+)
+
+// engine 告警引擎，在独立协程中按顺序评估日志，保证同一规则的冷却判断不会并发；
+// 告警通知由另一个协程发送，邮件或Webhook无响应时不影响规则评估
+type engine struct {
+	mu         sync.RWMutex
+	rules      []model.AlertRule
+	queue      chan model.SystemLog
+	deliveries chan alertDelivery
+	once       sync.Once
+}
+
+// alertDelivery 待发送的告警
+type alertDelivery struct {
+	rule    model.AlertRule
+	entry   model.SystemLog
+	history *model.AlertHistory
+}
+
+var alertEngine = &engine{
+	queue:      make(chan model.SystemLog, alertQueueSize),
+	deliveries: make(chan alertDelivery, alertDeliverySize),
+}
+
+// StartAlertEngine 加载告警规则并开始评估新写入的日志
+func StartAlertEngine() {
+	alertEngine.once.Do(func() {
+		alertEngine.reload()
+		AddLogHook(alertEngine.enqueue)
+		go alertEngine.run()
+		go alertEngine.deliver()
+	})
+}
+
+func (e *engine) reload() {
+	var rules []model.AlertRule
+	if err := config.DB.Where("enabled = ?", true).Find(&rules).Error; err != nil {
+		log.Printf("加载告警规则失败: %v", err)
+		return
+	}
+	e.mu.Lock()
+	e.rules = rules
+	e.mu.Unlock()
+}
+
+func (e *engine) enqueue(entry *model.SystemLog) {
+	select {
+	case e.queue <- *entry:
+	default:
+		log.Printf("告警队列已满，跳过日志%d", entry.ID)
+	}
+}
+
+func (e *engine) run() {
+	for entry := range e.queue {
+		e.mu.RLock()
+		rules := e.rules
+		e.mu.RUnlock()
+
+		for i := range rules {
+			if alertRuleMatches(&rules[i], &entry) {
+				e.evaluate(&rules[i], &entry)
+			}
+		}
+	}
+}
+
+// evaluate 统计时间窗口内的匹配次数，达到阈值且不在冷却期内时触发告警
+func (e *engine) evaluate(rule *model.AlertRule, entry *model.SystemLog) {
+	groupKey := alertGroupKey(rule, entry)
+
+	count := int64(1)
+	if rule.Window > 0 {
+		db := alertRuleScope(config.DB.Model(&model.SystemLog{}), rule).
+			Where("created_at >= ?", time.Now().Add(-time.Duration(rule.Window)*time.Second))
+		switch rule.GroupBy {
+		case "ip":
+			db = db.Where("ip = ?", entry.IP)
+		case "username":
+			db = db.Where("username = ?", entry.Username)
+		}
+		if err := db.Count(&count).Error; err != nil {
+			log.Printf("统计告警规则%d匹配次数失败: %v", rule.ID, err)
+			return
+		}
+	}
+	if count < int64(rule.Threshold) {
+		return
+	}
+
+	// 冷却期内同一规则同一分组不重复告警；未设置冷却时间时以时间窗口去重
+	cooldown := rule.Cooldown
+	if cooldown == 0 {
+		cooldown = rule.Window
+	}
+	if cooldown > 0 {
+		var recent int64
+		config.DB.Model(&model.AlertHistory{}).
+			Where("rule_id = ? AND group_key = ? AND created_at >= ?", rule.ID, groupKey,
+				time.Now().Add(-time.Duration(cooldown)*time.Second)).
+			Count(&recent)
+		if recent > 0 {
+			return
+		}
+	}
+
+	history := &model.AlertHistory{
+		RuleID:   rule.ID,
+		RuleName: rule.Name,
+		GroupKey: groupKey,
+		Count:    count,
+		LogID:    entry.ID,
+		Message:  alertMessage(rule, entry, groupKey, count),
+	}
+	if err := config.DB.Create(history).Error; err != nil {
+		log.Printf("保存告警记录失败: %v", err)
+		return
+	}
+
+	if len(rule.Channels) == 0 {
+		return
+	}
+	select {
+	case e.deliveries <- alertDelivery{rule: *rule, entry: *entry, history: history}:
+	default:
+		log.Printf("告警发送队列已满，跳过告警%d的通知", history.ID)
+		config.DB.Model(history).Update("delivery_error", "发送队列已满，未发送通知")
+	}
+}
+
+// deliver 依次发送告警通知，并记录各渠道的发送失败原因
+func (e *engine) deliver() {
+	for d := range e.deliveries {
+		if errs := deliverAlert(&d.rule, &d.entry, d.history); len(errs) > 0 {
+			config.DB.Model(d.history).Update("delivery_error", truncate(strings.Join(errs, "; "), 500))
+		}
+	}
+}
+
+// deliverAlert 按规则配置的渠道发送告警，返回各渠道的错误信息
+func deliverAlert(rule *model.AlertRule, entry *model.SystemLog, history *model.AlertHistory) []string {
+	var errs []string
+	title := "告警：" + rule.Name
+	for _, channel := range rule.Channels {
+		var err error
+		switch channel {
+		case model.AlertChannelNotify:
+			userIDs := rule.NotifyUsers
+			if len(userIDs) == 0 {
+				userIDs, err = usersWithPermission(model.PermissionSystemLog)
+				if err != nil {
+					break
+				}
+			}
+			err = NewNotificationService().Notify(userIDs, model.NotificationSourceAlert, "warning", title, history.Message)
+		case model.AlertChannelEmail:
+			err = sendMail(rule.Emails, title, history.Message)
+		case model.AlertChannelWebhook:
+			err = postAlertWebhook(rule.WebhookURL, rule, entry, history)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", channel, err))
+		}
+	}
+	return errs
+}
+
+// alertWebhookClient Webhook请求客户端
+var alertWebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+func postAlertWebhook(url string, rule *model.AlertRule, entry *model.SystemLog, history *model.AlertHistory) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"rule_id":   rule.ID,
+		"rule_name": rule.Name,
+		"group_key": history.GroupKey,
+		"count":     history.Count,
+		"message":   history.Message,
+		"fired_at":  history.CreatedAt,
+		"log":       entry,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := alertWebhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook返回状态码%d", resp.StatusCode)
+	}
+	return nil
+}
+
+// alertRuleMatches 判断日志是否满足规则的匹配条件
+func alertRuleMatches(rule *model.AlertRule, entry *model.SystemLog) bool {
+	if rule.Module != "" && entry.Module != rule.Module {
+		return false
+	}
+	if rule.Action != "" && entry.Action != rule.Action {
+		return false
+	}
+	if rule.Status != nil && entry.Status != *rule.Status {
+		return false
+	}
+	if rule.Username != "" && entry.Username != rule.Username {
+		return false
+	}
+	if rule.IP != "" && entry.IP != rule.IP {
+		return false
+	}
+	return true
+}
+
+// alertRuleScope 将规则的匹配条件转换为查询条件，与alertRuleMatches保持一致
+func alertRuleScope(db *gorm.DB, rule *model.AlertRule) *gorm.DB {
+	if rule.Module != "" {
+		db = db.Where("module = ?", rule.Module)
+	}
+	if rule.Action != "" {
+		db = db.Where("action = ?", rule.Action)
+	}
+	if rule.Status != nil {
+		db = db.Where("status = ?", *rule.Status)
+	}
+	if rule.Username != "" {
+		db = db.Where("username = ?", rule.Username)
+	}
+	if rule.IP != "" {
+		db = db.Where("ip = ?", rule.IP)
+	}
+	return db
+}
+
+func alertGroupKey(rule *model.AlertRule, entry *model.SystemLog) string {
+	switch rule.GroupBy {
+	case "ip":
+		return entry.IP
+	case "username":
+		return entry.Username
+	default:
+		return "*"
+	}
+}
+
+func alertMessage(rule *model.AlertRule, entry *model.SystemLog, groupKey string, count int64) string {
+	subject := fmt.Sprintf("%s/%s", entry.Module, entry.Action)
+	var b strings.Builder
+	if rule.Window > 0 {
+		fmt.Fprintf(&b, "%d秒内发生%d次%s", rule.Window, count, subject)
+	} else {
+		fmt.Fprintf(&b, "发生%s", subject)
+	}
+	if rule.GroupBy != "" {
+		fmt.Fprintf(&b, "（%s=%s）", rule.GroupBy, groupKey)
+	}
+	fmt.Fprintf(&b, "，最近一次操作用户：%s，IP：%s，时间：%s",
+		entry.Username, entry.IP, entry.CreatedAt.Format("2006-01-02 15:04:05"))
+	return b.String()
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"testing"
+	"time"
+)
+
+func TestAlertEvaluate(t *testing.T) {
+	type event struct {
+		ip  string
+		ago time.Duration // 日志写入时间距现在多久
+	}
+	tests := []struct {
+		name     string
+		rule     model.AlertRule
+		logs     []event
+		evaluate []string // 依次评估的日志IP，对应logs中最后一条同IP的日志
+		want     []string // 产生的告警分组
+	}{
+		{
+			name:     "below threshold",
+			rule:     model.AlertRule{Threshold: 3, Window: 60},
+			logs:     []event{{"1.1.1.1", 0}, {"1.1.1.1", 0}},
+			evaluate: []string{"1.1.1.1"},
+		},
+		{
+			name:     "threshold reached",
+			rule:     model.AlertRule{Threshold: 3, Window: 60},
+			logs:     []event{{"1.1.1.1", 0}, {"1.1.1.2", 0}, {"1.1.1.3", 0}},
+			evaluate: []string{"1.1.1.3"},
+			want:     []string{"*"},
+		},
+		{
+			name:     "logs outside the window are not counted",
+			rule:     model.AlertRule{Threshold: 3, Window: 60},
+			logs:     []event{{"1.1.1.1", 2 * time.Minute}, {"1.1.1.1", 2 * time.Minute}, {"1.1.1.1", 0}},
+			evaluate: []string{"1.1.1.1"},
+		},
+		{
+			name:     "group by ip counts each ip separately",
+			rule:     model.AlertRule{Threshold: 2, Window: 60, GroupBy: "ip"},
+			logs:     []event{{"1.1.1.1", 0}, {"1.1.1.1", 0}, {"1.1.1.2", 0}},
+			evaluate: []string{"1.1.1.2", "1.1.1.1"},
+			want:     []string{"1.1.1.1"},
+		},
+		{
+			name:     "window deduplicates without cooldown",
+			rule:     model.AlertRule{Threshold: 1, Window: 60},
+			logs:     []event{{"1.1.1.1", 0}},
+			evaluate: []string{"1.1.1.1", "1.1.1.1"},
+			want:     []string{"*"},
+		},
+		{
+			name:     "cooldown is per group",
+			rule:     model.AlertRule{Threshold: 1, Window: 60, GroupBy: "ip", Cooldown: 600},
+			logs:     []event{{"1.1.1.1", 0}, {"1.1.1.2", 0}},
+			evaluate: []string{"1.1.1.1", "1.1.1.1", "1.1.1.2"},
+			want:     []string{"1.1.1.1", "1.1.1.2"},
+		},
+		{
+			name:     "no window fires on every match",
+			rule:     model.AlertRule{Threshold: 1},
+			logs:     []event{{"1.1.1.1", 0}},
+			evaluate: []string{"1.1.1.1", "1.1.1.1"},
+			want:     []string{"*", "*"},
+		},
+	}
+
+	e := &engine{deliveries: make(chan alertDelivery, 1)}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 每个用例使用不同的规则ID和操作类型，互不影响
+			rule := tt.rule
+			rule.ID = uint(1000 + i)
+			rule.Name = tt.name
+			rule.Action = tt.name
+
+			entries := map[string]model.SystemLog{}
+			for _, ev := range tt.logs {
+				entry := model.SystemLog{Module: model.LogModuleAuth, Action: rule.Action, IP: ev.ip}
+				entry.CreatedAt = time.Now().Add(-ev.ago)
+				if err := config.DB.Create(&entry).Error; err != nil {
+					t.Fatal(err)
+				}
+				entries[ev.ip] = entry
+			}
+			for _, ip := range tt.evaluate {
+				entry := entries[ip]
+				e.evaluate(&rule, &entry)
+			}
+
+			var history []model.AlertHistory
+			if err := config.DB.Where("rule_id = ?", rule.ID).Order("id ASC").Find(&history).Error; err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, h := range history {
+				got = append(got, h.GroupKey)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("alerts = %v, want %v", got, tt.want)
+			}
+			for j := range got {
+				if got[j] != tt.want[j] {
+					t.Errorf("alerts = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAlertEvaluateQueuesDelivery(t *testing.T) {
+	e := &engine{deliveries: make(chan alertDelivery, 1)}
+	rule := model.AlertRule{Name: "queued", Action: "queued", Threshold: 1, Channels: []string{model.AlertChannelWebhook}, WebhookURL: "http://127.0.0.1:1"}
+	rule.ID = 2000
+	for i := 0; i < 2; i++ {
+		entry := model.SystemLog{Module: model.LogModuleAuth, Action: rule.Action}
+		if err := config.DB.Create(&entry).Error; err != nil {
+			t.Fatal(err)
+		}
+		// 发送协程未运行时评估也不会阻塞，队列已满的告警记录失败原因
+		e.evaluate(&rule, &entry)
+	}
+	if len(e.deliveries) != 1 {
+		t.Fatalf("%d deliveries queued, want 1", len(e.deliveries))
+	}
+	var dropped model.AlertHistory
+	if err := config.DB.Where("rule_id = ?", rule.ID).Order("id DESC").First(&dropped).Error; err != nil {
+		t.Fatal(err)
+	}
+	if dropped.DeliveryError == "" {
+		t.Errorf("dropped delivery should record an error")
+	}
+}
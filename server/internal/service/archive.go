@@ -0,0 +1,225 @@
+package service
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"log"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrArchiveEmpty 没有可下载的文件
+	ErrArchiveEmpty = errors.New("没有可下载的文件")
+	// ErrArchiveTooLarge 超出批量下载的大小或文件数限制
+	ErrArchiveTooLarge = errors.New("超出批量下载限制")
+)
+
+// ArchiveEntry 压缩包中的一个文件
+type ArchiveEntry struct {
+	Name string // 压缩包内的路径，已去重
+	File model.File
+}
+
+// ArchiveService 批量下载服务，将多个文件以ZIP格式流式输出
+type ArchiveService struct {
+	fileService   *FileService
+	folderService *FolderService
+	aclService    *ACLService
+}
+
+// NewArchiveService 创建批量下载服务实例
+func NewArchiveService() *ArchiveService {
+	return &ArchiveService{
+		fileService:   NewFileService(),
+		folderService: NewFolderService(),
+		aclService:    NewACLService(),
+	}
+}
+
+// Prepare 确定压缩包的文件名和内容，并检查大小和文件数限制
+// 按ID下载时文件放在压缩包根目录，没有下载权限的文件由调用方提前拒绝，有文件未通过病毒扫描时返回ErrFileInfected、ErrScanOversize或ErrScanPending；
+// 按文件夹下载时保留子文件夹结构，跳过没有下载权限和未通过病毒扫描的文件
+func (s *ArchiveService) Prepare(userID uint, req *model.FileArchiveRequest) (string, []ArchiveEntry, error) {
+	access, err := s.aclService.AccessFor(userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var name string
+	var entries []ArchiveEntry
+	switch {
+	case req.FolderID != 0:
+		folder, err := s.folderService.GetFolder(req.FolderID)
+		if err != nil {
+			return "", nil, err
+		}
+		if !access.Folder(folder.ID, model.ACLView) {
+			return "", nil, ErrAccessDenied
+		}
+		ids, err := s.folderService.DescendantIDs(folder)
+		if err != nil {
+			return "", nil, err
+		}
+		var files []model.File
+		if err := config.DB.Where("folder_id IN ?", ids).Find(&files).Error; err != nil {
+			return "", nil, fmt.Errorf("获取文件列表失败: %w", err)
+		}
+		var folders []model.Folder
+		if err := config.DB.Select("id", "path").Where("id IN ?", ids).Find(&folders).Error; err != nil {
+			return "", nil, fmt.Errorf("获取子文件夹失败: %w", err)
+		}
+		dirs := make(map[uint]string, len(folders))
+		for _, f := range folders {
+			dirs[f.ID] = strings.TrimPrefix(strings.TrimPrefix(f.Path, folder.Path), "/")
+		}
+		for _, file := range access.FilterFiles(files, model.ACLDownload) {
+			if CheckScan(&file) != nil {
+				continue
+			}
+			entries = append(entries, ArchiveEntry{Name: path.Join(dirs[file.FolderID], entryName(file.FileName)), File: file})
+		}
+		name = folder.Name + ".zip"
+	case len(req.IDs) > 0:
+		var files []model.File
+		if err := config.DB.Where("id IN ?", req.IDs).Find(&files).Error; err != nil {
+			return "", nil, fmt.Errorf("获取文件列表失败: %w", err)
+		}
+		for _, file := range access.FilterFiles(files, model.ACLDownload) {
+			if err := CheckScan(&file); err != nil {
+				return "", nil, fmt.Errorf("%s: %w", file.FileName, err)
+			}
+			entries = append(entries, ArchiveEntry{Name: entryName(file.FileName), File: file})
+		}
+		name = "files-" + time.Now().Format("20060102-150405") + ".zip"
+	}
+	if len(entries) == 0 {
+		return "", nil, ErrArchiveEmpty
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].File.ID < entries[j].File.ID
+	})
+	dedupeEntries(entries)
+
+	if err := checkArchiveLimits(entries); err != nil {
+		return "", nil, err
+	}
+	return name, entries, nil
+}
+
+// Write 依次读取文件写入ZIP压缩包，不在内存或临时文件中缓存，每写完一个文件增加其下载次数
+// 出错时压缩包不完整，调用方无法再修改已发送的响应状态
+func (s *ArchiveService) Write(w io.Writer, entries []ArchiveEntry) error {
+	zw := zip.NewWriter(w)
+	for i := range entries {
+		entry := &entries[i]
+		if err := s.writeEntry(zw, entry); err != nil {
+			return fmt.Errorf("写入%s失败: %w", entry.Name, err)
+		}
+		if err := s.fileService.IncrementDownloadCount(entry.File.ID); err != nil {
+			log.Printf("增加文件%d的下载次数失败: %v", entry.File.ID, err)
+		}
+	}
+	return zw.Close()
+}
+
+func (s *ArchiveService) writeEntry(zw *zip.Writer, entry *ArchiveEntry) error {
+	rc, _, err := s.fileService.OpenFile(&entry.File)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	header := &zip.FileHeader{
+		Name:     entry.Name,
+		Method:   zip.Store,
+		Modified: entry.File.UpdatedAt,
+	}
+	// 图片、音视频和压缩包等已压缩的内容直接存储，只压缩文本类文件
+	if compressible(entry.File.MimeType) {
+		header.Method = zip.Deflate
+	}
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, rc)
+	return err
+}
+
+// checkArchiveLimits 检查压缩包的文件数和总大小
+func checkArchiveLimits(entries []ArchiveEntry) error {
+	cfg := config.App.Archive
+	maxFiles := cfg.MaxFiles
+	if maxFiles == 0 {
+		maxFiles = 1000
+	}
+	maxSize := cfg.MaxSize
+	if maxSize == 0 {
+		maxSize = 2 << 30
+	}
+
+	if maxFiles > 0 && len(entries) > maxFiles {
+		return fmt.Errorf("%w: 共%d个文件，最多%d个", ErrArchiveTooLarge, len(entries), maxFiles)
+	}
+	var total int64
+	for _, entry := range entries {
+		total += entry.File.FileSize
+	}
+	if maxSize > 0 && total > maxSize {
+		return fmt.Errorf("%w: 共%d字节，最多%d字节", ErrArchiveTooLarge, total, maxSize)
+	}
+	return nil
+}
+
+// entryName 文件名中的路径分隔符替换为下划线，避免在压缩包中产生额外目录或越级路径
+func entryName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+	if name == "" || name == "." || name == ".." {
+		name = "_" + name
+	}
+	return name
+}
+
+// dedupeEntries 同一目录下重名（不区分大小写）的文件依次改为"名称 (1).扩展名"
+func dedupeEntries(entries []ArchiveEntry) {
+	used := make(map[string]bool, len(entries))
+	for i := range entries {
+		name := entries[i].Name
+		ext := path.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		for n := 1; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s (%d)%s", base, n, ext)
+		}
+		used[strings.ToLower(name)] = true
+		entries[i].Name = name
+	}
+}
+
+// compressible 内容类型是否值得压缩
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-yaml",
+		"image/svg+xml", "image/bmp", "image/x-ms-bmp", "application/x-tar":
+		return true
+	}
+	return false
+}
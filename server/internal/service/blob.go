@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// blobLocks 同一内容的写入和释放串行执行，避免释放时误删刚写入的对象
+var blobLocks keyLocks
+
+func lockBlob(hash string) func() {
+	return blobLocks.lock(hash)
+}
+
+// hashContent 计算内容的SHA-256和大小，完成后回到起始位置
+func hashContent(r io.ReadSeeker) (string, int64, error) {
+	h := sha256.New()
+	size, err := io.Copy(h, r)
+	if err != nil {
+		return "", 0, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// blobObjectKey 按内容哈希生成对象键，相同内容总是写入同一位置
+func blobObjectKey(hash string) string {
+	return "blobs/" + hash[:2] + "/" + hash[2:4] + "/" + hash
+}
+
+// storeBlob 存储内容并返回已计入本次引用的存储对象，已有相同内容时只增加引用计数
+func storeBlob(r io.Reader, hash string, size int64, contentType string) (*model.Blob, error) {
+	unlock := lockBlob(hash)
+	defer unlock()
+
+	if blob, err := acquireBlob(hash); err != nil || blob != nil {
+		return blob, err
+	}
+
+	st := DefaultStorage()
+	key := blobObjectKey(hash)
+	if err := st.Put(context.Background(), key, r, size, contentType); err != nil {
+		return nil, fmt.Errorf("保存文件失败: %w", err)
+	}
+	blob := &model.Blob{
+		Hash:      hash,
+		Size:      size,
+		Backend:   st.Name(),
+		ObjectKey: key,
+		RefCount:  1,
+	}
+	if err := config.DB.Create(blob).Error; err != nil {
+		st.Delete(context.Background(), key)
+		return nil, fmt.Errorf("保存存储对象失败: %w", err)
+	}
+	return blob, nil
+}
+
+// acquireBlob 为已有的存储对象增加一次引用，不存在时返回nil
+func acquireBlob(hash string) (*model.Blob, error) {
+	result := config.DB.Model(&model.Blob{}).Where("hash = ?", hash).
+		UpdateColumn("ref_count", gorm.Expr("ref_count + 1"))
+	if result.Error != nil {
+		return nil, fmt.Errorf("更新引用计数失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	var blob model.Blob
+	if err := config.DB.Where("hash = ?", hash).First(&blob).Error; err != nil {
+		return nil, fmt.Errorf("获取存储对象失败: %w", err)
+	}
+	return &blob, nil
+}
+
+// retainBlob 为已登记的存储对象增加一次引用
+func retainBlob(blobID uint) error {
+	var blob model.Blob
+	if err := config.DB.First(&blob, blobID).Error; err != nil {
+		return fmt.Errorf("获取存储对象失败: %w", err)
+	}
+	unlock := lockBlob(blob.Hash)
+	defer unlock()
+
+	result := config.DB.Model(&model.Blob{}).Where("id = ?", blobID).
+		UpdateColumn("ref_count", gorm.Expr("ref_count + 1"))
+	if result.Error != nil {
+		return fmt.Errorf("更新引用计数失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("存储对象不存在")
+	}
+	return nil
+}
+
+// releaseBlob 释放一次引用，最后一个引用释放后删除存储对象和物理文件
+func releaseBlob(blobID uint) error {
+	var blob model.Blob
+	if err := config.DB.First(&blob, blobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	unlock := lockBlob(blob.Hash)
+	defer unlock()
+
+	err := config.DB.Model(&model.Blob{}).Where("id = ? AND ref_count > 0", blob.ID).
+		UpdateColumn("ref_count", gorm.Expr("ref_count - 1")).Error
+	if err != nil {
+		return fmt.Errorf("更新引用计数失败: %w", err)
+	}
+	result := config.DB.Unscoped().Where("id = ? AND ref_count <= 0", blob.ID).Delete(&model.Blob{})
+	if result.Error != nil {
+		return fmt.Errorf("删除存储对象失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	st, err := GetStorage(blob.Backend)
+	if err != nil {
+		return err
+	}
+	if err := st.Delete(context.Background(), blob.ObjectKey); err != nil {
+		log.Printf("删除存储对象%s失败: %v", blob.ObjectKey, err)
+	}
+	removeVariants(blob.Hash, 0)
+	return nil
+}
+
+// BackfillResult 历史文件补算哈希的结果
+type BackfillResult struct {
+	Total   int   // 待处理的文件数
+	Hashed  int   // 成功补算的文件数
+	Deduped int   // 与已有内容重复而改为共用对象的文件数
+	Saved   int64 // 去重释放的存储空间（字节）
+	Failed  int   // 失败的文件数
+}
+
+// BackfillHashes 为未计算哈希的历史文件补算SHA-256并登记到存储对象表，内容重复的文件改为共用同一对象
+func (s *FileService) BackfillHashes(dryRun bool) (*BackfillResult, error) {
+	var files []model.File
+	if err := config.DB.Where("hash = '' OR hash IS NULL OR blob_id = 0 OR blob_id IS NULL").Order("id ASC").Find(&files).Error; err != nil {
+		return nil, err
+	}
+
+	result := &BackfillResult{Total: len(files)}
+	seen := map[string]bool{}
+	for i := range files {
+		deduped, err := s.backfillHash(&files[i], dryRun, seen)
+		if err != nil {
+			result.Failed++
+			log.Printf("文件%d（%s）补算哈希失败: %v", files[i].ID, files[i].FileName, err)
+			continue
+		}
+		result.Hashed++
+		if deduped {
+			result.Deduped++
+			result.Saved += files[i].FileSize
+		}
+	}
+	return result, nil
+}
+
+// backfillHash 补算单个文件的哈希，返回是否与已有内容重复
+// seen记录本次已处理的哈希，试运行时不写入存储对象表，据此判断重复
+func (s *FileService) backfillHash(file *model.File, dryRun bool, seen map[string]bool) (bool, error) {
+	st, err := GetStorage(file.Backend)
+	if err != nil {
+		return false, err
+	}
+	ctx := context.Background()
+	rc, _, err := st.Get(ctx, file.ObjectKey)
+	if err != nil {
+		return false, fmt.Errorf("读取文件失败: %w", err)
+	}
+	h := sha256.New()
+	size, err := io.Copy(h, rc)
+	rc.Close()
+	if err != nil {
+		return false, fmt.Errorf("读取文件失败: %w", err)
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	unlock := lockBlob(hash)
+	defer unlock()
+
+	var existing model.Blob
+	found := config.DB.Where("hash = ?", hash).Limit(1).Find(&existing)
+	if found.Error != nil {
+		return false, found.Error
+	}
+	duplicate := found.RowsAffected > 0
+	if dryRun {
+		duplicate = duplicate || seen[hash]
+		seen[hash] = true
+		file.Hash = hash
+		return duplicate, nil
+	}
+
+	if !duplicate {
+		// 首次出现的内容沿用文件原有的对象
+		blob := &model.Blob{Hash: hash, Size: size, Backend: file.Backend, ObjectKey: file.ObjectKey, RefCount: 1}
+		return false, config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(blob).Error; err != nil {
+				return err
+			}
+			return tx.Model(file).Updates(map[string]interface{}{"hash": hash, "blob_id": blob.ID, "file_size": size}).Error
+		})
+	}
+
+	// 重复内容改为引用已有对象，再删除自己的副本
+	ownKey := file.ObjectKey
+	target, err := GetStorage(existing.Backend)
+	if err != nil {
+		return false, err
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Blob{}).Where("id = ?", existing.ID).
+			UpdateColumn("ref_count", gorm.Expr("ref_count + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(file).Updates(map[string]interface{}{
+			"hash":       hash,
+			"blob_id":    existing.ID,
+			"backend":    existing.Backend,
+			"object_key": existing.ObjectKey,
+			"file_path":  storagePath(target, existing.ObjectKey),
+		}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	if existing.Backend != st.Name() || existing.ObjectKey != ownKey {
+		if err := st.Delete(ctx, ownKey); err != nil {
+			log.Printf("删除重复文件%s失败: %v", ownKey, err)
+		}
+	}
+	return true, nil
+}
@@ -1,12 +1,16 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"jing_vue_gin_admin/server/config"
 	"jing_vue_gin_admin/server/internal/model"
-	"os"
+	"jing_vue_gin_admin/server/internal/storage"
+	"log"
+	"mime"
+	"net/http"
 	"path/filepath"
 	"strings"
 	"time"
@@ -24,64 +28,218 @@ func NewFileService() *FileService {
 
 // UploadFile 上传文件
 func (s *FileService) UploadFile(req model.FileUploadRequest, userID uint) (*model.File, error) {
-	// 创建上传目录
-	uploadDir := "uploads"
-	if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(uploadDir, 0755); err != nil {
-			return nil, fmt.Errorf("创建上传目录失败: %w", err)
-		}
-	}
-
 	// 获取文件信息
 	fileHeader := req.File
-	filename := fileHeader.Filename
-	fileSize := fileHeader.Size
-	fileType := getFileType(filename)
-
-	// 创建唯一文件名
-	uniqueFilename := generateUniqueFilename(filename)
-	filePath := filepath.Join(uploadDir, uniqueFilename)
 
-	// 保存文件
 	srcFile, err := fileHeader.Open()
 	if err != nil {
 		return nil, fmt.Errorf("打开文件失败: %w", err)
 	}
 	defer srcFile.Close()
 
-	destFile, err := os.Create(filePath)
+	return s.saveFile(srcFile, &model.File{
+		FileName:    fileHeader.Filename,
+		Hash:        strings.ToLower(req.SHA256),
+		Category:    req.Category,
+		Description: req.Description,
+		FolderID:    req.FolderID,
+		UploadedBy:  userID,
+	})
+}
+
+// saveFile 校验内容后计算哈希写入存储并创建文件记录，相同内容只存储一份，普通上传和断点续传共用
+// file中需填写文件名、分类、描述和上传者，Hash不为空时校验内容是否一致
+// 校验不通过时返回*UploadError
+func (s *FileService) saveFile(r io.ReadSeeker, file *model.File) (*model.File, error) {
+	if file.Category == "" {
+		file.Category = "other"
+	}
+	if file.FolderID != 0 && config.DB.Select("id").Limit(1).Find(&model.Folder{}, file.FolderID).RowsAffected == 0 {
+		return nil, uploadError(UploadErrFolderNotFound, http.StatusBadRequest, "文件夹%d不存在", file.FolderID)
+	}
+	if err := canCreateIn(file.UploadedBy, file.FolderID); err != nil {
+		return nil, err
+	}
+
+	err := storeContent(r, file, func(size int64) error {
+		return checkQuota(file.UploadedBy, size, 1, false)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("创建文件失败: %w", err)
+		return nil, err
 	}
-	defer destFile.Close()
 
-	if _, err := io.Copy(destFile, srcFile); err != nil {
-		return nil, fmt.Errorf("保存文件失败: %w", err)
+	file.ScanStatus = initialScanStatus()
+	// 接收内容期间其他上传可能已占用配额，创建记录时再次校验
+	err = withQuota(file.UploadedBy, file.FileSize, 1, false, func(tx *gorm.DB) error {
+		return tx.Create(file).Error
+	})
+	if err != nil {
+		// 释放本次引用，没有其他文件引用时删除已保存的内容
+		releaseBlob(file.BlobID)
+		var uploadErr *UploadError
+		if errors.As(err, &uploadErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("保存文件记录失败: %w", err)
 	}
+	generateThumbnail(*file)
+	enqueueScan(file.ID)
 
-	// 创建文件记录
-	category := req.Category
-	if category == "" {
-		category = "other"
+	return file, nil
+}
+
+// storeContent 按上传者角色和文件分类校验内容，通过quota校验配额后写入存储，
+// 并填充file中的大小、类型、哈希和存储位置，成功时已为file计入一次存储对象引用
+func storeContent(r io.ReadSeeker, file *model.File, quota func(size int64) error) error {
+	policy := policyFor(file.UploadedBy, file.Category)
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	if err := policy.checkSize(size); err != nil {
+		return err
+	}
+	detected, err := policy.checkContent(r, file.FileName)
+	if err != nil {
+		return err
+	}
+	if err := quota(size); err != nil {
+		return err
 	}
 
-	file := &model.File{
-		FileName:    filename,
-		FileSize:    fileSize,
-		FileType:    fileType,
-		FilePath:    filePath,
-		Category:    category,
-		Description: req.Description,
-		UploadedBy:  userID,
+	hash, size, err := hashContent(r)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	if file.Hash != "" && file.Hash != hash {
+		return uploadError(UploadErrChecksumMismatch, http.StatusBadRequest, "文件校验失败: SHA-256为%s，与提供的%s不一致", hash, file.Hash)
 	}
 
-	if err := config.DB.Create(file).Error; err != nil {
-		// 删除物理文件
-		os.Remove(filePath)
-		return nil, fmt.Errorf("保存文件记录失败: %w", err)
+	blob, err := storeBlob(r, hash, size, detected.String())
+	if err != nil {
+		return err
+	}
+	st, err := GetStorage(blob.Backend)
+	if err != nil {
+		releaseBlob(blob.ID)
+		return err
 	}
 
-	return file, nil
+	// 没有扩展名时按识别出的类型补全文件类型
+	file.FileSize = size
+	file.FileType = getFileType(file.FileName)
+	if file.FileType == "unknown" && detected.Extension() != "" {
+		file.FileType = strings.TrimPrefix(detected.Extension(), ".")
+	}
+	file.MimeType = detected.String()
+	file.FilePath = storagePath(st, blob.ObjectKey)
+	file.Backend = blob.Backend
+	file.ObjectKey = blob.ObjectKey
+	file.Hash = hash
+	file.BlobID = blob.ID
+	return nil
+}
+
+// OpenFile 从文件所在的存储后端读取内容，调用方负责关闭
+func (s *FileService) OpenFile(file *model.File) (io.ReadCloser, *storage.ObjectInfo, error) {
+	st, err := GetStorage(file.Backend)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, info, err := st.Get(context.Background(), file.ObjectKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, fmt.Errorf("文件不存在")
+	}
+	return rc, info, err
+}
+
+// OpenContent 以可定位的方式打开文件内容，供范围请求和条件请求使用，调用方负责关闭
+func (s *FileService) OpenContent(file *model.File) (io.ReadSeekCloser, *storage.ObjectInfo, error) {
+	st, err := GetStorage(file.Backend)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, info, err := storage.OpenSeeker(context.Background(), st, file.ObjectKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, fmt.Errorf("文件不存在")
+	}
+	return content, info, err
+}
+
+// PresignFile 生成文件的临时下载地址，opts指定下载时的内容类型和文件名，后端不支持时返回storage.ErrNotSupported
+func (s *FileService) PresignFile(file *model.File, expires time.Duration, opts *storage.PresignOptions) (string, error) {
+	st, err := GetStorage(file.Backend)
+	if err != nil {
+		return "", err
+	}
+	return st.Presign(context.Background(), file.ObjectKey, expires, opts)
+}
+
+// MigrateFile 将文件复制到目标存储后端并更新记录，校验大小一致后按需删除源文件
+// 内容相同的文件共用同一对象，迁移时一并更新
+func (s *FileService) MigrateFile(file *model.File, target storage.Storage, deleteSource bool) error {
+	// 共用对象的文件可能已随其他文件迁移
+	if err := config.DB.Unscoped().First(file, file.ID).Error; err != nil {
+		return err
+	}
+	source, err := GetStorage(file.Backend)
+	if err != nil {
+		return err
+	}
+	if source.Name() == target.Name() {
+		return nil
+	}
+
+	ctx := context.Background()
+	rc, info, err := source.Get(ctx, file.ObjectKey)
+	if err != nil {
+		return fmt.Errorf("读取源文件失败: %w", err)
+	}
+	err = target.Put(ctx, file.ObjectKey, rc, info.Size, mime.TypeByExtension(filepath.Ext(file.FileName)))
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("写入目标存储失败: %w", err)
+	}
+
+	copied, err := target.Stat(ctx, file.ObjectKey)
+	if err != nil {
+		return fmt.Errorf("校验目标文件失败: %w", err)
+	}
+	if copied.Size != info.Size {
+		target.Delete(ctx, file.ObjectKey)
+		return fmt.Errorf("目标文件大小%d与源文件%d不一致", copied.Size, info.Size)
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&model.File{}).Where("backend = ? AND object_key = ?", source.Name(), file.ObjectKey).Updates(map[string]interface{}{
+			"backend":   target.Name(),
+			"file_path": storagePath(target, file.ObjectKey),
+		}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&model.FileVersion{}).Where("backend = ? AND object_key = ?", source.Name(), file.ObjectKey).
+			Update("backend", target.Name()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.Blob{}).Where("backend = ? AND object_key = ?", source.Name(), file.ObjectKey).
+			Update("backend", target.Name()).Error
+	})
+	if err != nil {
+		target.Delete(ctx, file.ObjectKey)
+		return fmt.Errorf("更新文件记录失败: %w", err)
+	}
+
+	if deleteSource {
+		if err := source.Delete(ctx, file.ObjectKey); err != nil {
+			log.Printf("删除源文件%s失败: %v", file.ObjectKey, err)
+		}
+	}
+	return nil
 }
 
 // GetFiles 获取文件列表
@@ -104,6 +262,18 @@ func (s *FileService) GetFiles(req model.FileListRequest) (*model.FileListRespon
 	if req.UploadedBy != 0 {
 		query = query.Where("uploaded_by = ?", req.UploadedBy)
 	}
+	if req.FolderID != nil {
+		if ids := folderIDsForFilter(*req.FolderID, req.Recursive); ids != nil {
+			query = query.Where("folder_id IN ?", ids)
+		}
+	}
+	if req.ViewerID != 0 {
+		access, err := NewACLService().AccessFor(req.ViewerID)
+		if err != nil {
+			return nil, err
+		}
+		query = access.Scope(query)
+	}
 	if req.StartDate != "" && req.EndDate != "" {
 		query = query.Where("created_at BETWEEN ? AND ?", req.StartDate, req.EndDate+" 23:59:59")
 	} else if req.StartDate != "" {
@@ -122,6 +292,9 @@ func (s *FileService) GetFiles(req model.FileListRequest) (*model.FileListRespon
 	if err := query.Offset(offset).Limit(req.PageSize).Order("created_at DESC").Find(&files).Error; err != nil {
 		return nil, fmt.Errorf("获取文件列表失败: %w", err)
 	}
+	for i := range files {
+		files[i].ThumbnailURL = ThumbnailURL(&files[i])
+	}
 
 	return &model.FileListResponse{
 		Total: int(total),
@@ -169,28 +342,56 @@ func (s *FileService) UpdateFile(req model.FileUpdateRequest) (*model.File, erro
 	return file, nil
 }
 
-// DeleteFile 删除文件
-func (s *FileService) DeleteFile(id uint) error {
+// DeleteFile 删除文件，移入回收站
+func (s *FileService) DeleteFile(id, userID uint) error {
 	file, err := s.GetFileByID(id)
 	if err != nil {
 		return err
 	}
 
-	// 开启事务
-	return config.DB.Transaction(func(tx *gorm.DB) error {
-		// 删除数据库记录
-		if err := tx.Delete(file).Error; err != nil {
-			return fmt.Errorf("删除文件记录失败: %w", err)
-		}
+	// 移入回收站，存储对象、历史版本和访问控制在彻底删除时才释放
+	if err := config.DB.Model(file).UpdateColumns(trashColumns(userID)).Error; err != nil {
+		return fmt.Errorf("删除文件记录失败: %w", err)
+	}
+	return nil
+}
 
-		// 删除物理文件
-		if err := os.Remove(file.FilePath); err != nil {
-			// 记录错误但不中断事务
-			fmt.Printf("删除物理文件失败: %v\n", err)
-		}
+// trashColumns 文件移入回收站时更新的字段
+func trashColumns(userID uint) map[string]interface{} {
+	return map[string]interface{}{
+		"trashed":    true,
+		"deleted_by": userID,
+		"deleted_at": time.Now(),
+	}
+}
 
+// purgeFile 彻底删除文件记录，释放存储对象、历史版本和访问控制条目
+func purgeFile(file *model.File) error {
+	if err := config.DB.Unscoped().Delete(file).Error; err != nil {
+		return fmt.Errorf("删除文件记录失败: %w", err)
+	}
+
+	// 释放共用的存储对象，最后一个引用释放时才删除物理文件
+	deleteVersions(file.ID)
+	deleteACL(model.ACLResourceFile, file.ID)
+	if file.BlobID != 0 {
+		if err := releaseBlob(file.BlobID); err != nil {
+			log.Printf("释放文件%d的存储对象失败: %v", file.ID, err)
+		}
 		return nil
-	})
+	}
+
+	// 未去重的历史文件直接删除
+	st, err := GetStorage(file.Backend)
+	if err != nil {
+		return err
+	}
+	if err := st.Delete(context.Background(), file.ObjectKey); err != nil {
+		// 记录错误但不影响删除结果
+		fmt.Printf("删除物理文件失败: %v\n", err)
+	}
+	removeVariants("", file.ID)
+	return nil
 }
 
 // IncrementDownloadCount 增加下载次数
@@ -202,18 +403,18 @@ func (s *FileService) IncrementDownloadCount(id uint) error {
 	return nil
 }
 
-// GetFileStats 获取文件统计信息
-func (s *FileService) GetFileStats() (*model.FileStats, error) {
+// GetFileStats 获取文件统计信息，最近上传和热门下载只包含viewerID有查看权限的文件
+func (s *FileService) GetFileStats(viewerID uint) (*model.FileStats, error) {
 	var stats model.FileStats
-	var totalCount int64
-	var totalSize int64
+	access, err := NewACLService().AccessFor(viewerID)
+	if err != nil {
+		return nil, err
+	}
 
 	// 获取文件总数和总大小
-	if err := config.DB.Model(&model.File{}).Count(&totalCount).Error; err != nil {
-		return nil, fmt.Errorf("获取文件总数失败: %w", err)
-	}
-	if err := config.DB.Model(&model.File{}).Select("COALESCE(SUM(file_size), 0)").Scan(&totalSize).Error; err != nil {
-		return nil, fmt.Errorf("获取文件总大小失败: %w", err)
+	totalCount, totalSize, err := fileTotals(config.DB.Model(&model.File{}))
+	if err != nil {
+		return nil, err
 	}
 
 	stats.TotalFiles = int(totalCount)
@@ -239,9 +440,9 @@ func (s *FileService) GetFileStats() (*model.FileStats, error) {
 	}
 	stats.Types = types
 
-	// 获取最近上传
+	// 获取最近上传，文件列表只包含当前用户有查看权限的文件
 	var recentUploads []model.File
-	if err := config.DB.Model(&model.File{}).
+	if err := access.Scope(config.DB.Model(&model.File{})).
 		Order("created_at DESC").
 		Limit(5).
 		Find(&recentUploads).Error; err != nil {
@@ -251,7 +452,7 @@ func (s *FileService) GetFileStats() (*model.FileStats, error) {
 
 	// 获取热门下载
 	var popularDownloads []model.File
-	if err := config.DB.Model(&model.File{}).
+	if err := access.Scope(config.DB.Model(&model.File{})).
 		Order("downloads DESC").
 		Limit(5).
 		Find(&popularDownloads).Error; err != nil {
@@ -294,14 +495,3 @@ func getFileType(filename string) string {
 	}
 	return strings.ToLower(ext[1:]) // 去掉开头的点
 }
-
-// generateUniqueFilename 生成唯一的文件名
-func generateUniqueFilename(originalFilename string) string {
-	ext := filepath.Ext(originalFilename)
-	baseName := filepath.Base(originalFilename)
-	baseName = strings.TrimSuffix(baseName, ext)
-	
-	// 使用时间戳和随机数生成唯一名称
-	timestamp := time.Now().UnixNano()
-	return fmt.Sprintf("%s_%d%s", baseName, timestamp, ext)
-} 
\ No newline at end of file
@@ -0,0 +1,485 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// UploadErrFolderNotFound 上传到的文件夹不存在
+const UploadErrFolderNotFound = "FOLDER_NOT_FOUND"
+
+var (
+	// ErrFolderNotFound 文件夹不存在
+	ErrFolderNotFound = errors.New("文件夹不存在")
+	// ErrFolderExists 同一文件夹下已有同名文件夹
+	ErrFolderExists = errors.New("同一位置已存在同名文件夹")
+	// ErrFolderName 文件夹名称无效
+	ErrFolderName = errors.New("文件夹名称不能为空，且不能包含 / 或 \\，也不能为 . 或 ..")
+	// ErrFolderCycle 不能把文件夹移动到自身或其子文件夹中
+	ErrFolderCycle = errors.New("不能移动到自身或其子文件夹中")
+	// ErrFolderNotEmpty 文件夹不为空，需要确认后递归删除
+	ErrFolderNotEmpty = errors.New("文件夹不为空")
+)
+
+// folderMu 文件夹的创建、重命名和移动串行执行，避免路径和同名校验出现竞争
+var folderMu sync.Mutex
+
+// FolderService 文件夹服务
+type FolderService struct {
+	fileService *FileService
+}
+
+// NewFolderService 创建文件夹服务实例
+func NewFolderService() *FolderService {
+	return &FolderService{fileService: NewFileService()}
+}
+
+// GetFolder 通过ID获取文件夹
+func (s *FolderService) GetFolder(id uint) (*model.Folder, error) {
+	var folder model.Folder
+	if config.DB.Limit(1).Find(&folder, id).RowsAffected == 0 {
+		return nil, ErrFolderNotFound
+	}
+	return &folder, nil
+}
+
+// GetFolderByPath 按完整路径查找文件夹，路径为空或 / 时返回nil表示根目录
+func (s *FolderService) GetFolderByPath(path string) (*model.Folder, error) {
+	path = normalizeFolderPath(path)
+	if path == "" {
+		return nil, nil
+	}
+	var folder model.Folder
+	if config.DB.Where("path = ?", path).Limit(1).Find(&folder).RowsAffected == 0 {
+		return nil, ErrFolderNotFound
+	}
+	return &folder, nil
+}
+
+// GetContents 获取文件夹的面包屑导航、子文件夹和直接包含的文件数，folder为nil表示根目录
+// viewerID不为0时只列出该用户有查看权限的子文件夹和文件
+func (s *FolderService) GetContents(folder *model.Folder, viewerID uint) (*model.FolderContents, error) {
+	contents := &model.FolderContents{Folder: folder, Breadcrumbs: []model.Folder{}, Folders: []model.Folder{}}
+	var parentID uint
+	if folder != nil {
+		parentID = folder.ID
+		breadcrumbs, err := s.Breadcrumbs(folder)
+		if err != nil {
+			return nil, err
+		}
+		contents.Breadcrumbs = breadcrumbs
+	}
+
+	if err := config.DB.Where("parent_id = ?", parentID).Order("name ASC").Find(&contents.Folders).Error; err != nil {
+		return nil, fmt.Errorf("获取子文件夹失败: %w", err)
+	}
+	files := config.DB.Model(&model.File{}).Where("folder_id = ?", parentID)
+	if viewerID != 0 {
+		access, err := NewACLService().AccessFor(viewerID)
+		if err != nil {
+			return nil, err
+		}
+		contents.Folders = access.Folders(contents.Folders)
+		files = access.Scope(files)
+	}
+	if err := files.Count(&contents.FileCount).Error; err != nil {
+		return nil, fmt.Errorf("获取文件数失败: %w", err)
+	}
+	return contents, nil
+}
+
+// Breadcrumbs 从根目录到指定文件夹（含自身）的各级文件夹
+func (s *FolderService) Breadcrumbs(folder *model.Folder) ([]model.Folder, error) {
+	crumbs := []model.Folder{*folder}
+	seen := map[uint]bool{folder.ID: true}
+	for parentID := folder.ParentID; parentID != 0; {
+		if seen[parentID] {
+			return nil, fmt.Errorf("文件夹%d的上级关系存在循环", folder.ID)
+		}
+		seen[parentID] = true
+		parent, err := s.GetFolder(parentID)
+		if err != nil {
+			return nil, err
+		}
+		crumbs = append(crumbs, *parent)
+		parentID = parent.ParentID
+	}
+	for i, j := 0, len(crumbs)-1; i < j; i, j = i+1, j-1 {
+		crumbs[i], crumbs[j] = crumbs[j], crumbs[i]
+	}
+	return crumbs, nil
+}
+
+// GetTree 获取完整的文件夹树，viewerID不为0时只包含该用户有查看权限的文件夹
+func (s *FolderService) GetTree(viewerID uint) ([]model.Folder, error) {
+	var folders []model.Folder
+	if err := config.DB.Order("name ASC").Find(&folders).Error; err != nil {
+		return nil, fmt.Errorf("获取文件夹列表失败: %w", err)
+	}
+	if viewerID != 0 {
+		access, err := NewACLService().AccessFor(viewerID)
+		if err != nil {
+			return nil, err
+		}
+		folders = access.Folders(folders)
+	}
+
+	children := map[uint][]model.Folder{}
+	for _, folder := range folders {
+		children[folder.ParentID] = append(children[folder.ParentID], folder)
+	}
+	var build func(parentID uint) []model.Folder
+	build = func(parentID uint) []model.Folder {
+		list := children[parentID]
+		for i := range list {
+			list[i].Children = build(list[i].ID)
+		}
+		return list
+	}
+	tree := build(0)
+	if tree == nil {
+		tree = []model.Folder{}
+	}
+	return tree, nil
+}
+
+// CreateFolder 创建文件夹
+func (s *FolderService) CreateFolder(userID uint, req *model.FolderCreateRequest) (*model.Folder, error) {
+	name, err := folderName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	folderMu.Lock()
+	defer folderMu.Unlock()
+
+	parentPath, err := s.parentPath(req.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFolderName(req.ParentID, name, 0); err != nil {
+		return nil, err
+	}
+
+	folder := &model.Folder{Name: name, ParentID: req.ParentID, Path: parentPath + "/" + name, CreatedBy: userID}
+	if err := config.DB.Create(folder).Error; err != nil {
+		return nil, fmt.Errorf("创建文件夹失败: %w", err)
+	}
+	return folder, nil
+}
+
+// RenameFolder 重命名文件夹，子文件夹的路径随之更新
+func (s *FolderService) RenameFolder(id uint, req *model.FolderRenameRequest) (*model.Folder, error) {
+	name, err := folderName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	folderMu.Lock()
+	defer folderMu.Unlock()
+
+	folder, err := s.GetFolder(id)
+	if err != nil {
+		return nil, err
+	}
+	if name == folder.Name {
+		return folder, nil
+	}
+	if err := checkFolderName(folder.ParentID, name, folder.ID); err != nil {
+		return nil, err
+	}
+
+	parentPath, err := s.parentPath(folder.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	return folder, s.relocate(folder, map[string]interface{}{"name": name}, parentPath+"/"+name)
+}
+
+// MoveFolder 将文件夹移动到另一个文件夹下
+func (s *FolderService) MoveFolder(id uint, req *model.FolderMoveRequest) (*model.Folder, error) {
+	folderMu.Lock()
+	defer folderMu.Unlock()
+
+	folder, err := s.GetFolder(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.ParentID == folder.ParentID {
+		return folder, nil
+	}
+	parentPath, err := s.parentPath(req.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ParentID == folder.ID || strings.HasPrefix(parentPath+"/", folder.Path+"/") {
+		return nil, ErrFolderCycle
+	}
+	if err := checkFolderName(req.ParentID, folder.Name, folder.ID); err != nil {
+		return nil, err
+	}
+
+	return folder, s.relocate(folder, map[string]interface{}{"parent_id": req.ParentID}, parentPath+"/"+folder.Name)
+}
+
+// DeleteFolder 删除文件夹，不为空时需recursive为true才会连同子文件夹一起删除，其中的文件移入回收站
+func (s *FolderService) DeleteFolder(id, userID uint, recursive bool) (*model.FolderDeleteResult, error) {
+	folderMu.Lock()
+	defer folderMu.Unlock()
+
+	folder, err := s.GetFolder(id)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := s.DescendantIDs(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.FolderDeleteResult{Folders: int64(len(ids))}
+	if err := config.DB.Model(&model.File{}).Where("folder_id IN ?", ids).Count(&result.Files).Error; err != nil {
+		return nil, fmt.Errorf("获取文件数失败: %w", err)
+	}
+	if (result.Folders > 1 || result.Files > 0) && !recursive {
+		return result, ErrFolderNotEmpty
+	}
+
+	// 其中的文件各自的访问控制可能不允许当前用户删除，有任一文件不允许时整体拒绝
+	var files []model.File
+	if err := config.DB.Select("id", "folder_id", "uploaded_by").Where("folder_id IN ?", ids).Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("获取文件列表失败: %w", err)
+	}
+	access, err := NewACLService().AccessFor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if allowed := access.FilterFiles(files, model.ACLDelete); len(allowed) < len(files) {
+		permitted := make(map[uint]bool, len(allowed))
+		for _, file := range allowed {
+			permitted[file.ID] = true
+		}
+		for _, file := range files {
+			if !permitted[file.ID] {
+				result.Denied = append(result.Denied, file.ID)
+			}
+		}
+		return result, ErrAccessDenied
+	}
+
+	// 其中的文件移入回收站并改为属于根目录，恢复时回到根目录，为此先保留从文件夹继承的访问控制；
+	// 文件、文件夹和访问控制在同一事务中修改，中途失败时全部回滚，存储对象在彻底删除文件时才释放
+	fileIDs := make([]uint, 0, len(files))
+	for _, file := range files {
+		fileIDs = append(fileIDs, file.ID)
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockForWrite(tx); err != nil {
+			return err
+		}
+		if err := keepInheritedACL(tx, files); err != nil {
+			return err
+		}
+		if len(fileIDs) > 0 {
+			columns := trashColumns(userID)
+			columns["folder_id"] = 0
+			if err := tx.Model(&model.File{}).Where("id IN ?", fileIDs).UpdateColumns(columns).Error; err != nil {
+				return fmt.Errorf("删除文件失败: %w", err)
+			}
+		}
+		if err := tx.Unscoped().Where("id IN ?", ids).Delete(&model.Folder{}).Error; err != nil {
+			return fmt.Errorf("删除文件夹失败: %w", err)
+		}
+		return deleteACLIn(tx, model.ACLResourceFolder, ids...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DescendantIDs 文件夹自身及全部子文件夹的ID
+func (s *FolderService) DescendantIDs(folder *model.Folder) ([]uint, error) {
+	ids := []uint{folder.ID}
+	var children []uint
+	err := config.DB.Model(&model.Folder{}).
+		Where("path LIKE ? ESCAPE '\\'", escapeLike(folder.Path)+"/%").
+		Pluck("id", &children).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取子文件夹失败: %w", err)
+	}
+	return append(ids, children...), nil
+}
+
+// MoveFiles 将文件移动到指定文件夹
+func (s *FolderService) MoveFiles(req *model.FileMoveRequest) error {
+	if req.FolderID != 0 {
+		if _, err := s.GetFolder(req.FolderID); err != nil {
+			return err
+		}
+	}
+	result := config.DB.Model(&model.File{}).Where("id IN ?", req.IDs).Update("folder_id", req.FolderID)
+	if result.Error != nil {
+		return fmt.Errorf("移动文件失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("文件不存在")
+	}
+	return nil
+}
+
+// CopyFiles 将文件复制到指定文件夹，副本与原文件共用存储对象，只复制当前版本，计入操作者的配额
+func (s *FolderService) CopyFiles(userID uint, req *model.FileMoveRequest) ([]model.File, error) {
+	if req.FolderID != 0 {
+		if _, err := s.GetFolder(req.FolderID); err != nil {
+			return nil, err
+		}
+	}
+
+	var files []model.File
+	if err := config.DB.Where("id IN ?", req.IDs).Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("获取文件失败: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, errors.New("文件不存在")
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ID < files[j].ID })
+
+	copies := make([]model.File, 0, len(files))
+	for i := range files {
+		file := &files[i]
+		// 未计算哈希的历史文件先登记为存储对象，副本才能共用
+		if file.BlobID == 0 {
+			if _, err := s.fileService.backfillHash(file, false, nil); err != nil {
+				return copies, fmt.Errorf("复制文件%d失败: %w", file.ID, err)
+			}
+			if err := config.DB.First(file, file.ID).Error; err != nil {
+				return copies, err
+			}
+		}
+		if err := retainBlob(file.BlobID); err != nil {
+			return copies, err
+		}
+
+		copied := *file
+		copied.Base = model.Base{}
+		copied.FolderID = req.FolderID
+		copied.UploadedBy = userID
+		copied.Downloads = 0
+		copied.Version = 1
+		err := withQuota(userID, file.FileSize, 1, false, func(tx *gorm.DB) error {
+			return tx.Create(&copied).Error
+		})
+		if err != nil {
+			releaseBlob(file.BlobID)
+			var uploadErr *UploadError
+			if errors.As(err, &uploadErr) {
+				return copies, err
+			}
+			return copies, fmt.Errorf("复制文件%d失败: %w", file.ID, err)
+		}
+		copies = append(copies, copied)
+	}
+	return copies, nil
+}
+
+// parentPath 上级文件夹的路径，根目录为空字符串
+func (s *FolderService) parentPath(parentID uint) (string, error) {
+	if parentID == 0 {
+		return "", nil
+	}
+	parent, err := s.GetFolder(parentID)
+	if err != nil {
+		return "", err
+	}
+	return parent.Path, nil
+}
+
+// relocate 更新文件夹的名称或上级，并把自身和全部子文件夹的路径前缀替换为newPath
+func (s *FolderService) relocate(folder *model.Folder, updates map[string]interface{}, newPath string) error {
+	oldPath := folder.Path
+	var descendants []model.Folder
+	err := config.DB.Where("path LIKE ? ESCAPE '\\'", escapeLike(oldPath)+"/%").Find(&descendants).Error
+	if err != nil {
+		return fmt.Errorf("获取子文件夹失败: %w", err)
+	}
+
+	updates["path"] = newPath
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(folder).Updates(updates).Error; err != nil {
+			return err
+		}
+		for _, child := range descendants {
+			path := newPath + strings.TrimPrefix(child.Path, oldPath)
+			if err := tx.Model(&model.Folder{}).Where("id = ?", child.ID).Update("path", path).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("更新文件夹失败: %w", err)
+	}
+	return nil
+}
+
+// folderIDsForFilter 文件列表按文件夹筛选时的文件夹ID，recursive为true时包含全部子文件夹
+func folderIDsForFilter(folderID uint, recursive bool) []uint {
+	if !recursive {
+		return []uint{folderID}
+	}
+	if folderID == 0 {
+		return nil
+	}
+	var folder model.Folder
+	if config.DB.Limit(1).Find(&folder, folderID).RowsAffected == 0 {
+		return []uint{folderID}
+	}
+	ids, err := NewFolderService().DescendantIDs(&folder)
+	if err != nil {
+		log.Printf("获取文件夹%d的子文件夹失败: %v", folderID, err)
+		return []uint{folderID}
+	}
+	return ids
+}
+
+// checkFolderName 同一上级文件夹下不能重名，excludeID为重命名或移动的文件夹自身
+func checkFolderName(parentID uint, name string, excludeID uint) error {
+	var count int64
+	config.DB.Model(&model.Folder{}).Where("parent_id = ? AND name = ? AND id <> ?", parentID, name, excludeID).Count(&count)
+	if count > 0 {
+		return ErrFolderExists
+	}
+	return nil
+}
+
+// folderName 去掉首尾空白并校验文件夹名称
+func folderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return "", ErrFolderName
+	}
+	return name, nil
+}
+
+// normalizeFolderPath 统一为以 / 开头、不以 / 结尾的路径，根目录返回空字符串
+func normalizeFolderPath(path string) string {
+	parts := strings.Split(path, "/")
+	clean := parts[:0]
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			clean = append(clean, part)
+		}
+	}
+	if len(clean) == 0 {
+		return ""
+	}
+	return "/" + strings.Join(clean, "/")
+}
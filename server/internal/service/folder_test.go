@@ -0,0 +1,125 @@
+package service
+
+import (
+	"errors"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestDeleteFolderRecursive(t *testing.T) {
+	ownerID := createTestUser(t, "folder-owner", "user", "")
+	s := NewFolderService()
+	parent, err := s.CreateFolder(ownerID, &model.FolderCreateRequest{Name: "delete-parent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := s.CreateFolder(ownerID, &model.FolderCreateRequest{Name: "child", ParentID: parent.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grant := model.FileACL{ResourceType: model.ACLResourceFolder, ResourceID: parent.ID,
+		SubjectType: model.ACLSubjectDepartment, SubjectName: "ops", Permissions: []string{model.ACLView}}
+	if err := config.DB.Create(&grant).Error; err != nil {
+		t.Fatal(err)
+	}
+	files := []model.File{
+		createTestRecord(t, "a.txt", parent.ID, ownerID),
+		createTestRecord(t, "b.txt", child.ID, ownerID),
+	}
+
+	result, err := s.DeleteFolder(parent.ID, ownerID, false)
+	if !errors.Is(err, ErrFolderNotEmpty) || result.Folders != 2 || result.Files != 2 {
+		t.Fatalf("non-recursive delete = %+v, %v", result, err)
+	}
+	if _, err := s.DeleteFolder(parent.ID, ownerID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var folders int64
+	config.DB.Model(&model.Folder{}).Where("id IN ?", []uint{parent.ID, child.ID}).Count(&folders)
+	if folders != 0 {
+		t.Errorf("%d folders left", folders)
+	}
+	var folderACL int64
+	config.DB.Model(&model.FileACL{}).Where("resource_type = ? AND resource_id = ?", model.ACLResourceFolder, parent.ID).Count(&folderACL)
+	if folderACL != 0 {
+		t.Errorf("folder ACL not deleted")
+	}
+	for _, file := range files {
+		var got model.File
+		config.DB.Unscoped().First(&got, file.ID)
+		if !got.Trashed || got.FolderID != 0 || got.DeletedBy != ownerID || !got.DeletedAt.Valid {
+			t.Errorf("file %d not moved to trash: %+v", file.ID, got)
+		}
+		// 从文件夹继承的条目保留在文件上
+		var entries []model.FileACL
+		config.DB.Where("resource_type = ? AND resource_id = ?", model.ACLResourceFile, file.ID).Find(&entries)
+		if len(entries) != 1 || entries[0].SubjectName != "ops" {
+			t.Errorf("file %d inherited ACL = %+v", file.ID, entries)
+		}
+	}
+}
+
+func TestDeleteFolderDeniedKeepsEverything(t *testing.T) {
+	ownerID := createTestUser(t, "folder-owner-2", "user", "")
+	otherID := createTestUser(t, "folder-other", "user", "")
+	s := NewFolderService()
+	folder, err := s.CreateFolder(otherID, &model.FolderCreateRequest{Name: "delete-denied"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mine := createTestRecord(t, "mine.txt", folder.ID, ownerID)
+	// 文件自身的条目只授权给上传者，其他用户不能删除
+	restricted := createTestRecord(t, "restricted.txt", folder.ID, otherID)
+	config.DB.Create(&model.FileACL{ResourceType: model.ACLResourceFile, ResourceID: restricted.ID,
+		SubjectType: model.ACLSubjectUser, SubjectID: otherID, Permissions: []string{model.ACLView}})
+
+	result, err := s.DeleteFolder(folder.ID, ownerID, true)
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("delete = %v, want ErrAccessDenied", err)
+	}
+	if len(result.Denied) != 1 || result.Denied[0] != restricted.ID {
+		t.Errorf("denied = %v", result.Denied)
+	}
+	var got model.File
+	config.DB.Unscoped().First(&got, mine.ID)
+	if got.Trashed || got.FolderID != folder.ID {
+		t.Errorf("no file should be trashed when the delete is denied")
+	}
+}
+
+func TestDeleteFolderRollsBack(t *testing.T) {
+	ownerID := createTestUser(t, "folder-owner-3", "user", "")
+	s := NewFolderService()
+	folder, err := s.CreateFolder(ownerID, &model.FolderCreateRequest{Name: "delete-rollback"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := createTestRecord(t, "rollback.txt", folder.ID, ownerID)
+
+	// 文件移入回收站之后删除文件夹失败
+	const callback = "test:fail_folder_delete"
+	config.DB.Callback().Delete().Before("gorm:delete").Register(callback, func(db *gorm.DB) {
+		if db.Statement.Table == "folders" {
+			db.AddError(errors.New("disk I/O error"))
+		}
+	})
+	_, err = s.DeleteFolder(folder.ID, ownerID, true)
+	config.DB.Callback().Delete().Remove(callback)
+	if err == nil {
+		t.Fatal("delete should fail")
+	}
+
+	var got model.File
+	config.DB.Unscoped().First(&got, file.ID)
+	if got.Trashed || got.FolderID != folder.ID {
+		t.Errorf("file should stay in the folder after a failed delete: %+v", got)
+	}
+	// 失败后重试得到相同的结果
+	if _, err := s.DeleteFolder(folder.ID, ownerID, true); err != nil {
+		t.Fatalf("retry = %v", err)
+	}
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/pkg/imaging"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 图片缩放方式
+const (
+	FitContain = "contain" // 等比缩放到不超过指定宽高
+	FitCover   = "cover"   // 等比缩放并居中裁剪为指定宽高
+)
+
+var (
+	// ErrNotImage 文件不是支持处理的图片
+	ErrNotImage = errors.New("文件不是支持处理的图片")
+	// ErrInvalidVariant 变换参数不在允许范围内
+	ErrInvalidVariant = errors.New("图片变换参数无效")
+	// ErrThumbnailURLInvalid 缩略图地址签名无效、已过期或文件内容已变化
+	ErrThumbnailURLInvalid = errors.New("缩略图地址无效或已过期")
+)
+
+// maxImageBytes 非本地存储的图片读入内存处理，超过该大小不处理
+const maxImageBytes = 64 << 20
+
+// thumbnailURLTTL 缩略图签名地址的有效期，过期时间按小时取整，同一小时内地址不变，浏览器可以缓存
+const thumbnailURLTTL = 2 * time.Hour
+
+// variantLocks 同一变换结果只生成一次
+var variantLocks keyLocks
+
+// VariantOptions 图片变换参数
+type VariantOptions struct {
+	Width  int    // 宽度，0表示按高度等比缩放
+	Height int    // 高度，0表示按宽度等比缩放
+	Fit    string // contain或cover，默认contain
+	Format string // jpeg、png、gif，默认与原图相同（GIF输出为PNG）
+}
+
+// ImageService 图片缩略图和变换服务
+type ImageService struct {
+	fileService *FileService
+}
+
+// NewImageService 创建图片服务实例
+func NewImageService() *ImageService {
+	return &ImageService{fileService: NewFileService()}
+}
+
+// variantSizes 允许的宽高
+func variantSizes() []int {
+	if sizes := config.App.Thumbnail.Sizes; len(sizes) > 0 {
+		return sizes
+	}
+	return []int{64, 128, 256, 512, 1024}
+}
+
+// thumbnailOptions 上传时生成、文件列表中展示的缩略图参数
+func thumbnailOptions() VariantOptions {
+	size := config.App.Thumbnail.Size
+	if size <= 0 {
+		size = 256
+	}
+	return VariantOptions{Width: size, Height: size, Fit: FitContain}
+}
+
+func variantCacheDir() string {
+	if dir := config.App.Thumbnail.CacheDir; dir != "" {
+		return dir
+	}
+	return filepath.Join("tmp", "variants")
+}
+
+// IsImage 文件是否为可生成缩略图的图片
+func IsImage(file *model.File) bool {
+	if file.MimeType != "" {
+		return strings.HasPrefix(mediaType(file.MimeType), "image/") && imaging.NormalizeFormat(mediaTypeSubtype(file.MimeType)) != ""
+	}
+	// 早期文件没有识别MIME类型，按扩展名判断
+	return imaging.NormalizeFormat(file.FileType) != ""
+}
+
+// ThumbnailURL 文件列表中展示的缩略图地址，非图片返回空字符串；
+// 地址带有与分享链接相同密钥签名的令牌，<img>等无法设置请求头的场景可以直接加载，只能获取缩略图
+func ThumbnailURL(file *model.File) string {
+	if !IsImage(file) {
+		return ""
+	}
+	expires := time.Now().Truncate(time.Hour).Add(thumbnailURLTTL)
+	payload := fmt.Sprintf("thumb.%d.%s.%d", file.ID, file.Hash, expires.Unix())
+	return "/api/thumbnails/" + base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(signShare(payload))
+}
+
+// ThumbnailFile 校验缩略图地址的令牌，返回对应的文件；文件内容变化后旧地址失效
+func (s *ImageService) ThumbnailFile(token string) (*model.File, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrThumbnailURLInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrThumbnailURLInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, signShare(string(payload))) {
+		return nil, ErrThumbnailURLInvalid
+	}
+	parts := strings.Split(string(payload), ".")
+	if len(parts) != 4 || parts[0] != "thumb" {
+		return nil, ErrThumbnailURLInvalid
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, ErrThumbnailURLInvalid
+	}
+	expires, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return nil, ErrThumbnailURLInvalid
+	}
+
+	file, err := s.fileService.GetFileByID(uint(id))
+	if err != nil {
+		return nil, err
+	}
+	if file.Hash != parts[2] {
+		return nil, ErrThumbnailURLInvalid
+	}
+	return file, nil
+}
+
+// Thumbnail 获取缩略图，返回缓存文件路径和内容类型
+func (s *ImageService) Thumbnail(file *model.File) (string, string, error) {
+	return s.Variant(file, thumbnailOptions())
+}
+
+// Variant 获取变换后的图片，结果按内容哈希缓存在磁盘上，相同内容的文件共用缓存
+func (s *ImageService) Variant(file *model.File, opts VariantOptions) (string, string, error) {
+	if !IsImage(file) {
+		return "", "", ErrNotImage
+	}
+	opts, err := normalizeVariant(file, opts)
+	if err != nil {
+		return "", "", err
+	}
+
+	path := variantPath(file, opts)
+	contentType := imaging.ContentType(opts.Format)
+	if _, err := os.Stat(path); err == nil {
+		return path, contentType, nil
+	}
+
+	defer variantLocks.lock(path)()
+	if _, err := os.Stat(path); err == nil {
+		return path, contentType, nil
+	}
+
+	if err := s.render(file, opts, path); err != nil {
+		return "", "", err
+	}
+	return path, contentType, nil
+}
+
+// render 读取原图、变换并写入缓存文件
+func (s *ImageService) render(file *model.File, opts VariantOptions, path string) error {
+	rc, _, err := s.fileService.OpenFile(file)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	r, ok := rc.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(io.LimitReader(rc, maxImageBytes+1))
+		if err != nil {
+			return fmt.Errorf("读取图片失败: %w", err)
+		}
+		if len(data) > maxImageBytes {
+			return imaging.ErrTooLarge
+		}
+		r = bytes.NewReader(data)
+	}
+
+	maxPixels := config.App.Thumbnail.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = 40000000
+	}
+	img, _, err := imaging.Decode(r, maxPixels)
+	if err != nil {
+		return err
+	}
+	if opts.Fit == FitCover {
+		img = imaging.Fill(img, opts.Width, opts.Height)
+	} else {
+		img = imaging.Fit(img, opts.Width, opts.Height)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".variant-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := imaging.Encode(tmp, img, opts.Format, config.App.Thumbnail.Quality); err != nil {
+		tmp.Close()
+		return fmt.Errorf("生成图片失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// normalizeVariant 校验参数是否在白名单内并补全默认值
+func normalizeVariant(file *model.File, opts VariantOptions) (VariantOptions, error) {
+	if opts.Fit == "" {
+		opts.Fit = FitContain
+	}
+	if opts.Fit != FitContain && opts.Fit != FitCover {
+		return opts, fmt.Errorf("%w: fit只能为contain或cover", ErrInvalidVariant)
+	}
+	if opts.Fit == FitCover && (opts.Width == 0 || opts.Height == 0) {
+		return opts, fmt.Errorf("%w: cover需要同时指定宽度和高度", ErrInvalidVariant)
+	}
+	for _, size := range []int{opts.Width, opts.Height} {
+		if size != 0 && !containsInt(variantSizes(), size) {
+			return opts, fmt.Errorf("%w: 宽高只能为%v", ErrInvalidVariant, variantSizes())
+		}
+	}
+
+	if opts.Format == "" {
+		opts.Format = sourceFormat(file)
+	} else if opts.Format = imaging.NormalizeFormat(opts.Format); opts.Format == "" {
+		return opts, fmt.Errorf("%w: format只能为jpeg、png或gif", ErrInvalidVariant)
+	}
+	if opts.Width == 0 && opts.Height == 0 && opts.Format == originalFormat(file) {
+		return opts, fmt.Errorf("%w: 请指定宽高或输出格式", ErrInvalidVariant)
+	}
+	return opts, nil
+}
+
+// sourceFormat 默认输出格式，GIF缩放后只保留第一帧，输出为PNG
+func sourceFormat(file *model.File) string {
+	if format := originalFormat(file); format != imaging.GIF {
+		return format
+	}
+	return imaging.PNG
+}
+
+// originalFormat 原图格式
+func originalFormat(file *model.File) string {
+	if format := imaging.NormalizeFormat(mediaTypeSubtype(file.MimeType)); format != "" {
+		return format
+	}
+	return imaging.NormalizeFormat(file.FileType)
+}
+
+// variantPath 缓存路径：<缓存目录>/<内容哈希>/<宽>x<高>-<缩放方式>.<格式>
+func variantPath(file *model.File, opts VariantOptions) string {
+	return filepath.Join(variantCacheKey(file), fmt.Sprintf("%dx%d-%s.%s", opts.Width, opts.Height, opts.Fit, opts.Format))
+}
+
+// variantCacheKey 文件的缓存目录，早期没有哈希的文件按ID区分
+func variantCacheKey(file *model.File) string {
+	return filepath.Join(variantCacheDir(), variantCacheName(file.Hash, file.ID))
+}
+
+func variantCacheName(hash string, fileID uint) string {
+	if hash != "" {
+		return hash
+	}
+	return fmt.Sprintf("file-%d", fileID)
+}
+
+// removeVariants 删除内容对应的全部缓存，存储对象删除时调用
+func removeVariants(hash string, fileID uint) {
+	if err := os.RemoveAll(filepath.Join(variantCacheDir(), variantCacheName(hash, fileID))); err != nil {
+		log.Printf("删除图片缓存失败: %v", err)
+	}
+}
+
+// generateThumbnail 上传图片后在后台生成缩略图
+func generateThumbnail(file model.File) {
+	if !IsImage(&file) {
+		return
+	}
+	go func() {
+		if _, _, err := NewImageService().Thumbnail(&file); err != nil {
+			log.Printf("生成文件%d的缩略图失败: %v", file.ID, err)
+		}
+	}()
+}
+
+// mediaTypeSubtype MIME类型的子类型，如 image/png -> png
+func mediaTypeSubtype(contentType string) string {
+	if i := strings.IndexByte(mediaType(contentType), '/'); i >= 0 {
+		return mediaType(contentType)[i+1:]
+	}
+	return ""
+}
+
+func containsInt(list []int, v int) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/storage"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// quarantinePrefix 孤立对象的隔离区，检查时跳过其中的对象
+const quarantinePrefix = "quarantine/"
+
+// orphanGrace 最近写入的对象可能属于尚未保存记录的上传，不视为孤立对象
+const orphanGrace = time.Hour
+
+var (
+	// ErrIntegrityRunning 已有一致性检查正在进行
+	ErrIntegrityRunning = errors.New("已有一致性检查正在进行")
+	// ErrIntegrityReportNotFound 检查报告不存在
+	ErrIntegrityReportNotFound = errors.New("检查报告不存在")
+)
+
+// integrityRunning 本进程中是否有检查正在进行
+var integrityRunning int32
+
+// IntegrityService 存储一致性检查服务，找出没有记录引用的孤立对象、记录引用但已不存在的对象，
+// 以及大小或内容哈希与记录不一致的对象
+type IntegrityService struct{}
+
+// NewIntegrityService 创建存储一致性检查服务实例
+func NewIntegrityService() *IntegrityService {
+	return &IntegrityService{}
+}
+
+// Start 在后台发起一致性检查，返回状态为running的报告
+func (s *IntegrityService) Start(userID uint, req *model.IntegrityCheckRequest) (*model.IntegrityReport, error) {
+	report, err := s.begin(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer atomic.StoreInt32(&integrityRunning, 0)
+		s.run(context.Background(), report)
+	}()
+	return report, nil
+}
+
+// Check 执行一致性检查并等待完成，用于命令行
+func (s *IntegrityService) Check(ctx context.Context, req *model.IntegrityCheckRequest) (*model.IntegrityReport, error) {
+	report, err := s.begin(0, req)
+	if err != nil {
+		return nil, err
+	}
+	defer atomic.StoreInt32(&integrityRunning, 0)
+	s.run(ctx, report)
+	if report.Status == model.IntegrityFailed {
+		return report, errors.New(report.Error)
+	}
+	return report, nil
+}
+
+// GetReports 最近的检查报告，不含问题明细
+func (s *IntegrityService) GetReports(limit int) ([]model.IntegrityReport, error) {
+	reports := []model.IntegrityReport{}
+	if err := config.DB.Omit("issues").Order("id DESC").Limit(limit).Find(&reports).Error; err != nil {
+		return nil, fmt.Errorf("获取检查报告失败: %w", err)
+	}
+	return reports, nil
+}
+
+// GetReport 获取检查报告及其问题明细
+func (s *IntegrityService) GetReport(id uint) (*model.IntegrityReport, error) {
+	var report model.IntegrityReport
+	if config.DB.Limit(1).Find(&report, id).RowsAffected == 0 {
+		return nil, ErrIntegrityReportNotFound
+	}
+	if report.Issues == nil {
+		report.Issues = []model.IntegrityIssue{}
+	}
+	return &report, nil
+}
+
+// begin 保存running状态的报告，同一进程中同时只能有一个检查
+func (s *IntegrityService) begin(userID uint, req *model.IntegrityCheckRequest) (*model.IntegrityReport, error) {
+	if !atomic.CompareAndSwapInt32(&integrityRunning, 0, 1) {
+		return nil, ErrIntegrityRunning
+	}
+	report := &model.IntegrityReport{
+		Status:      model.IntegrityRunning,
+		Verify:      req.Verify,
+		Repair:      req.Repair,
+		TriggeredBy: userID,
+		Skipped:     []string{},
+		Issues:      []model.IntegrityIssue{},
+	}
+	if err := config.DB.Create(report).Error; err != nil {
+		atomic.StoreInt32(&integrityRunning, 0)
+		return nil, fmt.Errorf("保存检查报告失败: %w", err)
+	}
+	return report, nil
+}
+
+// run 执行检查并保存结果
+func (s *IntegrityService) run(ctx context.Context, report *model.IntegrityReport) {
+	checker := &integrityChecker{
+		report:     report,
+		refs:       map[string]map[string]*objectRef{},
+		quarantine: quarantinePrefix + report.CreatedAt.Format("20060102-150405") + "/",
+	}
+	if err := checker.check(ctx); err != nil {
+		report.Status = model.IntegrityFailed
+		report.Error = err.Error()
+	} else {
+		report.Status = model.IntegrityDone
+	}
+	now := time.Now()
+	report.FinishedAt = &now
+	if err := config.DB.Save(report).Error; err != nil {
+		log.Printf("保存检查报告%d失败: %v", report.ID, err)
+	}
+	if report.Status == model.IntegrityDone {
+		log.Printf("存储一致性检查完成：孤立对象%d个，缺失%d个，不一致%d个，已修复%d个",
+			report.Orphans, report.Missing, report.Mismatches, report.Repaired)
+	}
+}
+
+// objectRef 记录对一个存储对象的引用，size为-1表示记录中没有大小
+type objectRef struct {
+	size    int64
+	hash    string
+	blobID  uint
+	fileIDs []uint
+	seen    bool
+}
+
+// integrityChecker 一次检查的状态
+type integrityChecker struct {
+	report     *model.IntegrityReport
+	refs       map[string]map[string]*objectRef // 存储后端 -> 对象键 -> 引用
+	quarantine string                           // 本次检查的隔离目录
+	broken     []uint                           // 需要标记为损坏的文件
+}
+
+func (c *integrityChecker) check(ctx context.Context) error {
+	if err := c.loadRefs(); err != nil {
+		return err
+	}
+
+	backends := []string{DefaultStorage().Name()}
+	for name := range c.refs {
+		if name != backends[0] {
+			backends = append(backends, name)
+		}
+	}
+	sort.Strings(backends[1:])
+
+	var checked []string
+	for _, name := range backends {
+		st, err := GetStorage(name)
+		if err != nil {
+			c.report.Skipped = append(c.report.Skipped, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if err := c.checkBackend(ctx, st); err != nil {
+			return fmt.Errorf("检查存储后端%s失败: %w", name, err)
+		}
+		checked = append(checked, name)
+	}
+
+	if c.report.Repair {
+		return c.markBroken(checked)
+	}
+	return nil
+}
+
+// loadRefs 汇总存储对象、文件（包括回收站中的文件）和历史版本记录引用的对象
+func (c *integrityChecker) loadRefs() error {
+	var blobs []model.Blob
+	if err := config.DB.Find(&blobs).Error; err != nil {
+		return fmt.Errorf("获取存储对象记录失败: %w", err)
+	}
+	byID := make(map[uint]*objectRef, len(blobs))
+	for _, blob := range blobs {
+		ref := c.ref(blob.Backend, blob.ObjectKey)
+		ref.size, ref.hash, ref.blobID = blob.Size, blob.Hash, blob.ID
+		byID[blob.ID] = ref
+	}
+
+	var files []model.File
+	if err := config.DB.Unscoped().Select("id", "backend", "object_key", "blob_id", "file_size", "hash").
+		Where("deleted_at IS NULL OR trashed = ?", true).Find(&files).Error; err != nil {
+		return fmt.Errorf("获取文件记录失败: %w", err)
+	}
+	for _, file := range files {
+		if ref, ok := byID[file.BlobID]; ok {
+			ref.fileIDs = append(ref.fileIDs, file.ID)
+			continue
+		}
+		// 未去重的历史文件，或存储对象记录已丢失，按文件自身记录的对象检查
+		ref := c.ref(file.Backend, file.ObjectKey)
+		if ref.size < 0 {
+			ref.size, ref.hash = file.FileSize, file.Hash
+		}
+		ref.fileIDs = append(ref.fileIDs, file.ID)
+	}
+
+	var versions []model.FileVersion
+	if err := config.DB.Select("id", "backend", "object_key", "blob_id", "file_size", "hash").
+		Find(&versions).Error; err != nil {
+		return fmt.Errorf("获取版本记录失败: %w", err)
+	}
+	for _, version := range versions {
+		if _, ok := byID[version.BlobID]; ok {
+			continue
+		}
+		ref := c.ref(version.Backend, version.ObjectKey)
+		if ref.size < 0 {
+			ref.size, ref.hash = version.FileSize, version.Hash
+		}
+	}
+	return nil
+}
+
+// ref 获取或创建对象的引用
+func (c *integrityChecker) ref(backend, key string) *objectRef {
+	if backend == "" {
+		backend = storage.BackendLocal
+	}
+	if c.refs[backend] == nil {
+		c.refs[backend] = map[string]*objectRef{}
+	}
+	ref := c.refs[backend][key]
+	if ref == nil {
+		ref = &objectRef{size: -1}
+		c.refs[backend][key] = ref
+	}
+	return ref
+}
+
+// checkBackend 列出后端中的全部对象与记录比较，不支持列出时只逐个检查记录引用的对象
+func (c *integrityChecker) checkBackend(ctx context.Context, st storage.Storage) error {
+	refs := c.refs[st.Name()]
+	c.report.Records += int64(len(refs))
+	var orphans []storage.ObjectInfo
+	lister, canList := st.(storage.Lister)
+	if canList {
+		err := lister.List(ctx, "", func(info storage.ObjectInfo) error {
+			if strings.HasPrefix(info.Key, quarantinePrefix) {
+				return nil
+			}
+			c.report.Objects++
+			ref := refs[info.Key]
+			if ref == nil {
+				if time.Since(info.LastModified) >= orphanGrace {
+					orphans = append(orphans, info)
+				}
+				return nil
+			}
+			ref.seen = true
+			return c.compare(ctx, st, info.Key, ref, info.Size)
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		c.report.Skipped = append(c.report.Skipped, st.Name()+": 不支持列出对象，未检查孤立对象")
+	}
+
+	// 列出时没有看到的对象逐个确认，避免把检查期间写入的对象误报为缺失
+	keys := make([]string, 0, len(refs))
+	for key, ref := range refs {
+		if !ref.seen {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		ref := refs[key]
+		if key == "" {
+			c.addIssue(model.IntegrityIssue{Type: model.IntegrityMissing, Backend: st.Name(), Detail: "记录中没有对象键"}, ref)
+			continue
+		}
+		info, err := st.Stat(ctx, key)
+		if errors.Is(err, storage.ErrNotFound) {
+			c.addIssue(model.IntegrityIssue{Type: model.IntegrityMissing, Backend: st.Name(), ObjectKey: key}, ref)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("获取对象%s信息失败: %w", key, err)
+		}
+		if !canList {
+			c.report.Objects++
+		}
+		if err := c.compare(ctx, st, key, ref, info.Size); err != nil {
+			return err
+		}
+	}
+
+	for _, info := range orphans {
+		c.addOrphan(ctx, st, info)
+	}
+	return nil
+}
+
+// compare 比较对象的大小，需要时读取内容比较哈希
+func (c *integrityChecker) compare(ctx context.Context, st storage.Storage, key string, ref *objectRef, size int64) error {
+	if ref.size >= 0 && size != ref.size {
+		c.addIssue(model.IntegrityIssue{
+			Type:      model.IntegritySizeMismatch,
+			Backend:   st.Name(),
+			ObjectKey: key,
+			Expected:  strconv.FormatInt(ref.size, 10),
+			Actual:    strconv.FormatInt(size, 10),
+		}, ref)
+		return nil
+	}
+	if !c.report.Verify || ref.hash == "" {
+		return nil
+	}
+
+	hash, err := hashObject(ctx, st, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.addIssue(model.IntegrityIssue{Type: model.IntegrityMissing, Backend: st.Name(), ObjectKey: key}, ref)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取对象%s失败: %w", key, err)
+	}
+	if hash != ref.hash {
+		c.addIssue(model.IntegrityIssue{
+			Type:      model.IntegrityHashMismatch,
+			Backend:   st.Name(),
+			ObjectKey: key,
+			Expected:  ref.hash,
+			Actual:    hash,
+		}, ref)
+	}
+	return nil
+}
+
+// addIssue 记录缺失或不一致的对象，修复时引用该对象的文件标记为损坏
+func (c *integrityChecker) addIssue(issue model.IntegrityIssue, ref *objectRef) {
+	issue.BlobID = ref.blobID
+	issue.FileIDs = ref.fileIDs
+	if issue.Type == model.IntegrityMissing {
+		c.report.Missing++
+	} else {
+		c.report.Mismatches++
+	}
+	if c.report.Repair && len(ref.fileIDs) > 0 {
+		issue.Repair = model.IntegrityMarked
+		c.broken = append(c.broken, ref.fileIDs...)
+		c.report.Repaired++
+	}
+	c.report.Issues = append(c.report.Issues, issue)
+}
+
+// addOrphan 记录孤立对象，修复时移入隔离区，确认无用后可手动删除
+func (c *integrityChecker) addOrphan(ctx context.Context, st storage.Storage, info storage.ObjectInfo) {
+	issue := model.IntegrityIssue{
+		Type:      model.IntegrityOrphan,
+		Backend:   st.Name(),
+		ObjectKey: info.Key,
+		Detail:    fmt.Sprintf("%d字节，最后修改于%s", info.Size, info.LastModified.Format("2006-01-02 15:04:05")),
+	}
+	c.report.Orphans++
+	if c.report.Repair {
+		if err := moveObject(ctx, st, info, c.quarantine+info.Key); err != nil {
+			issue.RepairErr = err.Error()
+		} else {
+			issue.Repair = model.IntegrityQuarantined
+			c.report.Repaired++
+		}
+	}
+	c.report.Issues = append(c.report.Issues, issue)
+}
+
+// markBroken 标记本次发现问题的文件为损坏，已检查后端上不再有问题的文件取消标记
+func (c *integrityChecker) markBroken(backends []string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		clear := tx.Unscoped().Model(&model.File{}).Where("broken = ? AND backend IN ?", true, backends)
+		if len(c.broken) > 0 {
+			clear = clear.Where("id NOT IN ?", c.broken)
+		}
+		if err := clear.UpdateColumn("broken", false).Error; err != nil {
+			return fmt.Errorf("更新文件状态失败: %w", err)
+		}
+		if len(c.broken) == 0 {
+			return nil
+		}
+		if err := tx.Unscoped().Model(&model.File{}).Where("id IN ?", c.broken).
+			UpdateColumn("broken", true).Error; err != nil {
+			return fmt.Errorf("标记损坏文件失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// hashObject 读取对象内容计算SHA-256
+func hashObject(ctx context.Context, st storage.Storage, key string) (string, error) {
+	rc, _, err := st.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// moveObject 在同一后端内移动对象，先复制再删除原对象
+func moveObject(ctx context.Context, st storage.Storage, info storage.ObjectInfo, target string) error {
+	rc, _, err := st.Get(ctx, info.Key)
+	if err != nil {
+		return err
+	}
+	err = st.Put(ctx, target, rc, info.Size, info.ContentType)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+	return st.Delete(ctx, info.Key)
+}
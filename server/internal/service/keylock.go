@@ -0,0 +1,47 @@
+package service
+
+import "sync"
+
+// keyLocks 按键串行执行的互斥锁，键没有持有者和等待者时即被移除，不会随键的数量无限增长
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[interface{}]*keyLock
+}
+
+// keyLock 单个键的互斥锁，refs为持有和等待该锁的数量
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock 锁定key，返回解锁函数
+func (l *keyLocks) lock(key interface{}) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[interface{}]*keyLock)
+	}
+	kl := l.locks[key]
+	if kl == nil {
+		kl = &keyLock{}
+		l.locks[key] = kl
+	}
+	kl.refs++
+	l.mu.Unlock()
+
+	kl.Lock()
+	return func() {
+		kl.Unlock()
+		l.mu.Lock()
+		if kl.refs--; kl.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// len 当前持有或等待中的键数量
+func (l *keyLocks) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.locks)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyLocksSerializeSameKey(t *testing.T) {
+	var l keyLocks
+	var wg sync.WaitGroup
+	active := map[int]int{}
+	var mu sync.Mutex
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(key int) {
+			defer wg.Done()
+			defer l.lock(key)()
+			mu.Lock()
+			active[key]++
+			if active[key] > 1 {
+				t.Errorf("key %d locked twice", key)
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			active[key]--
+			mu.Unlock()
+		}(i % 5)
+	}
+	wg.Wait()
+	if n := l.len(); n != 0 {
+		t.Errorf("%d locks left after use", n)
+	}
+}
+
+func TestKeyLocksIndependentKeys(t *testing.T) {
+	var l keyLocks
+	unlock := l.lock("a")
+	done := make(chan struct{})
+	go func() {
+		// 不同的键互不阻塞，相同字符串和整数键也互不影响
+		l.lock("b")()
+		l.lock(uint(1))()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("lock on another key blocked")
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		l.lock("a")()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("same key locked twice")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	<-acquired
+	if n := l.len(); n != 0 {
+		t.Errorf("%d locks left after use", n)
+	}
+}
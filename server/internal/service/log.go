@@ -1,13 +1,28 @@
 package service
 
 import (
+	"fmt"
 	"jing_vue_gin_admin/server/config"
 	"jing_vue_gin_admin/server/internal/model"
+	"sort"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type LogService struct{}
 
+// LogHook 日志写入数据库后的回调，回调中不应执行耗时操作
+type LogHook func(log *model.SystemLog)
+
+var logHooks []LogHook
+
+// AddLogHook 注册日志写入回调，需在服务启动前调用
+func AddLogHook(hook LogHook) {
+	logHooks = append(logHooks, hook)
+}
+
 // NewLogService 创建日志服务
 func NewLogService() *LogService {
 	return &LogService{}
@@ -15,7 +30,15 @@ func NewLogService() *LogService {
 
 // CreateLog 创建系统日志
 func (s *LogService) CreateLog(log *model.SystemLog) error {
-	return config.DB.Create(log).Error
+	enrichLog(log)
+	if err := config.DB.Create(log).Error; err != nil {
+		return err
+	}
+	logHub.publish(log)
+	for _, hook := range logHooks {
+		hook(log)
+	}
+	return nil
 }
 
 // GetLogList 获取日志列表
@@ -23,30 +46,7 @@ func (s *LogService) GetLogList(req *model.LogListRequest) (*model.LogListRespon
 	var total int64
 	var logs []model.SystemLog
 
-	db := config.DB.Model(&model.SystemLog{})
-
-	// 添加查询条件
-	if req.Module != "" {
-		db = db.Where("module = ?", req.Module)
-	}
-	if req.Action != "" {
-		db = db.Where("action = ?", req.Action)
-	}
-	if req.Username != "" {
-		db = db.Where("username LIKE ?", "%"+req.Username+"%")
-	}
-	if req.StartTime != "" {
-		startTime, err := time.Parse("2006-01-02 15:04:05", req.StartTime)
-		if err == nil {
-			db = db.Where("created_at >= ?", startTime)
-		}
-	}
-	if req.EndTime != "" {
-		endTime, err := time.Parse("2006-01-02 15:04:05", req.EndTime)
-		if err == nil {
-			db = db.Where("created_at <= ?", endTime)
-		}
-	}
+	db := applyLogFilter(config.DB.Model(&model.SystemLog{}), &req.LogFilter)
 
 	// 查询总数
 	if err := db.Count(&total).Error; err != nil {
@@ -55,9 +55,20 @@ func (s *LogService) GetLogList(req *model.LogListRequest) (*model.LogListRespon
 
 	// 查询分页数据
 	offset := (req.Page - 1) * req.PageSize
-	if err := db.Offset(offset).Limit(req.PageSize).Order("created_at DESC").Find(&logs).Error; err != nil {
+	sortBy := "created_at"
+	if req.SortBy != "" {
+		sortBy = req.SortBy
+	}
+	sortOrder := "DESC"
+	if req.SortOrder == "asc" {
+		sortOrder = "ASC"
+	}
+	// 排序字段已在请求绑定时校验，以id作为次级排序保证分页稳定
+	order := sortBy + " " + sortOrder + ", id " + sortOrder
+	if err := db.Offset(offset).Limit(req.PageSize).Order(order).Find(&logs).Error; err != nil {
 		return nil, err
 	}
+	highlightLogs(logs, req.Keyword)
 
 	return &model.LogListResponse{
 		Total: total,
@@ -65,6 +76,29 @@ func (s *LogService) GetLogList(req *model.LogListRequest) (*model.LogListRespon
 	}, nil
 }
 
+// ExportLogs 按筛选条件逐行遍历日志，每读取一行回调一次，不在内存中保留结果集
+func (s *LogService) ExportLogs(filter *model.LogFilter, fn func(log *model.SystemLog) error) error {
+	db := applyLogFilter(config.DB.Model(&model.SystemLog{}), filter)
+
+	rows, err := db.Order("created_at DESC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log model.SystemLog
+		if err := config.DB.ScanRows(rows, &log); err != nil {
+			return err
+		}
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 // DeleteLogs 批量删除日志
 func (s *LogService) DeleteLogs(ids []uint) error {
 	return config.DB.Where("id IN ?", ids).Delete(&model.SystemLog{}).Error
@@ -75,6 +109,41 @@ func (s *LogService) ClearLogs() error {
 	return config.DB.Exec("DELETE FROM system_logs").Error
 }
 
+// GetModules 获取日志模块列表：registered为路由注册的模块，
+// 再追加日志中出现过而未注册的模块（如后台任务写入的日志、已移除路由的历史日志）
+func (s *LogService) GetModules(registered []string) ([]string, error) {
+	return mergeLogValues("module", registered)
+}
+
+// GetActions 获取日志操作类型列表，合并方式与GetModules相同
+func (s *LogService) GetActions(registered []string) ([]string, error) {
+	return mergeLogValues("action", registered)
+}
+
+// mergeLogValues 合并已注册的值与日志表中column的不同取值，已注册的值保持原顺序在前，其余按字典序排列
+func mergeLogValues(column string, registered []string) ([]string, error) {
+	var logged []string
+	if err := config.DB.Model(&model.SystemLog{}).
+		Where(column + " <> ''").
+		Distinct().
+		Pluck(column, &logged).Error; err != nil {
+		return nil, fmt.Errorf("获取日志%s列表失败: %w", column, err)
+	}
+	sort.Strings(logged)
+
+	seen := make(map[string]bool, len(registered))
+	values := make([]string, 0, len(registered)+len(logged))
+	for _, list := range [][]string{registered, logged} {
+		for _, v := range list {
+			if !seen[v] {
+				seen[v] = true
+				values = append(values, v)
+			}
+		}
+	}
+	return values, nil
+}
+
 // AddOperationLog 添加操作日志（快捷方法）
 func (s *LogService) AddOperationLog(userID uint, username string, module string, action string, resource string, detail string, ip string, userAgent string, status int) error {
 	log := &model.SystemLog{
@@ -89,4 +158,60 @@ func (s *LogService) AddOperationLog(userID uint, username string, module string
 		Status:    status,
 	}
 	return s.CreateLog(log)
-} 
\ No newline at end of file
+}
+
+// applyLogFilter 添加日志查询条件
+func applyLogFilter(db *gorm.DB, filter *model.LogFilter) *gorm.DB {
+	if filter.Module != "" {
+		db = db.Where("module = ?", filter.Module)
+	}
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if filter.Username != "" {
+		db = db.Where("username LIKE ?", "%"+filter.Username+"%")
+	}
+	if filter.StartTime != "" {
+		startTime, err := time.Parse("2006-01-02 15:04:05", filter.StartTime)
+		if err == nil {
+			db = db.Where("created_at >= ?", startTime)
+		}
+	}
+	if filter.EndTime != "" {
+		endTime, err := time.Parse("2006-01-02 15:04:05", filter.EndTime)
+		if err == nil {
+			db = db.Where("created_at <= ?", endTime)
+		}
+	}
+	if filter.Status != nil {
+		db = db.Where("status = ?", *filter.Status)
+	}
+	if filter.RequestID != "" {
+		db = db.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.Method != "" {
+		db = db.Where("method = ?", strings.ToUpper(filter.Method))
+	}
+	if filter.StatusCode != 0 {
+		db = db.Where("status_code = ?", filter.StatusCode)
+	}
+	if filter.MinDuration != nil {
+		db = db.Where("duration >= ?", *filter.MinDuration)
+	}
+	if filter.MaxDuration != nil {
+		db = db.Where("duration <= ?", *filter.MaxDuration)
+	}
+	if filter.Country != "" {
+		db = db.Where("country = ?", filter.Country)
+	}
+	if filter.Device != "" {
+		db = db.Where("device = ?", filter.Device)
+	}
+	if filter.Flagged {
+		db = db.Where("new_country = ? OR new_device = ?", true, true)
+	}
+	if filter.Keyword != "" {
+		db = applyLogSearch(db, filter.Keyword)
+	}
+	return db
+}
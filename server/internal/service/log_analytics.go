@@ -0,0 +1,279 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidWorkHours 工作时间范围无效
+var ErrInvalidWorkHours = errors.New("工作时间范围无效")
+
+// LogAnalyticsService 日志分析服务，所有统计均由数据库聚合完成
+type LogAnalyticsService struct{}
+
+// NewLogAnalyticsService 创建日志分析服务
+func NewLogAnalyticsService() *LogAnalyticsService {
+	return &LogAnalyticsService{}
+}
+
+// analyticsRange 解析后的统计范围
+type analyticsRange struct {
+	start       time.Time
+	end         time.Time
+	granularity string
+	limit       int
+}
+
+// FailedLogins 登录失败趋势及按用户、IP的排行
+func (s *LogAnalyticsService) FailedLogins(req *model.LogAnalyticsRequest) (*model.FailedLoginAnalytics, error) {
+	r := parseAnalyticsRange(req)
+	failed := func() *gorm.DB {
+		return r.scope(config.DB.Model(&model.SystemLog{})).
+			Where("module = ? AND action = ? AND status = ?", model.LogModuleAuth, model.LogActionLogin, 0)
+	}
+
+	result := &model.FailedLoginAnalytics{
+		Trend:  []model.TrendPoint{},
+		ByUser: []model.FailedLoginStat{},
+		ByIP:   []model.FailedLoginStat{},
+	}
+
+	bucket, args := r.bucketExpr()
+	if err := failed().
+		Select(bucket+" AS bucket, COUNT(*) AS count", args...).
+		Group("bucket").Order("bucket").
+		Scan(&result.Trend).Error; err != nil {
+		return nil, fmt.Errorf("统计登录失败趋势失败: %w", err)
+	}
+
+	if err := failed().
+		Select("username AS `key`, COUNT(*) AS count, MAX(created_at) AS last_at").
+		Group("username").Order("count DESC").Limit(r.limit).
+		Scan(&result.ByUser).Error; err != nil {
+		return nil, fmt.Errorf("统计用户登录失败次数失败: %w", err)
+	}
+
+	if err := failed().
+		Select("ip AS `key`, COUNT(*) AS count, MAX(created_at) AS last_at").
+		Group("ip").Order("count DESC").Limit(r.limit).
+		Scan(&result.ByIP).Error; err != nil {
+		return nil, fmt.Errorf("统计IP登录失败次数失败: %w", err)
+	}
+
+	return result, nil
+}
+
+// TopUsers 操作最多的用户
+func (s *LogAnalyticsService) TopUsers(req *model.LogAnalyticsRequest) ([]model.UserActivityStat, error) {
+	r := parseAnalyticsRange(req)
+	stats := []model.UserActivityStat{}
+	if err := r.scope(config.DB.Model(&model.SystemLog{})).
+		Where("username <> ''").
+		Select("MAX(user_id) AS user_id, username, COUNT(*) AS count, " +
+			"SUM(CASE WHEN status = 0 THEN 1 ELSE 0 END) AS failed, MAX(created_at) AS last_at").
+		Group("username").Order("count DESC").Limit(r.limit).
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("统计活跃用户失败: %w", err)
+	}
+	return stats, nil
+}
+
+// TopEndpoints 调用最多的接口
+func (s *LogAnalyticsService) TopEndpoints(req *model.LogAnalyticsRequest) ([]model.EndpointStat, error) {
+	return s.endpointStats(req, "count DESC")
+}
+
+// ErrorRates 按接口统计错误率，错误率最高的排在前面
+func (s *LogAnalyticsService) ErrorRates(req *model.LogAnalyticsRequest) ([]model.EndpointStat, error) {
+	return s.endpointStats(req, "error_rate DESC, count DESC")
+}
+
+func (s *LogAnalyticsService) endpointStats(req *model.LogAnalyticsRequest, order string) ([]model.EndpointStat, error) {
+	r := parseAnalyticsRange(req)
+	stats := []model.EndpointStat{}
+	if err := r.scope(config.DB.Model(&model.SystemLog{})).
+		Where("path <> ''").
+		Select("method, path, COUNT(*) AS count, " +
+			"SUM(CASE WHEN status_code >= 400 OR status = 0 THEN 1 ELSE 0 END) AS errors, " +
+			"1.0 * SUM(CASE WHEN status_code >= 400 OR status = 0 THEN 1 ELSE 0 END) / COUNT(*) AS error_rate, " +
+			"AVG(duration) AS avg_duration, MAX(duration) AS max_duration").
+		Group("method, path").Order(order).Limit(r.limit).
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("统计接口调用失败: %w", err)
+	}
+	return stats, nil
+}
+
+// UnusualHours 在工作时间之外有操作的用户，小时按服务器本地时区计算（含夏令时）
+func (s *LogAnalyticsService) UnusualHours(req *model.UnusualHourRequest) ([]model.UserActivityStat, error) {
+	r := parseAnalyticsRange(&req.LogAnalyticsRequest)
+	workStart, workEnd := req.WorkStart, req.WorkEnd
+	if workEnd == 0 {
+		workEnd = 20
+		if workStart == 0 {
+			workStart = 8
+		}
+	}
+	if workEnd <= workStart {
+		return nil, ErrInvalidWorkHours
+	}
+
+	hour, args := r.hourExpr()
+	args = append(append(append(args, workStart), args...), workEnd)
+	stats := []model.UserActivityStat{}
+	if err := r.scope(config.DB.Model(&model.SystemLog{})).
+		Where("username <> ''").
+		Where(hour+" < ? OR "+hour+" >= ?", args...).
+		Select("MAX(user_id) AS user_id, username, COUNT(*) AS count, " +
+			"SUM(CASE WHEN status = 0 THEN 1 ELSE 0 END) AS failed, MAX(created_at) AS last_at").
+		Group("username").Order("count DESC").Limit(r.limit).
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("统计非工作时间活动失败: %w", err)
+	}
+	return stats, nil
+}
+
+// FirstSeen 每个用户首次使用的IP或用户代理，最近首次出现的排在前面
+func (s *LogAnalyticsService) FirstSeen(req *model.FirstSeenRequest) ([]model.FirstSeenStat, error) {
+	r := parseAnalyticsRange(&req.LogAnalyticsRequest)
+	column := "ip"
+	if req.Kind == "user_agent" {
+		column = "user_agent"
+	}
+
+	// 首次出现时间需要在全部历史中计算，时间范围只用于筛选首次出现落在范围内的记录
+	db := config.DB.Model(&model.SystemLog{}).Where("username <> '' AND " + column + " <> ''")
+	if req.Username != "" {
+		db = db.Where("username = ?", req.Username)
+	}
+
+	stats := []model.FirstSeenStat{}
+	if err := db.
+		Select("username, "+column+" AS value, MIN(created_at) AS first_seen, MAX(created_at) AS last_seen, COUNT(*) AS count").
+		Group("username, "+column).
+		Having("MIN(created_at) BETWEEN ? AND ?", r.start, r.end).
+		Order("first_seen DESC").Limit(r.limit).
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("统计首次出现记录失败: %w", err)
+	}
+	return stats, nil
+}
+
+// parseAnalyticsRange 解析统计范围，默认最近7天、按天统计、排行取前10
+func parseAnalyticsRange(req *model.LogAnalyticsRequest) analyticsRange {
+	r := analyticsRange{
+		end:         time.Now(),
+		granularity: req.Granularity,
+		limit:       req.Limit,
+	}
+	if req.EndTime != "" {
+		if t, err := time.ParseInLocation("2006-01-02 15:04:05", req.EndTime, time.Local); err == nil {
+			r.end = t
+		}
+	}
+	r.start = r.end.AddDate(0, 0, -7)
+	if req.StartTime != "" {
+		if t, err := time.ParseInLocation("2006-01-02 15:04:05", req.StartTime, time.Local); err == nil {
+			r.start = t
+		}
+	}
+	if r.granularity == "" {
+		r.granularity = "day"
+	}
+	if r.limit <= 0 {
+		r.limit = 10
+	}
+	return r
+}
+
+func (r analyticsRange) scope(db *gorm.DB) *gorm.DB {
+	return db.Where("created_at BETWEEN ? AND ?", r.start, r.end)
+}
+
+// bucketExpr 返回按粒度截断时间的SQL表达式及其参数，时间按服务器本地时区分桶
+func (r analyticsRange) bucketExpr() (string, []interface{}) {
+	formats := map[string]string{
+		"hour": "%Y-%m-%d %H:00",
+		"day":  "%Y-%m-%d",
+		"week": "%Y-W%W",
+	}
+	format, ok := formats[r.granularity]
+	if !ok {
+		format = formats["day"]
+	}
+	offset, args := r.localOffset()
+	return fmt.Sprintf("strftime('%s', created_at, %s)", format, offset), args
+}
+
+// hourExpr 返回取本地时间小时数的SQL表达式及其参数
+func (r analyticsRange) hourExpr() (string, []interface{}) {
+	offset, args := r.localOffset()
+	return fmt.Sprintf("CAST(strftime('%%H', created_at, %s) AS INTEGER)", offset), args
+}
+
+// localOffset SQLite的strftime按UTC计算，需要加上服务器时区偏移；统计范围跨越夏令时切换时
+// 偏移按每条日志的时间分段取值，返回strftime修饰符表达式及其参数
+func (r analyticsRange) localOffset() (string, []interface{}) {
+	segments := offsetSegments(r.start, r.end)
+	if len(segments) == 1 {
+		return "?", []interface{}{offsetModifier(segments[0].offset)}
+	}
+	expr := "CASE"
+	var args []interface{}
+	for _, seg := range segments[:len(segments)-1] {
+		// 按UTC比较，夏令时结束时重复的本地时间不会混淆
+		expr += " WHEN julianday(created_at) < julianday(?) THEN ?"
+		args = append(args, seg.until, offsetModifier(seg.offset))
+	}
+	expr += " ELSE ? END"
+	return expr, append(args, offsetModifier(segments[len(segments)-1].offset))
+}
+
+// offsetSegment 时区偏移不变的一段时间，until为该段的结束时间（不含），最后一段不限
+type offsetSegment struct {
+	until  time.Time
+	offset int
+}
+
+// offsetSegments 按时区偏移的变化把[start, end]分段，没有夏令时切换时只有一段
+func offsetSegments(start, end time.Time) []offsetSegment {
+	var segments []offsetSegment
+	offset := zoneOffset(start.Unix())
+	for t := start.Unix(); t < end.Unix(); {
+		next := t + 3600
+		if next > end.Unix() {
+			next = end.Unix()
+		}
+		if zoneOffset(next) != offset {
+			// 二分查找偏移变化的时刻
+			lo, hi := t, next
+			for hi-lo > 1 {
+				mid := lo + (hi-lo)/2
+				if zoneOffset(mid) == offset {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			segments = append(segments, offsetSegment{until: time.Unix(hi, 0), offset: offset})
+			offset = zoneOffset(hi)
+		}
+		t = next
+	}
+	return append(segments, offsetSegment{offset: offset})
+}
+
+// zoneOffset 服务器时区在指定时刻相对UTC的偏移（秒）
+func zoneOffset(unix int64) int {
+	_, offset := time.Unix(unix, 0).In(time.Local).Zone()
+	return offset
+}
+
+func offsetModifier(offset int) string {
+	return fmt.Sprintf("%+d seconds", offset)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/pkg/geoip"
+	"jing_vue_gin_admin/server/pkg/useragent"
+	"log"
+	"net"
+
+	"gorm.io/gorm"
+)
+
+// geoReader IP地理位置库，未配置时为nil
+var geoReader *geoip.Reader
+
+// InitGeoIP 加载离线IP地理位置库，未配置数据库文件时跳过
+func InitGeoIP(cfg config.GeoIPConfig) error {
+	if cfg.Database == "" {
+		return nil
+	}
+	reader, err := geoip.Open(cfg.Database, cfg.Languages...)
+	if err != nil {
+		return err
+	}
+	geoReader = reader
+	log.Printf("IP地理位置库已加载: %s", reader.Metadata().DatabaseType)
+	return nil
+}
+
+// lookupLocation 查询IP的地理位置，内网地址和未收录的地址返回nil
+func lookupLocation(ip string) *geoip.Location {
+	if geoReader == nil {
+		return nil
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsLoopback() || parsed.IsPrivate() {
+		return nil
+	}
+	loc, err := geoReader.Lookup(parsed)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// enrichLog 解析用户代理、查询IP地理位置，并标记来自新国家或新设备的登录
+func enrichLog(entry *model.SystemLog) {
+	if entry.UserAgent != "" {
+		ua := useragent.Parse(entry.UserAgent)
+		// 名称与版本分开保存，浏览器或系统升级后仍能识别为同一设备
+		entry.Browser = ua.Browser
+		entry.BrowserVersion = ua.BrowserVersion
+		entry.OS = ua.OS
+		entry.OSVersion = ua.OSVersion
+		entry.Device = ua.Device
+	}
+	if loc := lookupLocation(entry.IP); loc != nil {
+		entry.Country = loc.Country
+		entry.Region = loc.Region
+		entry.City = loc.City
+	}
+
+	if entry.Module == model.LogModuleAuth && entry.Action == model.LogActionLogin && entry.Status == 1 {
+		flagLogin(entry)
+	}
+}
+
+// flagLogin 与该用户以往的成功登录比较，首次登录不做标记；设备按deviceFingerprint识别，与用户设备列表一致
+func flagLogin(entry *model.SystemLog) {
+	if entry.UserID == 0 {
+		return
+	}
+	history := func() *gorm.DB {
+		return config.DB.Model(&model.SystemLog{}).
+			Where("module = ? AND action = ? AND status = ? AND user_id = ?",
+				model.LogModuleAuth, model.LogActionLogin, 1, entry.UserID)
+	}
+
+	var total int64
+	if err := history().Count(&total).Error; err != nil || total == 0 {
+		return
+	}
+
+	var count int64
+	if entry.Country != "" {
+		history().Where("country = ?", entry.Country).Count(&count)
+		entry.NewCountry = count == 0
+	}
+	if entry.Browser != "" || entry.OS != "" {
+		config.DB.Model(&model.UserDevice{}).
+			Where("user_id = ? AND fingerprint = ?", entry.UserID, deviceFingerprint(entry)).Count(&count)
+		entry.NewDevice = count == 0
+	}
+}
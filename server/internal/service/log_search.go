@@ -0,0 +1,231 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"log"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// 全文检索实现方式，也是log_search配置的取值
+const (
+	logSearchLike = "like" // LIKE模糊匹配
+	logSearchFTS5 = "fts5" // SQLite FTS5虚拟表，需要以 -tags sqlite_fts5 编译
+)
+
+// logSearchMode 当前使用的全文检索实现，由InitLogSearch根据配置和SQLite编译选项决定
+var logSearchMode = logSearchLike
+
+// logSearchColumns 参与全文检索的字段
+var logSearchColumns = []string{"detail", "resource", "user_agent", "ip"}
+
+// maxSearchTerms 单次检索最多使用的关键词数量
+const maxSearchTerms = 10
+
+// minTrigramLen FTS5 trigram分词器能匹配的最短关键词长度，更短的关键词改用LIKE
+const minTrigramLen = 3
+
+// InitLogSearch 初始化日志全文检索索引。mode为fts5时必须使用FTS5，未以 -tags sqlite_fts5 编译时返回错误；
+// 为like时只用LIKE匹配；为空时尽量使用FTS5，不可用时退回LIKE匹配并输出警告
+func InitLogSearch(mode string) error {
+	switch mode {
+	case logSearchLike:
+		return nil
+	case "", logSearchFTS5:
+	default:
+		return fmt.Errorf("不支持的全文检索方式: %s", mode)
+	}
+
+	if err := initSQLiteLogSearch(config.DB); err != nil {
+		if mode == logSearchFTS5 {
+			return fmt.Errorf("FTS5不可用，请以 go build -tags sqlite_fts5 编译: %w", err)
+		}
+		log.Printf("警告: FTS5不可用（未以 -tags sqlite_fts5 编译？），日志全文检索使用LIKE匹配: %v", err)
+	}
+	return nil
+}
+
+// initSQLiteLogSearch 创建FTS5外部内容表及同步触发器，触发器首次创建时重建索引
+func initSQLiteLogSearch(db *gorm.DB) error {
+	var triggers int64
+	db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'system_logs_fts_%'").Scan(&triggers)
+
+	err := db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS system_logs_fts USING fts5(" +
+		"detail, resource, user_agent, ip, content='system_logs', content_rowid='id', tokenize='trigram')").Error
+	if err == nil {
+		// 虚拟表已存在时CREATE不会报错，需要实际查询一次确认FTS5可用
+		err = db.Exec("SELECT rowid FROM system_logs_fts LIMIT 0").Error
+	}
+	if err != nil {
+		// 未编译FTS5时需要删除旧触发器，否则写入日志会因找不到虚拟表而失败
+		for _, name := range []string{"ai", "ad", "au"} {
+			db.Exec("DROP TRIGGER IF EXISTS system_logs_fts_" + name)
+		}
+		return err
+	}
+
+	statements := []string{
+		`CREATE TRIGGER IF NOT EXISTS system_logs_fts_ai AFTER INSERT ON system_logs BEGIN
+			INSERT INTO system_logs_fts(rowid, detail, resource, user_agent, ip)
+			VALUES (new.id, new.detail, new.resource, new.user_agent, new.ip);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS system_logs_fts_ad AFTER DELETE ON system_logs BEGIN
+			INSERT INTO system_logs_fts(system_logs_fts, rowid, detail, resource, user_agent, ip)
+			VALUES ('delete', old.id, old.detail, old.resource, old.user_agent, old.ip);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS system_logs_fts_au AFTER UPDATE ON system_logs BEGIN
+			INSERT INTO system_logs_fts(system_logs_fts, rowid, detail, resource, user_agent, ip)
+			VALUES ('delete', old.id, old.detail, old.resource, old.user_agent, old.ip);
+			INSERT INTO system_logs_fts(rowid, detail, resource, user_agent, ip)
+			VALUES (new.id, new.detail, new.resource, new.user_agent, new.ip);
+		END`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	// 触发器缺失期间写入的日志不在索引中，需要重建
+	if triggers < 3 {
+		if err := db.Exec("INSERT INTO system_logs_fts(system_logs_fts) VALUES ('rebuild')").Error; err != nil {
+			return err
+		}
+	}
+
+	logSearchMode = logSearchFTS5
+	return nil
+}
+
+// parseSearchTerms 将检索词拆分为关键词，双引号包围的部分作为一个短语
+func parseSearchTerms(keyword string) []string {
+	var terms []string
+	for i, part := range strings.Split(keyword, `"`) {
+		// 奇数段位于引号内，是短语
+		if i%2 == 1 {
+			if phrase := strings.TrimSpace(part); phrase != "" {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+		terms = append(terms, strings.Fields(part)...)
+	}
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	return terms
+}
+
+// applyLogSearch 为查询追加全文检索条件，多个关键词之间为“与”关系
+func applyLogSearch(db *gorm.DB, keyword string) *gorm.DB {
+	terms := parseSearchTerms(keyword)
+	if len(terms) == 0 {
+		return db
+	}
+
+	switch logSearchMode {
+	case logSearchFTS5:
+		var ftsTerms []string
+		for _, term := range terms {
+			if utf8.RuneCountInString(term) < minTrigramLen {
+				db = likeSearch(db, term)
+				continue
+			}
+			ftsTerms = append(ftsTerms, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+		}
+		if len(ftsTerms) > 0 {
+			db = db.Where("id IN (SELECT rowid FROM system_logs_fts WHERE system_logs_fts MATCH ?)",
+				strings.Join(ftsTerms, " "))
+		}
+	default:
+		for _, term := range terms {
+			db = likeSearch(db, term)
+		}
+	}
+	return db
+}
+
+// likeSearch 任一检索字段包含关键词即匹配
+func likeSearch(db *gorm.DB, term string) *gorm.DB {
+	pattern := "%" + escapeLike(term) + "%"
+	conditions := make([]string, 0, len(logSearchColumns))
+	args := make([]interface{}, 0, len(logSearchColumns))
+	for _, column := range logSearchColumns {
+		conditions = append(conditions, column+` LIKE ? ESCAPE '\'`)
+		args = append(args, pattern)
+	}
+	return db.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// matchLogKeyword 在内存中判断日志是否包含全部关键词，用于实时日志流
+func matchLogKeyword(keyword string, entry *model.SystemLog) bool {
+	terms := parseSearchTerms(keyword)
+	if len(terms) == 0 {
+		return true
+	}
+	text := strings.ToLower(strings.Join([]string{entry.Detail, entry.Resource, entry.UserAgent, entry.IP}, "\n"))
+	for _, term := range terms {
+		if !strings.Contains(text, strings.ToLower(term)) {
+			return false
+		}
+	}
+	return true
+}
+
+// highlightLogs 为检索结果生成高亮片段，匹配部分以<mark>包围，其余内容做HTML转义
+func highlightLogs(logs []model.SystemLog, keyword string) {
+	terms := parseSearchTerms(keyword)
+	if len(terms) == 0 {
+		return
+	}
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+
+	for i := range logs {
+		fields := map[string]string{
+			"detail":     logs[i].Detail,
+			"resource":   logs[i].Resource,
+			"user_agent": logs[i].UserAgent,
+			"ip":         logs[i].IP,
+		}
+		for name, value := range fields {
+			if marked, ok := highlight(re, value); ok {
+				if logs[i].Highlight == nil {
+					logs[i].Highlight = make(map[string]string)
+				}
+				logs[i].Highlight[name] = marked
+			}
+		}
+	}
+}
+
+func highlight(re *regexp.Regexp, value string) (string, bool) {
+	matches := re.FindAllStringIndex(value, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(html.EscapeString(value[last:m[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(value[m[0]:m[1]]))
+		b.WriteString("</mark>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(value[last:]))
+	return b.String(), true
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/pkg/jwt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogSubscription 日志订阅，C在有满足条件的新日志写入时收到通知，多条日志可能合并为一次通知，服务关闭时C被关闭；
+// 通知只表示有新日志，订阅者应从数据库读取ID大于已发送游标的日志，不依赖通知的先后顺序
+type LogSubscription struct {
+	C <-chan struct{}
+
+	ch     chan struct{}
+	filter model.LogFilter
+}
+
+// Close 取消订阅
+func (sub *LogSubscription) Close() {
+	logHub.unsubscribe(sub)
+}
+
+// hub 在新日志写入后通知所有订阅者
+type hub struct {
+	mu          sync.RWMutex
+	subscribers map[*LogSubscription]struct{}
+	closed      bool
+}
+
+var logHub = &hub{subscribers: make(map[*LogSubscription]struct{})}
+
+func (h *hub) subscribe(filter model.LogFilter) *LogSubscription {
+	ch := make(chan struct{}, 1)
+	sub := &LogSubscription{C: ch, ch: ch, filter: filter}
+
+	h.mu.Lock()
+	if h.closed {
+		close(ch)
+	} else {
+		h.subscribers[sub] = struct{}{}
+	}
+	h.mu.Unlock()
+	return sub
+}
+
+// close 关闭全部订阅的通知通道，之后的订阅立即关闭
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subscribers {
+		close(sub.ch)
+		delete(h.subscribers, sub)
+	}
+}
+
+func (h *hub) unsubscribe(sub *LogSubscription) {
+	h.mu.Lock()
+	delete(h.subscribers, sub)
+	h.mu.Unlock()
+}
+
+func (h *hub) publish(log *model.SystemLog) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subscribers {
+		if !matchLogFilter(&sub.filter, log) {
+			continue
+		}
+		// 已有未处理的通知时合并
+		select {
+		case sub.ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// CloseLogStreams 服务关闭时结束全部实时日志流
+func CloseLogStreams() {
+	logHub.close()
+}
+
+// SubscribeLogs 订阅新写入日志的通知
+func (s *LogService) SubscribeLogs(filter model.LogFilter) *LogSubscription {
+	return logHub.subscribe(filter)
+}
+
+const (
+	// LogStreamPath 实时日志流的路由路径，流令牌只能用于该接口
+	LogStreamPath = "/api/logs/stream"
+	// logStreamTokenTTL 流令牌的有效期，只需覆盖建立连接，连接建立后不再校验
+	logStreamTokenTTL = 5 * time.Minute
+)
+
+// IssueStreamToken 为当前会话签发实时日志流令牌，EventSource不能设置Authorization请求头，
+// 通过access_token查询参数传递；会话注销后令牌随之失效
+func (s *LogService) IssueStreamToken(sessionID string, userID uint, username, role string) (string, time.Time, error) {
+	return jwt.GenerateURLToken(sessionID, userID, username, role, LogStreamPath, logStreamTokenTTL)
+}
+
+// LatestLogID 当前最新日志的ID，没有日志时为0
+func (s *LogService) LatestLogID() (uint, error) {
+	var id uint
+	err := config.DB.Model(&model.SystemLog{}).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return id, err
+}
+
+// GetLogsAfter 获取ID大于游标的日志，按ID升序返回，用于断线重连补发
+func (s *LogService) GetLogsAfter(filter *model.LogFilter, afterID uint, limit int) ([]model.SystemLog, error) {
+	var logs []model.SystemLog
+	db := applyLogFilter(config.DB.Model(&model.SystemLog{}), filter)
+	if err := db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// matchLogFilter 判断日志是否满足筛选条件，与applyLogFilter的语义保持一致（SQLite的LIKE对ASCII字母不区分大小写）
+func matchLogFilter(filter *model.LogFilter, log *model.SystemLog) bool {
+	if filter.Module != "" && log.Module != filter.Module {
+		return false
+	}
+	if filter.Action != "" && log.Action != filter.Action {
+		return false
+	}
+	if filter.Username != "" && !containsFold(log.Username, filter.Username) {
+		return false
+	}
+	if filter.Status != nil && log.Status != *filter.Status {
+		return false
+	}
+	if filter.RequestID != "" && log.RequestID != filter.RequestID {
+		return false
+	}
+	if filter.Method != "" && log.Method != strings.ToUpper(filter.Method) {
+		return false
+	}
+	if filter.StatusCode != 0 && log.StatusCode != filter.StatusCode {
+		return false
+	}
+	if filter.MinDuration != nil && log.Duration < *filter.MinDuration {
+		return false
+	}
+	if filter.MaxDuration != nil && log.Duration > *filter.MaxDuration {
+		return false
+	}
+	if filter.Country != "" && log.Country != filter.Country {
+		return false
+	}
+	if filter.Device != "" && log.Device != filter.Device {
+		return false
+	}
+	if filter.Flagged && !log.NewCountry && !log.NewDevice {
+		return false
+	}
+	if filter.Keyword != "" && !matchLogKeyword(filter.Keyword, log) {
+		return false
+	}
+	if filter.StartTime != "" {
+		startTime, err := time.Parse("2006-01-02 15:04:05", filter.StartTime)
+		if err == nil && log.CreatedAt.Before(startTime) {
+			return false
+		}
+	}
+	if filter.EndTime != "" {
+		endTime, err := time.Parse("2006-01-02 15:04:05", filter.EndTime)
+		if err == nil && log.CreatedAt.After(endTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold 不区分大小写的子串匹配，对应SQL中的LIKE
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
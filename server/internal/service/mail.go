@@ -0,0 +1,106 @@
+package service
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// errSMTPNotConfigured 未配置SMTP服务器
+var errSMTPNotConfigured = errors.New("未配置SMTP服务器")
+
+// mailTimeout 连接SMTP服务器和发送一封邮件的总时限，服务器无响应时不会一直等待
+var mailTimeout = 30 * time.Second
+
+// sendMail 发送纯文本邮件，服务器支持时自动启用STARTTLS
+func sendMail(to []string, subject string, body string) error {
+	cfg := config.App.SMTP
+	if cfg.Host == "" {
+		return errSMTPNotConfigured
+	}
+	if len(to) == 0 {
+		return nil
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 25
+	}
+	from := cfg.From
+	if from == "" {
+		from = cfg.Username
+	}
+
+	var msg strings.Builder
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	addr := fmt.Sprintf("%s:%d", cfg.Host, port)
+	return deliverMail(addr, cfg.Host, auth, from, to, []byte(msg.String()))
+}
+
+// deliverMail 与smtp.SendMail的流程相同，但连接和每次读写都受mailTimeout限制
+func deliverMail(addr, host string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	conn, err := (&net.Dialer{Timeout: mailTimeout}).Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(mailTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("SMTP服务器不支持身份验证")
+		}
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"jing_vue_gin_admin/server/config"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSendMailTimeout(t *testing.T) {
+	// 接受连接后不发送问候语的SMTP服务器
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	oldTimeout, oldSMTP := mailTimeout, config.App.SMTP
+	defer func() { mailTimeout, config.App.SMTP = oldTimeout, oldSMTP }()
+	mailTimeout = 100 * time.Millisecond
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	config.App.SMTP.Host = host
+	config.App.SMTP.Port, _ = strconv.Atoi(port)
+
+	done := make(chan error, 1)
+	go func() { done <- sendMail([]string{"a@example.com"}, "subject", "body") }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("sendMail to a silent server should fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sendMail did not time out")
+	}
+}
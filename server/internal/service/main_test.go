@@ -0,0 +1,46 @@
+package service
+
+import (
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain 使用临时数据库运行服务测试
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "service-test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	code := func() int {
+		defer os.RemoveAll(dir)
+		if err := config.OpenDB(filepath.Join(dir, "test.db")); err != nil {
+			log.Fatal(err)
+		}
+		return m.Run()
+	}()
+	os.Exit(code)
+}
+
+// createTestUser 创建测试用户
+func createTestUser(t *testing.T, username, role, department string) uint {
+	t.Helper()
+	user := model.User{Username: username, Role: role, Department: department}
+	if err := config.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+// createTestRecord 创建只有记录、没有存储内容的文件
+func createTestRecord(t *testing.T, name string, folderID, uploadedBy uint) model.File {
+	t.Helper()
+	file := model.File{FileName: name, FolderID: folderID, UploadedBy: uploadedBy}
+	if err := config.DB.Create(&file).Error; err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
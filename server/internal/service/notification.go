@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NotificationService 站内通知服务
+type NotificationService struct{}
+
+// NewNotificationService 创建站内通知服务
+func NewNotificationService() *NotificationService {
+	return &NotificationService{}
+}
+
+// Notify 向指定用户发送站内通知
+func (s *NotificationService) Notify(userIDs []uint, source, level, title, content string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	notifications := make([]model.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		notifications = append(notifications, model.Notification{
+			UserID:  userID,
+			Source:  source,
+			Level:   level,
+			Title:   title,
+			Content: truncate(content, 500),
+		})
+	}
+	return config.DB.Create(&notifications).Error
+}
+
+// GetNotifications 获取用户的通知列表
+func (s *NotificationService) GetNotifications(userID uint, req *model.NotificationListRequest) (map[string]interface{}, error) {
+	var total int64
+	var list []model.Notification
+
+	db := config.DB.Model(&model.Notification{}).Where("user_id = ?", userID)
+	if req.Unread {
+		db = db.Where("is_read = ?", false)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"total": total,
+		"list":  list,
+	}, nil
+}
+
+// GetUnreadCount 获取用户的未读通知数
+func (s *NotificationService) GetUnreadCount(userID uint) (int64, error) {
+	var count int64
+	err := config.DB.Model(&model.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead 将通知标记为已读，只能操作自己的通知
+func (s *NotificationService) MarkRead(userID uint, id uint) error {
+	result := config.DB.Model(&model.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("通知不存在")
+	}
+	return nil
+}
+
+// MarkAllRead 将用户的全部通知标记为已读
+func (s *NotificationService) MarkAllRead(userID uint) error {
+	return config.DB.Model(&model.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()}).Error
+}
+
+// usersWithPermission 查找所属角色拥有指定权限且处于启用状态的用户
+func usersWithPermission(permission string) ([]uint, error) {
+	var roles []model.Role
+	if err := config.DB.Where("status = ?", 1).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	var roleNames []string
+	for _, role := range roles {
+		for _, perm := range role.Permissions {
+			if perm == permission {
+				roleNames = append(roleNames, role.Name)
+				break
+			}
+		}
+	}
+	if len(roleNames) == 0 {
+		return nil, nil
+	}
+
+	var userIDs []uint
+	err := config.DB.Model(&model.User{}).
+		Where("role IN ? AND status = ?", roleNames, 1).
+		Pluck("id", &userIDs).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return userIDs, err
+}
+
+// userHasPermission 用户所属角色处于启用状态且拥有指定权限
+func userHasPermission(userID uint, permission string) bool {
+	var user model.User
+	if config.DB.Select("role").Limit(1).Find(&user, userID).RowsAffected == 0 {
+		return false
+	}
+	var role model.Role
+	if config.DB.Where("name = ? AND status = ?", user.Role, 1).Limit(1).Find(&role).RowsAffected == 0 {
+		return false
+	}
+	for _, perm := range role.Permissions {
+		if perm == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
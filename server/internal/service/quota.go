@@ -0,0 +1,324 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"net/http"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 超出配额的错误码
+const (
+	UploadErrQuotaBytes = "QUOTA_BYTES_EXCEEDED" // 超出存储空间配额
+	UploadErrQuotaFiles = "QUOTA_FILES_EXCEEDED" // 超出文件数量配额
+)
+
+// QuotaService 存储配额服务
+type QuotaService struct{}
+
+// NewQuotaService 创建存储配额服务实例
+func NewQuotaService() *QuotaService {
+	return &QuotaService{}
+}
+
+// GetQuotas 获取全部配额设置
+func (s *QuotaService) GetQuotas() ([]model.Quota, error) {
+	var quotas []model.Quota
+	if err := config.DB.Order("user_id ASC, role ASC").Find(&quotas).Error; err != nil {
+		return nil, fmt.Errorf("获取配额列表失败: %w", err)
+	}
+	return quotas, nil
+}
+
+// SetQuota 设置角色或用户的配额，已存在时更新
+func (s *QuotaService) SetQuota(req *model.QuotaRequest) (*model.Quota, error) {
+	if (req.Role == "") == (req.UserID == 0) {
+		return nil, errors.New("角色和用户必须且只能指定一个")
+	}
+
+	if req.UserID != 0 {
+		if err := config.DB.Select("id").First(&model.User{}, req.UserID).Error; err != nil {
+			return nil, errors.New("用户不存在")
+		}
+	} else if err := config.DB.Where("name = ?", req.Role).First(&model.Role{}).Error; err != nil {
+		return nil, errors.New("角色不存在")
+	}
+
+	var quota model.Quota
+	err := config.DB.Where("role = ? AND user_id = ?", req.Role, req.UserID).First(&quota).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("获取配额失败: %w", err)
+	}
+	quota.Role = req.Role
+	quota.UserID = req.UserID
+	quota.MaxBytes = req.MaxBytes
+	quota.MaxFiles = req.MaxFiles
+	if err := config.DB.Save(&quota).Error; err != nil {
+		return nil, fmt.Errorf("保存配额失败: %w", err)
+	}
+	return &quota, nil
+}
+
+// DeleteQuota 删除配额设置，用户配额删除后恢复使用角色配额
+func (s *QuotaService) DeleteQuota(id uint) error {
+	result := config.DB.Unscoped().Delete(&model.Quota{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("删除配额失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("配额不存在")
+	}
+	return nil
+}
+
+// GetUsage 获取用户的存储用量和生效的配额
+func (s *QuotaService) GetUsage(userID uint) (*model.QuotaUsage, error) {
+	var user model.User
+	if err := config.DB.Select("id", "role").First(&user, userID).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	totals, err := usageTotals(config.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	used := totals[userID]
+	usage := &model.QuotaUsage{UserID: userID, UsedBytes: used.Bytes, UsedFiles: used.Files}
+	if quota, source := effectiveQuota(config.DB, userID, user.Role); quota != nil {
+		usage.MaxBytes, usage.MaxFiles, usage.Source = quota.MaxBytes, quota.MaxFiles, source
+	}
+	fillPercent(usage)
+	return usage, nil
+}
+
+// GetReport 列出用量达到配额指定比例（0~1，默认0.8）的用户，按用量比例从高到低排序
+func (s *QuotaService) GetReport(threshold float64) ([]model.QuotaReportItem, error) {
+	if threshold <= 0 {
+		threshold = 0.8
+	}
+
+	var quotas []model.Quota
+	if err := config.DB.Find(&quotas).Error; err != nil {
+		return nil, fmt.Errorf("获取配额列表失败: %w", err)
+	}
+	if len(quotas) == 0 {
+		return []model.QuotaReportItem{}, nil
+	}
+	roleQuotas := map[string]*model.Quota{}
+	userQuotas := map[uint]*model.Quota{}
+	for i := range quotas {
+		if quotas[i].UserID != 0 {
+			userQuotas[quotas[i].UserID] = &quotas[i]
+		} else {
+			roleQuotas[quotas[i].Role] = &quotas[i]
+		}
+	}
+
+	totals, err := usageTotals(config.DB, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []model.User
+	if err := config.DB.Select("id", "username", "nickname", "role").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("获取用户列表失败: %w", err)
+	}
+
+	items := []model.QuotaReportItem{}
+	for _, user := range users {
+		quota, source := userQuotas[user.ID], model.QuotaSourceUser
+		if quota == nil {
+			quota, source = roleQuotas[user.Role], model.QuotaSourceRole
+		}
+		if quota == nil {
+			continue
+		}
+
+		item := model.QuotaReportItem{Username: user.Username, Nickname: user.Nickname, Role: user.Role}
+		item.UserID = user.ID
+		item.MaxBytes, item.MaxFiles, item.Source = quota.MaxBytes, quota.MaxFiles, source
+		used := totals[user.ID]
+		item.UsedBytes, item.UsedFiles = used.Bytes, used.Files
+		fillPercent(&item.QuotaUsage)
+		if item.BytesPercent >= threshold*100 || item.FilesPercent >= threshold*100 {
+			items = append(items, item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return maxPercent(&items[i].QuotaUsage) > maxPercent(&items[j].QuotaUsage)
+	})
+	return items, nil
+}
+
+// checkQuota 校验用户的用量再增加size字节、files个文件后是否超出配额，
+// includePending为true时把尚未完成的断点续传任务也计入用量，避免同时创建多个任务绕过配额；
+// 只用于在接收内容前提前拒绝，写入记录时需通过withQuota再次校验
+func checkQuota(userID uint, size, files int64, includePending bool) error {
+	return checkQuotaIn(config.DB, userID, size, files, includePending)
+}
+
+// withQuota 在持有写锁的同一事务中校验配额并执行write，并发的上传、复制和恢复依次执行，
+// 不会各自通过校验后合计超出配额；超出配额或write返回错误时事务回滚
+func withQuota(userID uint, size, files int64, includePending bool, write func(tx *gorm.DB) error) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockForWrite(tx); err != nil {
+			return err
+		}
+		if err := checkQuotaIn(tx, userID, size, files, includePending); err != nil {
+			return err
+		}
+		return write(tx)
+	})
+}
+
+// lockForWrite 在事务开始时获取写锁：SQLite的事务在第一次写入时才获取写锁，
+// 两个事务都先读取再写入会互相等待而失败，先执行一条不修改数据的更新，其他写入在此期间等待
+func lockForWrite(tx *gorm.DB) error {
+	return tx.Model(&model.Quota{}).Where("1 = 0").Update("max_bytes", 0).Error
+}
+
+// checkQuotaIn 在db上执行配额校验，规则与checkQuota相同
+func checkQuotaIn(db *gorm.DB, userID uint, size, files int64, includePending bool) error {
+	if userID == 0 {
+		return nil
+	}
+	var user model.User
+	if db.Select("id", "role").Limit(1).Find(&user, userID).RowsAffected == 0 {
+		return nil
+	}
+	quota, _ := effectiveQuota(db, userID, user.Role)
+	if quota == nil || (quota.MaxBytes == 0 && quota.MaxFiles == 0) {
+		return nil
+	}
+
+	totals, err := usageTotals(db, userID)
+	if err != nil {
+		return err
+	}
+	count, used := totals[userID].Files, totals[userID].Bytes
+	if includePending {
+		var pending struct {
+			Count int64
+			Size  int64
+		}
+		err := db.Model(&model.Upload{}).
+			Select("COUNT(*) as count, COALESCE(SUM(length), 0) as size").
+			Where("user_id = ? AND file_id = 0 AND expires_at > ?", userID, time.Now()).
+			Scan(&pending).Error
+		if err != nil {
+			return fmt.Errorf("获取未完成的上传任务失败: %w", err)
+		}
+		count += pending.Count
+		used += pending.Size
+	}
+	if quota.MaxFiles > 0 && files > 0 && count+files > quota.MaxFiles {
+		return uploadError(UploadErrQuotaFiles, http.StatusRequestEntityTooLarge,
+			"文件数量已达到配额上限%d个", quota.MaxFiles)
+	}
+	if quota.MaxBytes > 0 && size > 0 && used+size > quota.MaxBytes {
+		return uploadError(UploadErrQuotaBytes, http.StatusRequestEntityTooLarge,
+			"存储空间不足：已用%d字节，配额%d字节，本次上传%d字节", used, quota.MaxBytes, size)
+	}
+	return nil
+}
+
+// effectiveQuota 用户单独设置的配额优先，否则使用角色配额
+func effectiveQuota(db *gorm.DB, userID uint, role string) (*model.Quota, string) {
+	var quota model.Quota
+	if db.Where("user_id = ?", userID).Limit(1).Find(&quota).RowsAffected > 0 {
+		return &quota, model.QuotaSourceUser
+	}
+	if role != "" && db.Where("user_id = 0 AND role = ?", role).Limit(1).Find(&quota).RowsAffected > 0 {
+		return &quota, model.QuotaSourceRole
+	}
+	return nil, ""
+}
+
+// usage 用户的文件数和占用空间
+type usage struct {
+	Files int64
+	Bytes int64
+}
+
+// usageTotals 按上传者统计文件数和占用空间，userID不为0时只统计该用户；
+// 回收站中的文件在彻底删除前仍占用存储，计入占用空间，但不计入文件数；历史版本也计入占用空间
+func usageTotals(db *gorm.DB, userID uint) (map[uint]usage, error) {
+	var rows []struct {
+		UploadedBy uint
+		Count      int64
+		Size       int64
+	}
+	query := db.Unscoped().Model(&model.File{}).
+		Select("uploaded_by, COALESCE(SUM(CASE WHEN trashed = ? THEN 0 ELSE 1 END), 0) as count, "+
+			"COALESCE(SUM(file_size), 0) as size", true).
+		Where("(deleted_at IS NULL OR trashed = ?)", true)
+	if userID != 0 {
+		query = query.Where("uploaded_by = ?", userID)
+	}
+	if err := query.Group("uploaded_by").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("获取用户存储用量失败: %w", err)
+	}
+
+	totals := make(map[uint]usage, len(rows))
+	for _, row := range rows {
+		totals[row.UploadedBy] = usage{Files: row.Count, Bytes: row.Size}
+	}
+
+	// 历史版本保留各自的存储对象，与当前内容不同的对象按文件所有者计入占用空间，同一对象只计一次
+	history := db.Model(&model.FileVersion{}).
+		Select("DISTINCT files.uploaded_by, file_versions.blob_id, file_versions.file_size").
+		Joins("JOIN files ON files.id = file_versions.file_id").
+		Where("(files.deleted_at IS NULL OR files.trashed = ?) AND file_versions.blob_id <> files.blob_id", true)
+	if userID != 0 {
+		history = history.Where("files.uploaded_by = ?", userID)
+	}
+	var versions []struct {
+		UploadedBy uint
+		Size       int64
+	}
+	if err := db.Table("(?) AS history", history).
+		Select("uploaded_by, COALESCE(SUM(file_size), 0) as size").
+		Group("uploaded_by").Scan(&versions).Error; err != nil {
+		return nil, fmt.Errorf("获取历史版本用量失败: %w", err)
+	}
+	for _, v := range versions {
+		total := totals[v.UploadedBy]
+		total.Bytes += v.Size
+		totals[v.UploadedBy] = total
+	}
+	return totals, nil
+}
+
+// fileTotals 统计查询范围内的文件数和总大小
+func fileTotals(query *gorm.DB) (int64, int64, error) {
+	var count, size int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return 0, 0, fmt.Errorf("获取文件总数失败: %w", err)
+	}
+	if err := query.Session(&gorm.Session{}).Select("COALESCE(SUM(file_size), 0)").Scan(&size).Error; err != nil {
+		return 0, 0, fmt.Errorf("获取文件总大小失败: %w", err)
+	}
+	return count, size, nil
+}
+
+func fillPercent(usage *model.QuotaUsage) {
+	if usage.MaxBytes > 0 {
+		usage.BytesPercent = float64(usage.UsedBytes) * 100 / float64(usage.MaxBytes)
+	}
+	if usage.MaxFiles > 0 {
+		usage.FilesPercent = float64(usage.UsedFiles) * 100 / float64(usage.MaxFiles)
+	}
+}
+
+func maxPercent(usage *model.QuotaUsage) float64 {
+	if usage.BytesPercent > usage.FilesPercent {
+		return usage.BytesPercent
+	}
+	return usage.FilesPercent
+}
@@ -84,6 +84,10 @@ func (s *RoleService) GetAllPermissions() map[string][]PermissionInfo {
 			{Value: model.PermissionRoleDelete, Label: "删除角色"},
 			{Value: model.PermissionSystemConfig, Label: "系统配置"},
 			{Value: model.PermissionSystemLog, Label: "系统日志"},
+			{Value: model.PermissionLogDelete, Label: "删除日志"},
+			{Value: model.PermissionLogExport, Label: "导出日志"},
+			{Value: model.PermissionAlertView, Label: "查看告警"},
+			{Value: model.PermissionAlertManage, Label: "管理告警规则"},
 		},
 		"内容管理": {
 			{Value: model.PermissionArticleView, Label: "查看文章"},
@@ -115,6 +119,11 @@ func (s *RoleService) GetAllPermissions() map[string][]PermissionInfo {
 			{Value: model.PermissionFileUpload, Label: "上传文件"},
 			{Value: model.PermissionFileUpdate, Label: "编辑文件"},
 			{Value: model.PermissionFileDelete, Label: "删除文件"},
+			{Value: model.PermissionFileQuota, Label: "管理存储配额"},
+			{Value: model.PermissionFileShare, Label: "分享文件"},
+			{Value: model.PermissionFilePurge, Label: "彻底删除文件"},
+			{Value: model.PermissionFileCheck, Label: "存储一致性检查"},
+			{Value: model.PermissionFileScan, Label: "病毒扫描"},
 		},
 		"数据统计": {
 			{Value: model.PermissionStatView, Label: "查看统计"},
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/scanner"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrFileInfected 文件发现病毒，已隔离
+	ErrFileInfected = errors.New("文件发现病毒，已隔离，不允许下载")
+	// ErrScanPending 文件尚未完成病毒扫描
+	ErrScanPending = errors.New("文件正在进行病毒扫描，请稍后再试")
+	// ErrScanOversize 文件超过扫描大小上限，未经扫描不允许下载
+	ErrScanOversize = errors.New("文件超过病毒扫描大小上限，未经扫描不允许下载")
+	// ErrScanDisabled 未启用病毒扫描
+	ErrScanDisabled = errors.New("未启用病毒扫描")
+)
+
+var (
+	// activeScanner 配置的病毒扫描器，为nil表示不扫描
+	activeScanner scanner.Scanner
+	// scanQueue 等待扫描的文件ID，扫描协程启动后才创建
+	scanQueue chan uint
+	// scanQueued 已在队列中的文件ID，避免重复排队
+	scanQueued sync.Map
+)
+
+// InitScanner 根据配置初始化病毒扫描器，backend为空时不扫描
+func InitScanner(cfg config.ScanConfig) error {
+	switch cfg.Backend {
+	case "":
+		activeScanner = nil
+	case scanner.BackendClamd:
+		clamd, err := scanner.NewClamd(cfg.Address, scanTimeout())
+		if err != nil {
+			return err
+		}
+		activeScanner = clamd
+	case scanner.BackendFake:
+		activeScanner = scanner.NewFake()
+	default:
+		return fmt.Errorf("不支持的病毒扫描器: %s", cfg.Backend)
+	}
+	return nil
+}
+
+// ScanEnabled 是否启用了病毒扫描
+func ScanEnabled() bool {
+	return activeScanner != nil
+}
+
+// scanOversizeReason 超过扫描大小上限时记录的原因
+const scanOversizeReason = "超过扫描大小上限"
+
+// StartScanWorkers 启动扫描协程，并定期把仍在等待扫描或重新扫描的文件重新排队（包括扫描失败待重试和重启前未完成的文件）
+func StartScanWorkers() {
+	if activeScanner == nil {
+		return
+	}
+	// 早期版本把超过大小上限的文件记为未扫描，按原因改为超限状态
+	config.DB.Unscoped().Model(&model.File{}).
+		Where("scan_status = ? AND scan_result = ?", model.ScanStatusUnscanned, scanOversizeReason).
+		UpdateColumn("scan_status", model.ScanStatusOversize)
+
+	workers := config.App.Scan.Workers
+	if workers <= 0 {
+		workers = 2
+	}
+	scanQueue = make(chan uint, 1024)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for id := range scanQueue {
+				scanQueued.Delete(id)
+				scanFile(id)
+			}
+		}()
+	}
+
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for {
+			var ids []uint
+			if err := config.DB.Unscoped().Model(&model.File{}).
+				Where("scan_status = ? OR rescan = ?", model.ScanStatusPending, true).Pluck("id", &ids).Error; err != nil {
+				log.Printf("获取待扫描文件失败: %v", err)
+			}
+			for _, id := range ids {
+				enqueueScan(id)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// initialScanStatus 新内容的扫描状态，启用扫描时在扫描完成前不允许下载
+func initialScanStatus() string {
+	if activeScanner == nil {
+		return model.ScanStatusUnscanned
+	}
+	return model.ScanStatusPending
+}
+
+// enqueueScan 把文件加入扫描队列，队列已满或扫描协程未启动时由定期任务补充排队
+func enqueueScan(fileID uint) {
+	if scanQueue == nil {
+		return
+	}
+	if _, loaded := scanQueued.LoadOrStore(fileID, struct{}{}); loaded {
+		return
+	}
+	select {
+	case scanQueue <- fileID:
+	default:
+		scanQueued.Delete(fileID)
+	}
+}
+
+// scanTimeout 单个文件的扫描超时
+func scanTimeout() time.Duration {
+	if config.App.Scan.Timeout > 0 {
+		return time.Duration(config.App.Scan.Timeout) * time.Second
+	}
+	return 5 * time.Minute
+}
+
+// scanMaxSize 扫描的文件大小上限，-1表示不限制
+func scanMaxSize() int64 {
+	if config.App.Scan.MaxSize == 0 {
+		return 25 << 20
+	}
+	return config.App.Scan.MaxSize
+}
+
+// scanFile 扫描文件的当前内容，结果写入内容相同的所有文件；
+// 扫描失败时保持原状态（等待扫描或重新扫描前的结果）并记录原因，由定期任务重试
+func scanFile(id uint) {
+	var file model.File
+	if config.DB.Unscoped().Limit(1).Find(&file, id).RowsAffected == 0 ||
+		(file.ScanStatus != model.ScanStatusPending && !file.Rescan) {
+		return
+	}
+
+	if max := scanMaxSize(); max > 0 && file.FileSize > max {
+		updateScanResult(&file, model.ScanStatusOversize, scanOversizeReason)
+		return
+	}
+
+	rc, _, err := NewFileService().OpenFile(&file)
+	if err != nil {
+		recordScanError(&file, err)
+		return
+	}
+	defer rc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), scanTimeout())
+	defer cancel()
+	result, err := activeScanner.Scan(ctx, rc)
+	if err != nil {
+		recordScanError(&file, err)
+		return
+	}
+	if !result.Infected {
+		updateScanResult(&file, model.ScanStatusClean, "")
+		return
+	}
+
+	infected := updateScanResult(&file, model.ScanStatusInfected, result.Signature)
+	log.Printf("文件%d（%s）发现病毒%s，已隔离", file.ID, file.FileName, result.Signature)
+	notifyInfected(infected, result.Signature)
+}
+
+// scannedFiles 与file内容相同的文件，内容已被新版本替换的文件按哈希排除
+func scannedFiles(file *model.File) *gorm.DB {
+	query := config.DB.Unscoped().Model(&model.File{}).Where("hash = ?", file.Hash)
+	if file.BlobID != 0 {
+		return query.Where("blob_id = ? OR id = ?", file.BlobID, file.ID)
+	}
+	return query.Where("id = ?", file.ID)
+}
+
+// updateScanResult 更新内容相同的所有文件的扫描状态，返回更新的文件
+func updateScanResult(file *model.File, status, result string) []model.File {
+	var files []model.File
+	if err := scannedFiles(file).Find(&files).Error; err != nil {
+		log.Printf("获取文件%d的扫描结果失败: %v", file.ID, err)
+		return nil
+	}
+	now := time.Now()
+	err := scannedFiles(file).UpdateColumns(map[string]interface{}{
+		"scan_status": status,
+		"scan_result": truncate(result, 255),
+		"scanned_at":  now,
+		"rescan":      false,
+	}).Error
+	if err != nil {
+		log.Printf("保存文件%d的扫描结果失败: %v", file.ID, err)
+		return nil
+	}
+	return files
+}
+
+// recordScanError 记录扫描失败原因，文件保持等待扫描状态
+func recordScanError(file *model.File, err error) {
+	log.Printf("扫描文件%d失败: %v", file.ID, err)
+	config.DB.Unscoped().Model(&model.File{}).Where("id = ? AND hash = ?", file.ID, file.Hash).
+		UpdateColumn("scan_result", truncate(err.Error(), 255))
+}
+
+// notifyInfected 通知上传者文件发现病毒
+func notifyInfected(files []model.File, signature string) {
+	owners := make(map[uint][]string)
+	for _, f := range files {
+		owners[f.UploadedBy] = append(owners[f.UploadedBy], f.FileName)
+	}
+	for userID, names := range owners {
+		content := fmt.Sprintf("文件%v发现病毒%s，已隔离，不能下载", names, signature)
+		if err := NewNotificationService().Notify([]uint{userID}, model.NotificationSourceScan, "warning", "文件发现病毒", content); err != nil {
+			log.Printf("发送病毒扫描通知失败: %v", err)
+		}
+	}
+}
+
+// CheckScan 检查文件是否允许下载：发现病毒的文件不允许下载，启用扫描时尚未完成扫描的文件也不允许下载，
+// 超过扫描大小上限的文件只有配置了scan.allow_oversize才允许下载；重新扫描期间按原扫描状态判断
+func CheckScan(file *model.File) error {
+	switch file.ScanStatus {
+	case model.ScanStatusInfected:
+		return ErrFileInfected
+	case model.ScanStatusPending:
+		if activeScanner != nil {
+			return ErrScanPending
+		}
+	case model.ScanStatusOversize:
+		if activeScanner != nil && !config.App.Scan.AllowOversize {
+			return ErrScanOversize
+		}
+	}
+	return nil
+}
+
+// CheckHashScan 检查指定内容是否允许下载，用于历史版本：内容相同的文件发现病毒时不允许下载
+func CheckHashScan(hash string) error {
+	if hash == "" {
+		return nil
+	}
+	var count int64
+	config.DB.Unscoped().Model(&model.File{}).
+		Where("hash = ? AND scan_status = ?", hash, model.ScanStatusInfected).Count(&count)
+	if count > 0 {
+		return ErrFileInfected
+	}
+	return nil
+}
+
+// ScanService 病毒扫描服务
+type ScanService struct {
+	fileService *FileService
+}
+
+// NewScanService 创建病毒扫描服务实例
+func NewScanService() *ScanService {
+	return &ScanService{fileService: NewFileService()}
+}
+
+// Rescan 重新扫描文件，扫描完成前保留原扫描状态，已通过扫描的文件仍可下载
+func (s *ScanService) Rescan(fileID uint) (*model.File, error) {
+	if activeScanner == nil {
+		return nil, ErrScanDisabled
+	}
+	file, err := s.fileService.GetFileByID(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.DB.Model(file).UpdateColumn("rescan", true).Error; err != nil {
+		return nil, fmt.Errorf("更新扫描状态失败: %w", err)
+	}
+	file.Rescan = true
+	enqueueScan(file.ID)
+	return file, nil
+}
+
+// RescanAll 重新扫描指定状态的全部文件（包括回收站中的文件），status为空表示全部文件，返回排队的文件数；
+// 扫描完成前保留原扫描状态，病毒库更新后重新扫描不会导致文件暂时无法下载
+func (s *ScanService) RescanAll(status string) (int, error) {
+	if activeScanner == nil {
+		return 0, ErrScanDisabled
+	}
+	files := func() *gorm.DB {
+		query := config.DB.Unscoped().Model(&model.File{}).Where("deleted_at IS NULL OR trashed = ?", true)
+		if status != "" {
+			query = query.Where("scan_status = ?", status)
+		}
+		return query
+	}
+	var ids []uint
+	if err := files().Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("获取文件失败: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := files().UpdateColumn("rescan", true).Error; err != nil {
+		return 0, fmt.Errorf("更新扫描状态失败: %w", err)
+	}
+	for _, id := range ids {
+		enqueueScan(id)
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/pkg/useragent"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// sessionTouchInterval 会话最近活跃时间的更新间隔，避免每个请求都写数据库
+	sessionTouchInterval = time.Minute
+	// sessionCacheTTL 会话校验结果的缓存时间，缓存期内的请求不再查询会话表；
+	// 本进程注销会话时立即清除缓存，多实例部署时其他实例最多延迟该时间生效
+	sessionCacheTTL = 30 * time.Second
+	// loginTrackQueueSize 等待维护用户设备的登录日志数
+	loginTrackQueueSize = 256
+)
+
+// cachedSession 已校验通过的会话
+type cachedSession struct {
+	userID    uint
+	expiresAt time.Time
+	checkedAt time.Time
+}
+
+var (
+	// sessionCache 会话ID到cachedSession的缓存
+	sessionCache sync.Map
+	// sessionCachePruned 上次清理过期缓存的时间
+	sessionCachePruned time.Time
+	sessionCacheMu     sync.Mutex
+)
+
+// SecurityService 账号安全服务：登录记录、会话和设备
+type SecurityService struct{}
+
+// NewSecurityService 创建账号安全服务
+func NewSecurityService() *SecurityService {
+	return &SecurityService{}
+}
+
+// newSessionID 生成随机会话ID
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateSession 登录成功后记录会话
+func (s *SecurityService) CreateSession(userID uint, sessionID, ip, userAgent string, expiresAt time.Time) error {
+	ua := useragent.Parse(userAgent)
+	now := time.Now()
+	session := &model.UserSession{
+		UserID:       userID,
+		SessionID:    sessionID,
+		IP:           ip,
+		UserAgent:    truncate(userAgent, 255),
+		Browser:      ua.BrowserString(),
+		OS:           ua.OSString(),
+		Device:       ua.Device,
+		Location:     locationString(ip),
+		LastActiveAt: now,
+		ExpiresAt:    expiresAt,
+	}
+	return config.DB.Create(session).Error
+}
+
+// ValidateSession 校验会话是否有效，并定期刷新最近活跃时间
+func (s *SecurityService) ValidateSession(userID uint, sessionID string) error {
+	if sessionID == "" {
+		return errors.New("会话不存在")
+	}
+	now := time.Now()
+	if v, ok := sessionCache.Load(sessionID); ok {
+		cached := v.(cachedSession)
+		if cached.userID == userID && now.Sub(cached.checkedAt) < sessionCacheTTL && now.Before(cached.expiresAt) {
+			return nil
+		}
+		sessionCache.Delete(sessionID)
+	}
+
+	var session model.UserSession
+	if err := config.DB.Where("session_id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		return errors.New("会话不存在")
+	}
+	if session.RevokedAt != nil {
+		return errors.New("会话已注销")
+	}
+	if now.After(session.ExpiresAt) {
+		return errors.New("会话已过期")
+	}
+	if now.Sub(session.LastActiveAt) > sessionTouchInterval {
+		config.DB.Model(&session).UpdateColumn("last_active_at", now)
+	}
+	sessionCache.Store(sessionID, cachedSession{userID: userID, expiresAt: session.ExpiresAt, checkedAt: now})
+	pruneSessionCache(now)
+	return nil
+}
+
+// pruneSessionCache 定期清理过期的会话缓存，避免不再使用的会话一直占用内存
+func pruneSessionCache(now time.Time) {
+	sessionCacheMu.Lock()
+	if now.Sub(sessionCachePruned) < sessionCacheTTL {
+		sessionCacheMu.Unlock()
+		return
+	}
+	sessionCachePruned = now
+	sessionCacheMu.Unlock()
+
+	sessionCache.Range(func(key, value interface{}) bool {
+		if now.Sub(value.(cachedSession).checkedAt) >= sessionCacheTTL {
+			sessionCache.Delete(key)
+		}
+		return true
+	})
+}
+
+// forgetUserSessions 清除用户全部会话的缓存，注销会话后调用
+func forgetUserSessions(userID uint) {
+	sessionCache.Range(func(key, value interface{}) bool {
+		if value.(cachedSession).userID == userID {
+			sessionCache.Delete(key)
+		}
+		return true
+	})
+}
+
+// GetLoginHistory 获取用户的登录记录，失败记录按用户名关联
+func (s *SecurityService) GetLoginHistory(userID uint, req *model.LoginHistoryRequest) (*model.LogListResponse, error) {
+	var user model.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+
+	db := config.DB.Model(&model.SystemLog{}).
+		Where("module = ? AND action = ?", model.LogModuleAuth, model.LogActionLogin).
+		Where("user_id = ? OR (user_id = 0 AND username = ?)", user.ID, user.Username)
+	if req.Status != nil {
+		db = db.Where("status = ?", *req.Status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var logs []model.SystemLog
+	offset := (req.Page - 1) * req.PageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(req.PageSize).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	return &model.LogListResponse{
+		Total: total,
+		List:  logs,
+	}, nil
+}
+
+// GetSessions 获取用户未过期且未注销的会话，标记当前会话
+func (s *SecurityService) GetSessions(userID uint, currentSessionID string) ([]model.UserSession, error) {
+	var sessions []model.UserSession
+	err := config.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_active_at DESC").Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession 注销用户的指定会话
+func (s *SecurityService) RevokeSession(userID, id uint) error {
+	result := config.DB.Model(&model.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("会话不存在")
+	}
+	forgetUserSessions(userID)
+	return nil
+}
+
+// RevokeOtherSessions 注销当前会话以外的全部会话
+func (s *SecurityService) RevokeOtherSessions(userID uint, currentSessionID string) (int64, error) {
+	result := config.DB.Model(&model.UserSession{}).
+		Where("user_id = ? AND session_id <> ? AND revoked_at IS NULL", userID, currentSessionID).
+		Update("revoked_at", time.Now())
+	forgetUserSessions(userID)
+	return result.RowsAffected, result.Error
+}
+
+// RevokeCurrentSession 注销当前会话（退出登录）
+func (s *SecurityService) RevokeCurrentSession(userID uint, sessionID string) error {
+	err := config.DB.Model(&model.UserSession{}).
+		Where("user_id = ? AND session_id = ? AND revoked_at IS NULL", userID, sessionID).
+		Update("revoked_at", time.Now()).Error
+	sessionCache.Delete(sessionID)
+	return err
+}
+
+// GetDevices 获取用户登录过的设备
+func (s *SecurityService) GetDevices(userID uint) ([]model.UserDevice, error) {
+	var devices []model.UserDevice
+	if err := config.DB.Where("user_id = ?", userID).Order("last_login_at DESC").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// DeleteDevice 移除设备记录，下次从该设备登录时会重新提醒
+func (s *SecurityService) DeleteDevice(userID, id uint) error {
+	result := config.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&model.UserDevice{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("设备不存在")
+	}
+	return nil
+}
+
+// loginTracker 在独立协程中按顺序处理登录日志，不阻塞登录请求，同一设备的并发登录也不会重复创建设备记录
+var loginTracker = struct {
+	queue chan model.SystemLog
+	once  sync.Once
+}{queue: make(chan model.SystemLog, loginTrackQueueSize)}
+
+// StartLoginTracker 开始根据登录成功记录维护用户设备，从未使用过的设备或IP登录时提醒用户
+func StartLoginTracker() {
+	loginTracker.once.Do(func() {
+		AddLogHook(enqueueLogin)
+		go func() {
+			for entry := range loginTracker.queue {
+				trackLogin(&entry)
+			}
+		}()
+	})
+}
+
+// enqueueLogin 日志钩子：登录成功记录加入处理队列
+func enqueueLogin(entry *model.SystemLog) {
+	if entry.Module != model.LogModuleAuth || entry.Action != model.LogActionLogin ||
+		entry.Status != 1 || entry.UserID == 0 {
+		return
+	}
+	select {
+	case loginTracker.queue <- *entry:
+	default:
+		log.Printf("登录处理队列已满，跳过日志%d", entry.ID)
+	}
+}
+
+// trackLogin 维护用户设备，从未使用过的设备或IP登录时提醒用户
+func trackLogin(entry *model.SystemLog) {
+
+	fingerprint := deviceFingerprint(entry)
+	location := joinLocation(entry.Country, entry.Region, entry.City)
+
+	var device model.UserDevice
+	err := config.DB.Where("user_id = ? AND fingerprint = ?", entry.UserID, fingerprint).First(&device).Error
+	newDevice := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !newDevice {
+		log.Printf("查询用户设备失败: %v", err)
+		return
+	}
+
+	device.UserID = entry.UserID
+	device.Fingerprint = fingerprint
+	device.Browser = entry.Browser
+	device.OS = entry.OS
+	device.Device = entry.Device
+	device.LastIP = entry.IP
+	device.Location = location
+	device.LoginCount++
+	device.LastLoginAt = entry.CreatedAt
+	if err := config.DB.Save(&device).Error; err != nil {
+		log.Printf("保存用户设备失败: %v", err)
+		return
+	}
+
+	// 首次登录没有可比较的历史，不提醒
+	var previous int64
+	config.DB.Model(&model.SystemLog{}).
+		Where("module = ? AND action = ? AND status = ? AND user_id = ? AND id <> ?",
+			model.LogModuleAuth, model.LogActionLogin, 1, entry.UserID, entry.ID).
+		Count(&previous)
+	if previous == 0 {
+		return
+	}
+
+	var sameIP int64
+	config.DB.Model(&model.SystemLog{}).
+		Where("module = ? AND action = ? AND status = ? AND user_id = ? AND ip = ? AND id <> ?",
+			model.LogModuleAuth, model.LogActionLogin, 1, entry.UserID, entry.IP, entry.ID).
+		Count(&sameIP)
+	newIP := sameIP == 0
+
+	if !newDevice && !newIP {
+		return
+	}
+	notifyNewLogin(entry, newDevice, location)
+}
+
+// notifyNewLogin 发送新设备/新IP登录提醒，用户设置了邮箱时同时发送邮件
+func notifyNewLogin(entry *model.SystemLog, newDevice bool, location string) {
+	title := "新IP登录提醒"
+	if newDevice {
+		title = "新设备登录提醒"
+	}
+
+	device := strings.TrimSpace(entry.Browser + " " + entry.OS)
+	if device == "" {
+		device = "未知设备"
+	}
+	if location == "" {
+		location = "未知地点"
+	}
+	content := fmt.Sprintf("您的账号于%s在%s（IP：%s，%s）登录。如非本人操作，请立即修改密码并注销其他会话。",
+		entry.CreatedAt.Format("2006-01-02 15:04:05"), device, entry.IP, location)
+
+	if err := NewNotificationService().Notify([]uint{entry.UserID}, model.NotificationSourceSecurity, "warning", title, content); err != nil {
+		log.Printf("发送登录提醒失败: %v", err)
+	}
+
+	var user model.User
+	if err := config.DB.First(&user, entry.UserID).Error; err != nil || user.Email == "" || config.App.SMTP.Host == "" {
+		return
+	}
+	// 邮件发送较慢，不阻塞登录请求
+	go func() {
+		if err := sendMail([]string{user.Email}, title, content); err != nil {
+			log.Printf("发送登录提醒邮件失败: %v", err)
+		}
+	}()
+}
+
+// deviceFingerprint 以浏览器名称、操作系统名称和设备类型标识设备，不含版本号，升级后仍是同一设备；
+// 登录日志的新设备标记和用户设备列表共用
+func deviceFingerprint(entry *model.SystemLog) string {
+	sum := sha256.Sum256([]byte(entry.Browser + "|" + entry.OS + "|" + entry.Device))
+	return hex.EncodeToString(sum[:16])
+}
+
+// locationString 查询IP所在地点，未配置地理位置库时为空
+func locationString(ip string) string {
+	loc := lookupLocation(ip)
+	if loc == nil {
+		return ""
+	}
+	return joinLocation(loc.Country, loc.Region, loc.City)
+}
+
+func joinLocation(parts ...string) string {
+	var nonEmpty []string
+	for _, p := range parts {
+		if p != "" && (len(nonEmpty) == 0 || nonEmpty[len(nonEmpty)-1] != p) {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
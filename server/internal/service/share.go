@@ -0,0 +1,341 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrShareNotFound 令牌无效或分享链接不存在
+	ErrShareNotFound = errors.New("分享链接不存在")
+	// ErrShareExpired 分享链接已过期
+	ErrShareExpired = errors.New("分享链接已过期")
+	// ErrShareRevoked 分享链接已被撤销
+	ErrShareRevoked = errors.New("分享链接已被撤销")
+	// ErrShareExhausted 下载次数已用完
+	ErrShareExhausted = errors.New("分享链接的下载次数已用完")
+	// ErrSharePassword 访问密码错误或未提供
+	ErrSharePassword = errors.New("访问密码错误")
+	// ErrShareForbidden 无权操作他人创建的分享链接
+	ErrShareForbidden = errors.New("无权操作该分享链接")
+)
+
+var (
+	shareSecretOnce sync.Once
+	shareSecret     []byte
+)
+
+// ShareService 文件分享链接服务
+type ShareService struct {
+	fileService *FileService
+}
+
+// NewShareService 创建分享链接服务实例
+func NewShareService() *ShareService {
+	return &ShareService{fileService: NewFileService()}
+}
+
+// CreateShare 为文件创建分享链接
+func (s *ShareService) CreateShare(userID uint, req *model.ShareCreateRequest) (*model.FileShare, error) {
+	file, err := s.fileService.GetFileByID(req.FileID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresIn := req.ExpiresIn
+	if expiresIn == 0 {
+		expiresIn = config.App.Share.DefaultExpiry
+		if expiresIn <= 0 {
+			expiresIn = 7 * 24 * 3600
+		}
+	}
+	if max := config.App.Share.MaxExpiry; max > 0 && expiresIn > max {
+		return nil, fmt.Errorf("有效期不能超过%d秒", max)
+	}
+
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("生成分享链接失败: %w", err)
+	}
+	share := &model.FileShare{
+		FileID:       file.ID,
+		CreatedBy:    userID,
+		Nonce:        hex.EncodeToString(nonce),
+		ExpiresAt:    time.Now().Add(time.Duration(expiresIn) * time.Second),
+		MaxDownloads: req.MaxDownloads,
+	}
+	if req.Password != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("生成分享链接失败: %w", err)
+		}
+		share.Password = string(hashed)
+	}
+
+	if err := config.DB.Create(share).Error; err != nil {
+		return nil, fmt.Errorf("保存分享链接失败: %w", err)
+	}
+	fillShare(share, file.FileName)
+	return share, nil
+}
+
+// GetShares 获取分享链接列表，all为true且拥有删除文件权限时返回所有人创建的链接
+func (s *ShareService) GetShares(userID uint, req *model.ShareListRequest) (*model.ShareListResponse, error) {
+	query := config.DB.Model(&model.FileShare{})
+	if !req.All || !userHasPermission(userID, model.PermissionFileDelete) {
+		query = query.Where("created_by = ?", userID)
+	}
+	if req.FileID != 0 {
+		query = query.Where("file_id = ?", req.FileID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("获取分享链接总数失败: %w", err)
+	}
+	var shares []model.FileShare
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Offset(offset).Limit(req.PageSize).Order("id DESC").Find(&shares).Error; err != nil {
+		return nil, fmt.Errorf("获取分享链接列表失败: %w", err)
+	}
+
+	names := map[uint]string{}
+	var fileIDs []uint
+	for _, share := range shares {
+		fileIDs = append(fileIDs, share.FileID)
+	}
+	if len(fileIDs) > 0 {
+		var files []model.File
+		config.DB.Select("id", "file_name").Where("id IN ?", fileIDs).Find(&files)
+		for _, file := range files {
+			names[file.ID] = file.FileName
+		}
+	}
+	for i := range shares {
+		fillShare(&shares[i], names[shares[i].FileID])
+	}
+	return &model.ShareListResponse{Total: int(total), List: shares}, nil
+}
+
+// RevokeShare 撤销分享链接，只能撤销自己创建的链接，拥有删除文件权限的用户可撤销任意链接
+func (s *ShareService) RevokeShare(userID, id uint) error {
+	var share model.FileShare
+	if err := config.DB.First(&share, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrShareNotFound
+		}
+		return fmt.Errorf("获取分享链接失败: %w", err)
+	}
+	if share.CreatedBy != userID && !userHasPermission(userID, model.PermissionFileDelete) {
+		return ErrShareForbidden
+	}
+	if share.RevokedAt != nil {
+		return nil
+	}
+	if err := config.DB.Model(&share).Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("撤销分享链接失败: %w", err)
+	}
+	return nil
+}
+
+// Resolve 校验令牌签名并返回有效的分享链接及其文件，返回的错误可能带有已找到的分享链接
+func (s *ShareService) Resolve(token string) (*model.FileShare, *model.File, error) {
+	id, nonce, ok := parseShareToken(token)
+	if !ok {
+		return nil, nil, ErrShareNotFound
+	}
+	var share model.FileShare
+	if config.DB.Limit(1).Find(&share, id).RowsAffected == 0 || !hmac.Equal([]byte(share.Nonce), []byte(nonce)) {
+		return nil, nil, ErrShareNotFound
+	}
+
+	switch {
+	case share.RevokedAt != nil:
+		return &share, nil, ErrShareRevoked
+	case time.Now().After(share.ExpiresAt):
+		return &share, nil, ErrShareExpired
+	}
+
+	file, err := s.fileService.GetFileByID(share.FileID)
+	if err != nil {
+		return &share, nil, ErrShareNotFound
+	}
+	// 次数用完时仍返回文件，以便已开始的下载继续断点续传
+	if share.MaxDownloads > 0 && share.Downloads >= share.MaxDownloads {
+		return &share, file, ErrShareExhausted
+	}
+	return &share, file, nil
+}
+
+// GetShareInfo 获取分享链接的公开信息
+func (s *ShareService) GetShareInfo(token string) (*model.ShareInfo, error) {
+	share, file, err := s.Resolve(token)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ShareInfo{
+		FileName:     file.FileName,
+		FileSize:     file.FileSize,
+		FileType:     file.FileType,
+		ExpiresAt:    share.ExpiresAt,
+		HasPassword:  share.Password != "",
+		MaxDownloads: share.MaxDownloads,
+		Downloads:    share.Downloads,
+	}, nil
+}
+
+// CheckPassword 校验访问密码，未设置密码的链接直接通过
+func (s *ShareService) CheckPassword(share *model.FileShare, password string) error {
+	if share.Password == "" {
+		return nil
+	}
+	if password == "" || bcrypt.CompareHashAndPassword([]byte(share.Password), []byte(password)) != nil {
+		return ErrSharePassword
+	}
+	return nil
+}
+
+// RecordDownload 占用一次下载次数，并发下载时不会超过上限
+func (s *ShareService) RecordDownload(share *model.FileShare) error {
+	result := config.DB.Model(&model.FileShare{}).
+		Where("id = ? AND (max_downloads = 0 OR downloads < max_downloads)", share.ID).
+		Updates(map[string]interface{}{
+			"downloads":      gorm.Expr("downloads + ?", 1),
+			"last_access_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("更新下载次数失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrShareExhausted
+	}
+	return nil
+}
+
+// shareSessionTTL 续传凭证的有效期
+const shareSessionTTL = 24 * time.Hour
+
+// IssueSession 计入一次下载后签发续传凭证，凭证绑定分享链接和文件内容，
+// 有效期内同一内容的续传请求不再计数，下载次数用完后也可继续；返回凭证和有效期（秒）
+func (s *ShareService) IssueSession(share *model.FileShare, file *model.File) (string, int) {
+	expires := time.Now().Add(shareSessionTTL)
+	if share.ExpiresAt.Before(expires) {
+		expires = share.ExpiresAt
+	}
+	payload := fmt.Sprintf("session.%d.%s.%d", share.ID, file.Hash, expires.Unix())
+	session := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(signShare(payload))
+	return session, int(time.Until(expires).Seconds())
+}
+
+// CheckSession 校验续传凭证是否由该分享链接签发、未过期且文件内容未变化
+func (s *ShareService) CheckSession(share *model.FileShare, file *model.File, session string) bool {
+	encoded, sig, ok := strings.Cut(session, ".")
+	if !ok {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, signShare(string(payload))) {
+		return false
+	}
+	parts := strings.Split(string(payload), ".")
+	if len(parts) != 4 || parts[0] != "session" || parts[1] != strconv.FormatUint(uint64(share.ID), 10) || parts[2] != file.Hash {
+		return false
+	}
+	expires, err := strconv.ParseInt(parts[3], 10, 64)
+	return err == nil && time.Now().Unix() < expires
+}
+
+// fillShare 填充文件名、令牌和分享地址
+func fillShare(share *model.FileShare, fileName string) {
+	share.FileName = fileName
+	share.HasPassword = share.Password != ""
+	share.Token = shareToken(share.ID, share.Nonce)
+	share.URL = strings.TrimRight(config.App.Share.BaseURL, "/") + "/api/s/" + share.Token
+}
+
+// shareToken 令牌格式：base64url(ID.随机数).base64url(HMAC-SHA256签名)
+func shareToken(id uint, nonce string) string {
+	payload := strconv.FormatUint(uint64(id), 10) + "." + nonce
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(signShare(payload))
+}
+
+// parseShareToken 校验签名并解析出链接ID和随机数
+func parseShareToken(token string) (uint, string, bool) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, "", false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, signShare(string(payload))) {
+		return 0, "", false
+	}
+	idStr, nonce, ok := strings.Cut(string(payload), ".")
+	if !ok {
+		return 0, "", false
+	}
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, "", false
+	}
+	return uint(id), nonce, true
+}
+
+func signShare(payload string) []byte {
+	mac := hmac.New(sha256.New, shareKey())
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// shareKey 签名密钥：优先使用配置的密钥，否则读取或生成密钥文件，重启后已发出的链接仍然有效
+func shareKey() []byte {
+	shareSecretOnce.Do(func() {
+		if config.App.Share.Secret != "" {
+			shareSecret = []byte(config.App.Share.Secret)
+			return
+		}
+		path := config.App.Share.SecretFile
+		if path == "" {
+			path = "share.key"
+		}
+		if data, err := os.ReadFile(path); err == nil && len(strings.TrimSpace(string(data))) > 0 {
+			shareSecret = []byte(strings.TrimSpace(string(data)))
+			return
+		}
+
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			log.Fatalf("生成分享链接密钥失败: %v", err)
+		}
+		shareSecret = []byte(hex.EncodeToString(key))
+		if err := os.WriteFile(path, shareSecret, 0600); err != nil {
+			log.Printf("保存分享链接密钥失败，重启后已发出的链接将失效: %v", err)
+		}
+	})
+	return shareSecret
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/storage"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// storages 已配置的存储后端，本地存储始终可用，用于读取历史文件
+	storages = map[string]storage.Storage{}
+	// defaultStorage 新上传文件使用的存储后端
+	defaultStorage storage.Storage
+)
+
+// InitStorage 根据配置初始化存储后端，并为历史文件记录补全后端和对象键
+func InitStorage(cfg config.StorageConfig) error {
+	local, err := storage.NewLocal(cfg.Local.Dir)
+	if err != nil {
+		return fmt.Errorf("初始化本地存储失败: %w", err)
+	}
+	storages[storage.BackendLocal] = local
+
+	if cfg.S3.Endpoint != "" {
+		s3, err := storage.NewS3(storage.S3Options{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+			Prefix:    cfg.S3.Prefix,
+		})
+		if err != nil {
+			return err
+		}
+		storages[storage.BackendS3] = s3
+	}
+	if cfg.Backend == storage.BackendMemory {
+		storages[storage.BackendMemory] = storage.NewMemory()
+	}
+
+	name := cfg.Backend
+	if name == "" {
+		name = storage.BackendLocal
+	}
+	defaultStorage = storages[name]
+	if defaultStorage == nil {
+		return fmt.Errorf("存储后端%s未配置", name)
+	}
+
+	return backfillObjectKeys(local)
+}
+
+// GetStorage 按名称获取存储后端，空名称表示本地存储
+func GetStorage(name string) (storage.Storage, error) {
+	if name == "" {
+		name = storage.BackendLocal
+	}
+	st, ok := storages[name]
+	if !ok {
+		return nil, fmt.Errorf("存储后端%s未配置", name)
+	}
+	return st, nil
+}
+
+// DefaultStorage 新上传文件使用的存储后端
+func DefaultStorage() storage.Storage {
+	return defaultStorage
+}
+
+// storagePath 文件记录中展示的存储位置，本地存储为磁盘路径
+func storagePath(st storage.Storage, key string) string {
+	if local, ok := st.(*storage.Local); ok {
+		if p, err := local.Path(key); err == nil {
+			return p
+		}
+	}
+	return st.Name() + "://" + key
+}
+
+// backfillObjectKeys 早期文件记录只保存了磁盘路径，按本地存储目录换算出对象键
+func backfillObjectKeys(local *storage.Local) error {
+	var files []model.File
+	if err := config.DB.Unscoped().Where("object_key = '' OR object_key IS NULL").Find(&files).Error; err != nil {
+		return err
+	}
+	for _, file := range files {
+		key, err := filepath.Rel(local.Root(), file.FilePath)
+		if err != nil || strings.HasPrefix(key, "..") {
+			log.Printf("文件%d的路径%s不在本地存储目录下，无法换算对象键", file.ID, file.FilePath)
+			continue
+		}
+		err = config.DB.Unscoped().Model(&model.File{}).Where("id = ?", file.ID).Updates(map[string]interface{}{
+			"backend":    storage.BackendLocal,
+			"object_key": filepath.ToSlash(key),
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotInTrash 回收站中没有该文件，或不是当前用户删除或上传的文件
+var ErrNotInTrash = errors.New("回收站中没有该文件")
+
+// TrashService 回收站服务
+//
+// 删除的文件只标记为已删除并移入回收站，存储对象、历史版本和访问控制保持不变；
+// 恢复时回到原文件夹，原文件夹已删除时回到根目录。管理员可以看到全部文件，
+// 其他用户只能看到自己上传的文件，以及自己删除且仍有查看权限的文件
+type TrashService struct {
+	aclService *ACLService
+}
+
+// NewTrashService 创建回收站服务实例
+func NewTrashService() *TrashService {
+	return &TrashService{
+		aclService: NewACLService(),
+	}
+}
+
+// GetTrash 获取回收站中的文件列表，按删除时间倒序
+func (s *TrashService) GetTrash(userID uint, req *model.TrashListRequest) (*model.TrashListResponse, error) {
+	access, err := s.aclService.AccessFor(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.scope(access)
+	if req.FileName != "" {
+		query = query.Where("file_name LIKE ?", "%"+req.FileName+"%")
+	}
+	resp := &model.TrashListResponse{List: []model.TrashItem{}}
+	if err := query.Count(&resp.Total).Error; err != nil {
+		return nil, fmt.Errorf("获取回收站文件总数失败: %w", err)
+	}
+	var files []model.File
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("deleted_at DESC").Offset(offset).Limit(req.PageSize).Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("获取回收站文件失败: %w", err)
+	}
+
+	userIDs := make([]uint, 0, len(files))
+	for _, file := range files {
+		userIDs = append(userIDs, file.DeletedBy)
+	}
+	var users []model.User
+	config.DB.Select("id", "username").Where("id IN ?", userIDs).Find(&users)
+	names := make(map[uint]string, len(users))
+	for _, user := range users {
+		names[user.ID] = user.Username
+	}
+
+	keepDays := trashKeepDays()
+	for _, file := range files {
+		item := model.TrashItem{
+			File:          file,
+			TrashedAt:     file.DeletedAt.Time,
+			DeletedByName: names[file.DeletedBy],
+			FolderPath:    access.folders[file.FolderID].Path,
+		}
+		if keepDays > 0 {
+			expires := file.DeletedAt.Time.AddDate(0, 0, keepDays)
+			item.ExpiresAt = &expires
+		}
+		resp.List = append(resp.List, item)
+	}
+	return resp, nil
+}
+
+// Restore 从回收站恢复文件，需要有在目标文件夹中新建文件的权限，超出上传者的文件数量配额时返回*UploadError
+func (s *TrashService) Restore(userID, id uint) (*model.File, error) {
+	access, err := s.aclService.AccessFor(userID)
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.trashedFile(access, id)
+	if err != nil {
+		return nil, err
+	}
+
+	folderID := file.FolderID
+	if _, ok := access.folders[folderID]; !ok {
+		folderID = 0
+	}
+	if err := canCreateIn(userID, folderID); err != nil {
+		return nil, err
+	}
+	// 回收站中的文件仍计入占用空间，恢复后只重新计入文件数
+	err = withQuota(file.UploadedBy, 0, 1, false, func(tx *gorm.DB) error {
+		return tx.Unscoped().Model(file).UpdateColumns(map[string]interface{}{
+			"trashed":    false,
+			"deleted_by": 0,
+			"deleted_at": gorm.Expr("NULL"),
+			"folder_id":  folderID,
+		}).Error
+	})
+	if err != nil {
+		var uploadErr *UploadError
+		if errors.As(err, &uploadErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("恢复文件失败: %w", err)
+	}
+	return NewFileService().GetFileByID(id)
+}
+
+// Purge 彻底删除回收站中的文件
+func (s *TrashService) Purge(userID, id uint) error {
+	access, err := s.aclService.AccessFor(userID)
+	if err != nil {
+		return err
+	}
+	file, err := s.trashedFile(access, id)
+	if err != nil {
+		return err
+	}
+	return purgeFile(file)
+}
+
+// Empty 清空当前用户可见的回收站文件，返回彻底删除的文件数
+func (s *TrashService) Empty(userID uint) (int, error) {
+	access, err := s.aclService.AccessFor(userID)
+	if err != nil {
+		return 0, err
+	}
+	var files []model.File
+	if err := s.scope(access).Find(&files).Error; err != nil {
+		return 0, fmt.Errorf("获取回收站文件失败: %w", err)
+	}
+	return purgeFiles(files), nil
+}
+
+// PurgeExpired 彻底删除超过保留天数的回收站文件，返回删除的文件数
+func (s *TrashService) PurgeExpired() (int, error) {
+	keepDays := trashKeepDays()
+	if keepDays <= 0 {
+		return 0, nil
+	}
+	var files []model.File
+	cutoff := time.Now().AddDate(0, 0, -keepDays)
+	if err := config.DB.Unscoped().Where("trashed = ? AND deleted_at < ?", true, cutoff).Find(&files).Error; err != nil {
+		return 0, fmt.Errorf("获取过期的回收站文件失败: %w", err)
+	}
+	return purgeFiles(files), nil
+}
+
+// scope 回收站中当前用户可见的文件：自己上传的文件，以及自己删除且仍有查看权限的文件
+func (s *TrashService) scope(access *Access) *gorm.DB {
+	query := config.DB.Unscoped().Model(&model.File{}).Where("trashed = ?", true)
+	if !access.admin {
+		query = query.Where("uploaded_by = ? OR id IN ?", access.userID, s.deletedByUser(access))
+	}
+	return query
+}
+
+// deletedByUser 当前用户删除的他人文件中仍有查看权限的文件ID
+func (s *TrashService) deletedByUser(access *Access) []uint {
+	var files []model.File
+	if err := config.DB.Unscoped().Select("id", "folder_id", "uploaded_by").
+		Where("trashed = ? AND deleted_by = ? AND uploaded_by <> ?", true, access.userID, access.userID).
+		Find(&files).Error; err != nil {
+		log.Printf("获取回收站文件失败: %v", err)
+		return nil
+	}
+	ids := []uint{}
+	for _, file := range access.FilterFiles(files, model.ACLView) {
+		ids = append(ids, file.ID)
+	}
+	return ids
+}
+
+// trashedFile 获取回收站中当前用户可见的文件
+func (s *TrashService) trashedFile(access *Access, id uint) (*model.File, error) {
+	var file model.File
+	if s.scope(access).Where("id = ?", id).Limit(1).Find(&file).RowsAffected == 0 {
+		return nil, ErrNotInTrash
+	}
+	return &file, nil
+}
+
+// purgeFiles 逐个彻底删除文件，出错时记录日志并继续
+func purgeFiles(files []model.File) int {
+	purged := 0
+	for i := range files {
+		if err := purgeFile(&files[i]); err != nil {
+			log.Printf("彻底删除文件%d失败: %v", files[i].ID, err)
+			continue
+		}
+		purged++
+	}
+	return purged
+}
+
+// trashKeepDays 回收站保留天数，0表示不自动清理
+func trashKeepDays() int {
+	switch days := config.App.Trash.KeepDays; {
+	case days < 0:
+		return 0
+	case days == 0:
+		return 30
+	default:
+		return days
+	}
+}
+
+// StartTrashCleanup 定期彻底删除超过保留天数的回收站文件
+func StartTrashCleanup() {
+	go func() {
+		s := NewTrashService()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			if n, err := s.PurgeExpired(); err != nil {
+				log.Printf("清理回收站失败: %v", err)
+			} else if n > 0 {
+				log.Printf("已从回收站彻底删除%d个文件", n)
+			}
+			<-ticker.C
+		}
+	}()
+}
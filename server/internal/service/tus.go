@@ -0,0 +1,368 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TusVersion 支持的tus协议版本
+const TusVersion = "1.0.0"
+
+// TusExtensions 支持的tus协议扩展
+const TusExtensions = "creation,creation-with-upload,termination,expiration"
+
+var (
+	// ErrUploadNotFound 上传任务不存在或不属于当前用户
+	ErrUploadNotFound = errors.New("上传任务不存在")
+	// ErrUploadExpired 上传任务已过期
+	ErrUploadExpired = errors.New("上传任务已过期")
+	// ErrUploadOffset 请求的Upload-Offset与已接收的字节数不一致
+	ErrUploadOffset = errors.New("上传偏移量不匹配")
+	// ErrUploadTooLarge 文件大小超过上限
+	ErrUploadTooLarge = errors.New("文件大小超过上限")
+	// ErrUploadFinished 上传任务已完成，不再接收数据
+	ErrUploadFinished = errors.New("上传任务已完成")
+	// ErrUploadMetadata 上传元数据无效
+	ErrUploadMetadata = errors.New("上传元数据无效")
+)
+
+// uploadLocks 同一上传任务的数据块按顺序写入，终止和过期清理也需持有该锁
+var uploadLocks keyLocks
+
+// lockUpload 锁定上传任务，返回解锁函数
+func lockUpload(uploadID string) func() {
+	return uploadLocks.lock(uploadID)
+}
+
+// TusService 断点续传服务
+type TusService struct {
+	fileService *FileService
+}
+
+// NewTusService 创建断点续传服务实例
+func NewTusService() *TusService {
+	return &TusService{fileService: NewFileService()}
+}
+
+// TusMaxSize 单个文件大小上限，0表示不限制
+func TusMaxSize() int64 {
+	return config.App.Storage.Tus.MaxSize
+}
+
+// tusDir 未完成上传的临时目录
+func tusDir() string {
+	if dir := config.App.Storage.Tus.Dir; dir != "" {
+		return dir
+	}
+	return filepath.Join("tmp", "tus")
+}
+
+// tusExpiration 上传任务无活动后的过期时间
+func tusExpiration() time.Duration {
+	if seconds := config.App.Storage.Tus.Expiration; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 24 * time.Hour
+}
+
+// uploadPath 上传任务的临时文件路径
+func uploadPath(upload *model.Upload) string {
+	return filepath.Join(tusDir(), upload.UploadID)
+}
+
+// ParseUploadMetadata 解析Upload-Metadata请求头，格式为逗号分隔的“键 base64值”
+func ParseUploadMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.Fields(pair)
+		if len(parts) > 2 {
+			return nil, fmt.Errorf("%w: 格式错误 %s", ErrUploadMetadata, pair)
+		}
+		value := ""
+		if len(parts) == 2 {
+			decoded, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s不是有效的base64编码", ErrUploadMetadata, parts[0])
+			}
+			value = string(decoded)
+		}
+		metadata[parts[0]] = value
+	}
+	return metadata, nil
+}
+
+// CreateUpload 创建上传任务，metadata中的filename为必填，category、description、folder_id为可选
+func (s *TusService) CreateUpload(userID uint, length int64, rawMetadata string) (*model.Upload, error) {
+	if max := TusMaxSize(); max > 0 && length > max {
+		return nil, ErrUploadTooLarge
+	}
+	metadata, err := ParseUploadMetadata(rawMetadata)
+	if err != nil {
+		return nil, err
+	}
+	filename := filepath.Base(strings.ReplaceAll(metadata["filename"], "\\", "/"))
+	if filename == "" || filename == "." || filename == "/" {
+		return nil, fmt.Errorf("%w: 缺少filename", ErrUploadMetadata)
+	}
+
+	// 提前按声明的大小和文件名校验，内容在接收完整后再校验
+	category := metadata["category"]
+	if category == "" {
+		category = "other"
+	}
+	policy := policyFor(userID, category)
+	if err := policy.checkSize(length); err != nil {
+		return nil, err
+	}
+	if err := policy.checkName(filename); err != nil {
+		return nil, err
+	}
+	var folderID uint
+	if value := metadata["folder_id"]; value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: folder_id无效", ErrUploadMetadata)
+		}
+		if config.DB.Select("id").Limit(1).Find(&model.Folder{}, id).RowsAffected == 0 {
+			return nil, fmt.Errorf("%w: 文件夹不存在", ErrUploadMetadata)
+		}
+		if err := canCreateIn(userID, uint(id)); err != nil {
+			return nil, err
+		}
+		folderID = uint(id)
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return nil, err
+	}
+	upload := &model.Upload{
+		UploadID:    id,
+		UserID:      userID,
+		Length:      length,
+		FileName:    filename,
+		Category:    category,
+		Description: metadata["description"],
+		FolderID:    folderID,
+		Metadata:    rawMetadata,
+		ExpiresAt:   time.Now().Add(tusExpiration()),
+	}
+
+	if err := os.MkdirAll(tusDir(), 0755); err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	f, err := os.OpenFile(uploadPath(upload), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	f.Close()
+
+	// 未完成的上传任务计入用量，校验和创建在同一事务中完成
+	err = withQuota(userID, length, 1, true, func(tx *gorm.DB) error {
+		return tx.Create(upload).Error
+	})
+	if err != nil {
+		os.Remove(uploadPath(upload))
+		var uploadErr *UploadError
+		if errors.As(err, &uploadErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("保存上传任务失败: %w", err)
+	}
+	return upload, nil
+}
+
+// GetUpload 获取当前用户的上传任务
+func (s *TusService) GetUpload(userID uint, uploadID string) (*model.Upload, error) {
+	var upload model.Upload
+	err := config.DB.Where("upload_id = ? AND user_id = ?", uploadID, userID).First(&upload).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, fmt.Errorf("获取上传任务失败: %w", err)
+	}
+	if upload.FileID == 0 && time.Now().After(upload.ExpiresAt) {
+		return nil, ErrUploadExpired
+	}
+	return &upload, nil
+}
+
+// WriteChunk 从offset处追加数据块，数据接收完整后转存为文件记录并返回
+// 连接中断时已写入的部分仍然保留，客户端可通过HEAD查询偏移量后续传
+func (s *TusService) WriteChunk(userID uint, uploadID string, offset int64, r io.Reader) (*model.Upload, *model.File, error) {
+	defer lockUpload(uploadID)()
+
+	upload, err := s.GetUpload(userID, uploadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if upload.FileID != 0 {
+		return upload, nil, ErrUploadFinished
+	}
+	if offset != upload.Offset {
+		return upload, nil, ErrUploadOffset
+	}
+
+	f, err := os.OpenFile(uploadPath(upload), os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开临时文件失败: %w", err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("定位临时文件失败: %w", err)
+	}
+	// 多出Upload-Length的数据直接丢弃
+	n, copyErr := io.Copy(f, io.LimitReader(r, upload.Length-offset))
+	closeErr := f.Close()
+	if copyErr == nil {
+		copyErr = closeErr
+	}
+
+	upload.Offset += n
+	upload.ExpiresAt = time.Now().Add(tusExpiration())
+	err = config.DB.Model(upload).Updates(map[string]interface{}{
+		"offset":     upload.Offset,
+		"expires_at": upload.ExpiresAt,
+	}).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("更新上传进度失败: %w", err)
+	}
+	if copyErr != nil {
+		return upload, nil, fmt.Errorf("接收数据中断: %w", copyErr)
+	}
+
+	if upload.Offset < upload.Length {
+		return upload, nil, nil
+	}
+	file, err := s.finish(upload)
+	if err != nil {
+		// 内容校验不通过时重试也无法完成，直接丢弃
+		var uploadErr *UploadError
+		if errors.As(err, &uploadErr) {
+			s.remove(upload)
+		}
+		return upload, nil, err
+	}
+	return upload, file, nil
+}
+
+// finish 将接收完整的临时文件转存到存储后端，生成文件记录后删除临时文件
+func (s *TusService) finish(upload *model.Upload) (*model.File, error) {
+	f, err := os.Open(uploadPath(upload))
+	if err != nil {
+		return nil, fmt.Errorf("打开临时文件失败: %w", err)
+	}
+	file, err := s.fileService.saveFile(f, &model.File{
+		FileName:    upload.FileName,
+		Category:    upload.Category,
+		Description: upload.Description,
+		FolderID:    upload.FolderID,
+		UploadedBy:  upload.UserID,
+	})
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	upload.FileID = file.ID
+	if err := config.DB.Model(upload).Update("file_id", file.ID).Error; err != nil {
+		log.Printf("更新上传任务%s失败: %v", upload.UploadID, err)
+	}
+	os.Remove(uploadPath(upload))
+	return file, nil
+}
+
+// Terminate 终止上传任务并删除已接收的数据
+func (s *TusService) Terminate(userID uint, uploadID string) error {
+	defer lockUpload(uploadID)()
+
+	// 已过期的任务同样允许终止
+	var upload model.Upload
+	if err := config.DB.Where("upload_id = ? AND user_id = ?", uploadID, userID).First(&upload).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUploadNotFound
+		}
+		return fmt.Errorf("获取上传任务失败: %w", err)
+	}
+	s.remove(&upload)
+	return nil
+}
+
+// remove 删除上传任务记录和临时文件，调用方需持有上传任务的锁
+func (s *TusService) remove(upload *model.Upload) {
+	if err := os.Remove(uploadPath(upload)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("删除上传临时文件%s失败: %v", upload.UploadID, err)
+	}
+	config.DB.Unscoped().Where("upload_id = ?", upload.UploadID).Delete(&model.Upload{})
+}
+
+// expiredUploads 过期未完成的上传任务，以及完成超过过期时间的任务记录
+func expiredUploads(now time.Time) *gorm.DB {
+	return config.DB.Where("(file_id = 0 AND expires_at < ?) OR (file_id <> 0 AND updated_at < ?)",
+		now, now.Add(-tusExpiration()))
+}
+
+// CleanupExpiredUploads 清理过期的上传任务；逐个持有上传任务的锁后重新检查，
+// 等待期间写入了数据块而延长了有效期的任务不会被删除
+func (s *TusService) CleanupExpiredUploads() (int, error) {
+	var ids []string
+	now := time.Now()
+	if err := expiredUploads(now).Model(&model.Upload{}).Pluck("upload_id", &ids).Error; err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, id := range ids {
+		unlock := lockUpload(id)
+		var upload model.Upload
+		if expiredUploads(now).Where("upload_id = ?", id).Limit(1).Find(&upload).RowsAffected > 0 {
+			s.remove(&upload)
+			removed++
+		}
+		unlock()
+	}
+	return removed, nil
+}
+
+// StartUploadCleanup 定期清理过期的上传任务
+func StartUploadCleanup() {
+	go func() {
+		s := NewTusService()
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for {
+			if n, err := s.CleanupExpiredUploads(); err != nil {
+				log.Printf("清理过期上传任务失败: %v", err)
+			} else if n > 0 {
+				log.Printf("已清理%d个过期上传任务", n)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成上传任务ID失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
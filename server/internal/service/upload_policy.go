@@ -0,0 +1,227 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// 上传被拒绝的错误码
+const (
+	UploadErrTooLarge          = "FILE_TOO_LARGE"     // 超过大小上限
+	UploadErrTypeDenied        = "TYPE_DENIED"        // 命中拒绝列表
+	UploadErrTypeNotAllowed    = "TYPE_NOT_ALLOWED"   // 不在允许列表内
+	UploadErrExtensionMismatch = "EXTENSION_MISMATCH" // 扩展名与实际内容不符
+	UploadErrChecksumMismatch  = "CHECKSUM_MISMATCH"  // 内容与提供的SHA-256不一致
+)
+
+// defaultDeniedTypes 未配置全局拒绝列表时拒绝的类型：可执行文件、脚本和浏览器会执行的网页内容
+var defaultDeniedTypes = []string{
+	"application/vnd.microsoft.portable-executable",
+	"application/x-msdownload",
+	"application/x-executable",
+	"application/x-elf",
+	"application/x-mach-binary",
+	"application/x-sharedlib",
+	"application/x-shellscript",
+	"application/javascript",
+	"text/javascript",
+	"text/html",
+	"application/xhtml+xml",
+	"image/svg+xml",
+	".exe", ".dll", ".com", ".bat", ".cmd", ".msi", ".scr", ".ps1", ".vbs", ".sh", ".js", ".html", ".htm", ".svg",
+}
+
+// UploadError 上传内容校验失败，Code为错误码，Status为建议的HTTP状态码
+type UploadError struct {
+	Code    string
+	Status  int
+	Message string
+}
+
+func (e *UploadError) Error() string {
+	return e.Message
+}
+
+func uploadError(code string, status int, format string, args ...interface{}) *UploadError {
+	return &UploadError{Code: code, Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// uploadPolicy 某个用户上传某个分类文件时生效的规则
+type uploadPolicy struct {
+	allow          [][]string
+	deny           []string
+	maxSize        int64
+	checkExtension bool
+}
+
+// policyFor 合并全局、分类和角色三级规则
+func policyFor(userID uint, category string) *uploadPolicy {
+	cfg := config.App.Upload
+	p := &uploadPolicy{
+		maxSize:        cfg.MaxSize,
+		checkExtension: cfg.CheckExtension == nil || *cfg.CheckExtension,
+	}
+
+	deny := cfg.Deny
+	if deny == nil {
+		deny = defaultDeniedTypes
+	}
+	rules := []config.UploadRule{{Allow: cfg.Allow, Deny: deny}}
+	if rule, ok := cfg.Categories[category]; ok {
+		rules = append(rules, rule)
+	}
+
+	var user model.User
+	if userID != 0 && config.DB.Select("role").Limit(1).Find(&user, userID).RowsAffected > 0 {
+		if rule, ok := cfg.Roles[user.Role]; ok {
+			rules = append(rules, rule)
+			if rule.MaxSize != 0 {
+				p.maxSize = rule.MaxSize
+			}
+		}
+	}
+
+	for _, rule := range rules {
+		if len(rule.Allow) > 0 {
+			p.allow = append(p.allow, rule.Allow)
+		}
+		p.deny = append(p.deny, rule.Deny...)
+	}
+	return p
+}
+
+// checkSize 校验文件大小
+func (p *uploadPolicy) checkSize(size int64) error {
+	if p.maxSize > 0 && size > p.maxSize {
+		return uploadError(UploadErrTooLarge, http.StatusRequestEntityTooLarge, "文件大小%d字节超过上限%d字节", size, p.maxSize)
+	}
+	return nil
+}
+
+// checkName 只按文件名校验扩展名，用于断点续传创建任务时提前拒绝
+func (p *uploadPolicy) checkName(filename string) error {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return p.checkType(ext, mediaType(mime.TypeByExtension(ext)), "")
+}
+
+// checkContent 读取文件头识别MIME类型并校验，完成后回到起始位置，返回识别出的MIME类型
+func (p *uploadPolicy) checkContent(r io.ReadSeeker, filename string) (*mimetype.MIME, error) {
+	detected, err := mimetype.DetectReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	extType := mediaType(mime.TypeByExtension(ext))
+	if err := p.checkType(ext, extType, mediaType(detected.String())); err != nil {
+		return nil, err
+	}
+	if p.checkExtension && !extensionMatches(detected, extType) {
+		return nil, uploadError(UploadErrExtensionMismatch, http.StatusUnsupportedMediaType,
+			"文件扩展名%s与实际内容%s不符", ext, mediaType(detected.String()))
+	}
+	return detected, nil
+}
+
+// checkType 扩展名、扩展名对应的类型和识别出的类型任一命中拒绝列表即拒绝，每个允许列表都需命中其一
+func (p *uploadPolicy) checkType(ext, extType, detected string) error {
+	candidates := []string{ext, extType, detected}
+	for _, pattern := range p.deny {
+		for _, c := range candidates {
+			if matchType(pattern, c) {
+				return uploadError(UploadErrTypeDenied, http.StatusUnsupportedMediaType, "不允许上传%s类型的文件", c)
+			}
+		}
+	}
+
+	for _, allow := range p.allow {
+		matched := false
+		for _, pattern := range allow {
+			// 允许列表中的扩展名只匹配扩展名，MIME类型优先匹配识别出的类型
+			if strings.HasPrefix(pattern, ".") {
+				matched = matchType(pattern, ext)
+			} else if detected != "" {
+				matched = matchType(pattern, detected)
+			} else {
+				matched = matchType(pattern, extType)
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			name := detected
+			if name == "" {
+				name = ext
+			}
+			return uploadError(UploadErrTypeNotAllowed, http.StatusUnsupportedMediaType, "%s类型的文件不在允许上传的范围内", name)
+		}
+	}
+	return nil
+}
+
+// matchType 规则匹配：扩展名（.exe）、通配符（image/*、*）或完整的MIME类型
+func matchType(pattern, value string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" || value == "" {
+		return false
+	}
+	if pattern == "*" || pattern == "*/*" {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(value, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == value
+}
+
+// extensionMatches 识别出的类型与扩展名对应的类型是否一致：
+// 两者相同或存在继承关系（如docx识别为zip、json识别为纯文本）即视为一致，
+// 扩展名未知或无法按文件头识别的类型不做校验
+func extensionMatches(detected *mimetype.MIME, extType string) bool {
+	if extType == "" {
+		return true
+	}
+	expected := mimetype.Lookup(extType)
+	if expected == nil {
+		return true
+	}
+	for m := detected; m != nil; m = m.Parent() {
+		if m.Is(extType) {
+			return true
+		}
+	}
+	// 识别结果是扩展名类型的上级类型，但未识别出任何特征时不算一致
+	if detected.Parent() == nil {
+		return false
+	}
+	for m := expected.Parent(); m != nil; m = m.Parent() {
+		if m.Is(detected.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaType 去掉MIME类型中的参数，如 text/plain; charset=utf-8 -> text/plain
+func mediaType(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	return t
+}
@@ -15,7 +15,7 @@ func NewUserService() *UserService {
 	return &UserService{}
 }
 
-func (s *UserService) Login(req *model.LoginRequest) (*model.LoginResponse, error) {
+func (s *UserService) Login(req *model.LoginRequest, ip string, userAgent string) (*model.LoginResponse, error) {
 	log.Printf("Login attempt for user: %s", req.Username)
 	
 	var user model.User
@@ -34,11 +34,19 @@ func (s *UserService) Login(req *model.LoginRequest) (*model.LoginResponse, erro
 		return nil, errors.New("账号已被禁用")
 	}
 
-	token, expiresAt, err := jwt.GenerateToken(user.ID, user.Username, user.Role)
+	sessionID, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+	token, expiresAt, err := jwt.GenerateToken(sessionID, user.ID, user.Username, user.Role)
 	if err != nil {
 		log.Printf("Failed to generate token: %v", err)
 		return nil, err
 	}
+	if err := NewSecurityService().CreateSession(user.ID, sessionID, ip, userAgent, expiresAt); err != nil {
+		log.Printf("Failed to create session: %v", err)
+		return nil, err
+	}
 
 	log.Printf("Login successful for user: %s", req.Username)
 	return &model.LoginResponse{
@@ -85,6 +93,12 @@ func (s *UserService) UpdateUser(id uint, userData *model.UpdateUserRequest) err
 	// 更新用户信息
 	user.Nickname = userData.Nickname
 	user.Role = userData.Role
+	if userData.Email != nil {
+		user.Email = *userData.Email
+	}
+	if userData.Department != nil {
+		user.Department = *userData.Department
+	}
 
 	return config.DB.Save(&user).Error
 }
@@ -106,8 +120,8 @@ func (s *UserService) ToggleUserStatus(id uint) error {
 	return config.DB.Save(&user).Error
 }
 
-// UpdateProfile 更新用户个人信息
-func (s *UserService) UpdateProfile(id uint, nickname string, avatar string) error {
+// UpdateProfile 更新用户个人信息，email为nil时保持不变
+func (s *UserService) UpdateProfile(id uint, nickname string, avatar string, email *string) error {
 	// 查找用户
 	var user model.User
 	if err := config.DB.First(&user, id).Error; err != nil {
@@ -118,6 +132,9 @@ func (s *UserService) UpdateProfile(id uint, nickname string, avatar string) err
 	updates := map[string]interface{}{
 		"nickname": nickname,
 	}
+	if email != nil {
+		updates["email"] = *email
+	}
 	
 	// 如果提供了头像URL，则更新头像
 	if avatar != "" {
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"jing_vue_gin_admin/server/config"
+	"jing_vue_gin_admin/server/internal/model"
+	"jing_vue_gin_admin/server/internal/storage"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrVersionNotFound 文件版本不存在
+	ErrVersionNotFound = errors.New("文件版本不存在")
+	// ErrVersionCurrent 当前版本不能删除
+	ErrVersionCurrent = errors.New("不能删除当前版本")
+)
+
+// fileLocks 同一文件的版本变更串行执行，保证版本号连续
+var fileLocks keyLocks
+
+func lockFile(id uint) func() {
+	return fileLocks.lock(id)
+}
+
+// VersionService 文件版本服务
+type VersionService struct {
+	fileService *FileService
+}
+
+// NewVersionService 创建文件版本服务实例
+func NewVersionService() *VersionService {
+	return &VersionService{fileService: NewFileService()}
+}
+
+// GetVersions 获取文件的版本列表，按版本号从新到旧排序
+func (s *VersionService) GetVersions(fileID uint) ([]model.FileVersion, error) {
+	file, err := s.fileService.GetFileByID(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []model.FileVersion
+	if err := config.DB.Where("file_id = ?", fileID).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("获取版本列表失败: %w", err)
+	}
+	// 从未上传过新版本的文件只有当前版本
+	if len(versions) == 0 {
+		versions = append(versions, versionOf(file))
+	}
+
+	userIDs := make([]uint, 0, len(versions))
+	for _, v := range versions {
+		userIDs = append(userIDs, v.UploadedBy)
+	}
+	var users []model.User
+	config.DB.Select("id", "username").Where("id IN ?", userIDs).Find(&users)
+	names := make(map[uint]string, len(users))
+	for _, user := range users {
+		names[user.ID] = user.Username
+	}
+	for i := range versions {
+		versions[i].UploaderName = names[versions[i].UploadedBy]
+		versions[i].Current = versions[i].Version == file.Version
+	}
+	return versions, nil
+}
+
+// UploadVersion 上传文件的新版本，文件ID、下载次数等信息保持不变
+// 内容按上传者角色和文件分类校验，当前版本成为历史版本后仍占用空间，配额按新版本的完整大小计算，校验不通过时返回*UploadError
+func (s *VersionService) UploadVersion(fileID, userID uint, req *model.FileVersionUploadRequest) (*model.File, error) {
+	src, err := req.File.Open()
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer src.Close()
+
+	unlock := lockFile(fileID)
+	defer unlock()
+
+	file, err := s.fileService.GetFileByID(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureVersions(file); err != nil {
+		return nil, err
+	}
+
+	content := &model.File{
+		FileName:   req.File.Filename,
+		Category:   file.Category,
+		UploadedBy: userID,
+		Hash:       strings.ToLower(req.SHA256),
+	}
+	// 用量按文件所有者统计
+	err = storeContent(src, content, func(size int64) error {
+		return checkQuota(file.UploadedBy, size, 0, false)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	version := versionOf(content)
+	version.Comment = req.Comment
+	return s.setCurrent(file, &version)
+}
+
+// RestoreVersion 将历史版本恢复为当前版本，恢复操作会生成一个内容相同的新版本，不会删除中间的版本
+func (s *VersionService) RestoreVersion(fileID uint, number int, userID uint) (*model.File, error) {
+	unlock := lockFile(fileID)
+	defer unlock()
+
+	file, err := s.fileService.GetFileByID(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if number == file.Version {
+		return file, nil
+	}
+	if err := s.ensureVersions(file); err != nil {
+		return nil, err
+	}
+
+	var target model.FileVersion
+	if config.DB.Where("file_id = ? AND version = ?", fileID, number).Limit(1).Find(&target).RowsAffected == 0 {
+		return nil, ErrVersionNotFound
+	}
+	if err := retainBlob(target.BlobID); err != nil {
+		return nil, err
+	}
+
+	version := target
+	version.Base = model.Base{}
+	version.UploadedBy = userID
+	version.Comment = fmt.Sprintf("恢复自版本%d", number)
+	return s.setCurrent(file, &version)
+}
+
+// DeleteVersion 删除历史版本，当前版本不能删除
+func (s *VersionService) DeleteVersion(fileID uint, number int) error {
+	unlock := lockFile(fileID)
+	defer unlock()
+
+	file, err := s.fileService.GetFileByID(fileID)
+	if err != nil {
+		return err
+	}
+	if number == file.Version {
+		return ErrVersionCurrent
+	}
+	var version model.FileVersion
+	if config.DB.Where("file_id = ? AND version = ?", fileID, number).Limit(1).Find(&version).RowsAffected == 0 {
+		return ErrVersionNotFound
+	}
+	return removeVersion(&version)
+}
+
+// OpenVersion 以可定位的方式打开指定版本的内容，调用方负责关闭，内容未通过病毒扫描时返回ErrFileInfected、ErrScanOversize或ErrScanPending
+func (s *VersionService) OpenVersion(fileID uint, number int) (io.ReadSeekCloser, *storage.ObjectInfo, *model.FileVersion, error) {
+	file, err := s.fileService.GetFileByID(fileID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var version model.FileVersion
+	if config.DB.Where("file_id = ? AND version = ?", fileID, number).Limit(1).Find(&version).RowsAffected == 0 {
+		if number != file.Version {
+			return nil, nil, nil, ErrVersionNotFound
+		}
+		version = versionOf(file)
+	}
+	// 当前版本按文件的扫描状态检查，历史版本按内容哈希检查
+	if number == file.Version {
+		err = CheckScan(file)
+	} else {
+		err = CheckHashScan(version.Hash)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	rc, info, err := s.fileService.OpenContent(&model.File{Backend: version.Backend, ObjectKey: version.ObjectKey})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return rc, info, &version, nil
+}
+
+// PruneAllVersions 按保留配置清理所有文件的历史版本，返回删除的版本数
+func (s *VersionService) PruneAllVersions() (int, error) {
+	var fileIDs []uint
+	if err := config.DB.Model(&model.FileVersion{}).Distinct("file_id").Pluck("file_id", &fileIDs).Error; err != nil {
+		return 0, fmt.Errorf("获取文件版本失败: %w", err)
+	}
+
+	total := 0
+	for _, id := range fileIDs {
+		var file model.File
+		if config.DB.Limit(1).Find(&file, id).RowsAffected == 0 {
+			continue
+		}
+		unlock := lockFile(id)
+		total += pruneVersions(&file)
+		unlock()
+	}
+	return total, nil
+}
+
+// ensureVersions 首次上传新版本前把当前内容登记为第一个版本，
+// 未计算哈希的历史文件先补算哈希，使每个版本都通过存储对象引用计数管理
+func (s *VersionService) ensureVersions(file *model.File) error {
+	var count int64
+	if err := config.DB.Model(&model.FileVersion{}).Where("file_id = ?", file.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("获取版本列表失败: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if file.BlobID == 0 {
+		if _, err := s.fileService.backfillHash(file, false, nil); err != nil {
+			return fmt.Errorf("补算文件哈希失败: %w", err)
+		}
+		if err := config.DB.First(file, file.ID).Error; err != nil {
+			return err
+		}
+	}
+	if file.Version == 0 {
+		file.Version = 1
+	}
+
+	if err := retainBlob(file.BlobID); err != nil {
+		return err
+	}
+	version := versionOf(file)
+	if err := config.DB.Create(&version).Error; err != nil {
+		releaseBlob(file.BlobID)
+		return fmt.Errorf("保存文件版本失败: %w", err)
+	}
+	return nil
+}
+
+// setCurrent 登记新版本并设为文件的当前内容，version需已计入一次存储对象引用
+func (s *VersionService) setCurrent(file *model.File, version *model.FileVersion) (*model.File, error) {
+	// 文件记录本身也持有当前内容的一次引用
+	if err := retainBlob(version.BlobID); err != nil {
+		releaseBlob(version.BlobID)
+		return nil, err
+	}
+
+	oldBlobID := file.BlobID
+	version.FileID = file.ID
+	version.Version = file.Version + 1
+	// 用量按文件所有者统计，原来的内容作为历史版本保留，新内容按完整大小计入
+	err := withQuota(file.UploadedBy, version.FileSize, 0, false, func(tx *gorm.DB) error {
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+		return tx.Model(file).Updates(map[string]interface{}{
+			"file_size":  version.FileSize,
+			"file_type":  version.FileType,
+			"mime_type":  version.MimeType,
+			"file_path":  storagePathOf(version.Backend, version.ObjectKey),
+			"backend":    version.Backend,
+			"object_key": version.ObjectKey,
+			"hash":       version.Hash,
+			"blob_id":    version.BlobID,
+			"version":    version.Version,
+			// 新内容重新扫描，扫描完成前不允许下载
+			"scan_status": initialScanStatus(),
+			"scan_result": "",
+			"scanned_at":  nil,
+		}).Error
+	})
+	if err != nil {
+		releaseBlob(version.BlobID)
+		releaseBlob(version.BlobID)
+		var uploadErr *UploadError
+		if errors.As(err, &uploadErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("保存文件版本失败: %w", err)
+	}
+
+	if err := releaseBlob(oldBlobID); err != nil {
+		log.Printf("释放文件%d旧版本的存储对象失败: %v", file.ID, err)
+	}
+	generateThumbnail(*file)
+	enqueueScan(file.ID)
+	pruneVersions(file)
+	return file, nil
+}
+
+// pruneVersions 按保留配置删除超出数量或超过保留天数的历史版本，返回删除的版本数
+func pruneVersions(file *model.File) int {
+	cfg := config.App.Version
+	maxVersions := cfg.MaxVersions
+	if maxVersions == 0 {
+		maxVersions = 20
+	}
+	if maxVersions < 0 && cfg.KeepDays <= 0 {
+		return 0
+	}
+
+	var versions []model.FileVersion
+	if err := config.DB.Where("file_id = ? AND version <> ?", file.ID, file.Version).
+		Order("version DESC").Find(&versions).Error; err != nil {
+		log.Printf("获取文件%d的历史版本失败: %v", file.ID, err)
+		return 0
+	}
+
+	removed := 0
+	cutoff := time.Now().AddDate(0, 0, -cfg.KeepDays)
+	for i := range versions {
+		// 当前版本占用一个名额
+		overLimit := maxVersions > 0 && i+1 >= maxVersions
+		expired := cfg.KeepDays > 0 && versions[i].CreatedAt.Before(cutoff)
+		if !overLimit && !expired {
+			continue
+		}
+		if err := removeVersion(&versions[i]); err != nil {
+			log.Printf("清理文件%d的版本%d失败: %v", file.ID, versions[i].Version, err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
+
+// removeVersion 删除版本记录并释放其存储对象引用
+func removeVersion(version *model.FileVersion) error {
+	if err := config.DB.Unscoped().Delete(version).Error; err != nil {
+		return fmt.Errorf("删除文件版本失败: %w", err)
+	}
+	if err := releaseBlob(version.BlobID); err != nil {
+		log.Printf("释放版本%d的存储对象失败: %v", version.ID, err)
+	}
+	return nil
+}
+
+// deleteVersions 删除文件的全部版本记录，文件删除时调用
+func deleteVersions(fileID uint) {
+	var versions []model.FileVersion
+	if err := config.DB.Where("file_id = ?", fileID).Find(&versions).Error; err != nil {
+		log.Printf("获取文件%d的版本失败: %v", fileID, err)
+		return
+	}
+	for i := range versions {
+		if err := removeVersion(&versions[i]); err != nil {
+			log.Printf("删除文件%d的版本%d失败: %v", fileID, versions[i].Version, err)
+		}
+	}
+}
+
+// versionOf 由文件的当前内容生成版本记录
+func versionOf(file *model.File) model.FileVersion {
+	version := file.Version
+	if version == 0 {
+		version = 1
+	}
+	return model.FileVersion{
+		Base:       model.Base{CreatedAt: file.CreatedAt},
+		FileID:     file.ID,
+		Version:    version,
+		FileName:   file.FileName,
+		FileSize:   file.FileSize,
+		FileType:   file.FileType,
+		MimeType:   file.MimeType,
+		Backend:    file.Backend,
+		ObjectKey:  file.ObjectKey,
+		Hash:       file.Hash,
+		BlobID:     file.BlobID,
+		UploadedBy: file.UploadedBy,
+	}
+}
+
+// storagePathOf 按后端名称生成文件记录中的存储路径
+func storagePathOf(backend, key string) string {
+	st, err := GetStorage(backend)
+	if err != nil {
+		return key
+	}
+	return storagePath(st, key)
+}
@@ -0,0 +1,9 @@
+package storage
+
+import "context"
+
+// Lister 支持列出对象的后端，用于检查存储与文件记录是否一致
+type Lister interface {
+	// List 列出键以prefix开头的全部对象，prefix为空时列出全部，fn返回错误时停止并返回该错误
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory 内存存储，用于测试和开发环境，进程退出后数据丢失
+type Memory struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	data []byte
+	info ObjectInfo
+}
+
+// NewMemory 创建内存存储
+func NewMemory() *Memory {
+	return &Memory{objects: make(map[string]memoryObject)}
+}
+
+// Name 后端名称
+func (m *Memory) Name() string {
+	return BackendMemory
+}
+
+// Put 写入对象
+func (m *Memory) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(readerWithContext(ctx, r))
+	if err != nil {
+		return err
+	}
+	sum := md5.Sum(data)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = memoryObject{
+		data: data,
+		info: ObjectInfo{
+			Key:          key,
+			Size:         int64(len(data)),
+			ContentType:  contentType,
+			ETag:         hex.EncodeToString(sum[:]),
+			LastModified: time.Now(),
+		},
+	}
+	return nil
+}
+
+// Get 读取对象
+func (m *Memory) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	obj, err := m.lookup(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := obj.info
+	return io.NopCloser(bytes.NewReader(obj.data)), &info, nil
+}
+
+// GetRange 读取对象的指定范围
+func (m *Memory) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	obj, err := m.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	data := obj.data
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	data = data[offset:]
+	if length >= 0 && length < int64(len(data)) {
+		data = data[:length]
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Stat 获取对象元信息
+func (m *Memory) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	obj, err := m.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	info := obj.info
+	return &info, nil
+}
+
+// Delete 删除对象
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	delete(m.objects, key)
+	m.mu.Unlock()
+	return nil
+}
+
+// List 按键的字典序列出对象
+func (m *Memory) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	m.mu.RLock()
+	infos := make([]ObjectInfo, 0, len(m.objects))
+	for key, obj := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			infos = append(infos, obj.info)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	for _, info := range infos {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Presign 内存存储没有独立的访问地址
+func (m *Memory) Presign(ctx context.Context, key string, expires time.Duration, opts *PresignOptions) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (m *Memory) lookup(key string) (memoryObject, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return memoryObject{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	obj, ok := m.objects[key]
+	if !ok {
+		return memoryObject{}, ErrNotFound
+	}
+	return obj, nil
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMemoryRoundTrip(t *testing.T) {
+	m := NewMemory()
+	ctx := context.Background()
+
+	if err := m.Put(ctx, "docs/a.txt", strings.NewReader("hello world"), -1, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Put(ctx, "docs/b.txt", strings.NewReader("b"), 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Put(ctx, "other/c.txt", strings.NewReader("c"), 1, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, info, err := m.Get(ctx, "docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello world" || info.Size != 11 || info.ContentType != "text/plain" {
+		t.Errorf("got %q %+v", data, info)
+	}
+
+	rc, err = m.GetRange(ctx, "docs/a.txt", 6, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "world" {
+		t.Errorf("range = %q", data)
+	}
+
+	var keys []string
+	if err := m.List(ctx, "docs/", func(info ObjectInfo) error {
+		keys = append(keys, info.Key)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("list docs/ = %v", keys)
+	}
+
+	if err := m.Delete(ctx, "docs/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Stat(ctx, "docs/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("stat after delete = %v, want ErrNotFound", err)
+	}
+	if err := m.Delete(ctx, "docs/a.txt"); err != nil {
+		t.Errorf("deleting a missing object should succeed: %v", err)
+	}
+	if _, err := m.Presign(ctx, "docs/b.txt", 0, nil); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("presign = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestCleanKey(t *testing.T) {
+	valid := map[string]string{"a/b": "a/b", `a\b`: "a/b", "a/./b/../c": "a/c"}
+	for in, want := range valid {
+		if got, err := CleanKey(in); err != nil || got != want {
+			t.Errorf("CleanKey(%q) = %q, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"", "/abs", "..", "../x", "a/../../x"} {
+		if _, err := CleanKey(in); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("CleanKey(%q) should be rejected", in)
+		}
+	}
+}
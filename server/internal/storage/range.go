@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RangeReader 支持按字节范围读取对象的后端，用于断点续传下载和媒体拖动播放
+type RangeReader interface {
+	// GetRange 读取对象从offset开始的内容，length为-1时读到末尾
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+}
+
+// OpenSeeker 以可定位的方式打开对象，只在实际读取时按当前位置请求数据，
+// 后端不支持范围读取时从头读取并跳过offset之前的内容
+func OpenSeeker(ctx context.Context, st Storage, key string) (io.ReadSeekCloser, *ObjectInfo, error) {
+	info, err := st.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &seeker{ctx: ctx, st: st, key: key, size: info.Size}, info, nil
+}
+
+// seeker 延迟打开的可定位读取器，Seek只记录位置，下一次Read时从该位置重新读取
+type seeker struct {
+	ctx    context.Context
+	st     Storage
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (s *seeker) Read(p []byte) (int, error) {
+	if s.offset >= s.size {
+		return 0, io.EOF
+	}
+	if s.body == nil {
+		body, err := s.open()
+		if err != nil {
+			return 0, err
+		}
+		s.body = body
+	}
+	n, err := s.body.Read(p)
+	s.offset += int64(n)
+	return n, err
+}
+
+func (s *seeker) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = s.offset + offset
+	case io.SeekEnd:
+		abs = s.size + offset
+	default:
+		return 0, errors.New("storage: 无效的whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("storage: 定位到负偏移")
+	}
+	if abs != s.offset && s.body != nil {
+		s.body.Close()
+		s.body = nil
+	}
+	s.offset = abs
+	return abs, nil
+}
+
+func (s *seeker) Close() error {
+	if s.body == nil {
+		return nil
+	}
+	err := s.body.Close()
+	s.body = nil
+	return err
+}
+
+// open 从当前位置打开对象内容
+func (s *seeker) open() (io.ReadCloser, error) {
+	if rr, ok := s.st.(RangeReader); ok {
+		return rr.GetRange(s.ctx, s.key, s.offset, -1)
+	}
+	body, _, err := s.st.Get(s.ctx, s.key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, body, s.offset); err != nil {
+		body.Close()
+		return nil, fmt.Errorf("storage: 跳过前%d字节失败: %w", s.offset, err)
+	}
+	return body, nil
+}
+
+// limitedReadCloser 只读取指定长度，关闭时关闭底层读取器
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitBody 按length截断读取器，length为-1时不截断
+func limitBody(rc io.ReadCloser, length int64) io.ReadCloser {
+	if length < 0 {
+		return rc
+	}
+	return limitedReadCloser{Reader: io.LimitReader(rc, length), Closer: rc}
+}
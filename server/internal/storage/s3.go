@@ -0,0 +1,537 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unsignedPayload 不对请求体计算哈希，S3和MinIO均支持
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// s3IdleTimeout 传输过程中连续多久没有进展就取消请求；不限制总时长，大文件的上传下载不会被中断
+var s3IdleTimeout = 2 * time.Minute
+
+// S3Options S3兼容对象存储配置
+type S3Options struct {
+	Endpoint  string // 服务地址，如 https://s3.amazonaws.com 或 http://127.0.0.1:9000
+	Region    string // 区域，默认 us-east-1
+	Bucket    string // 存储桶
+	AccessKey string
+	SecretKey string
+	PathStyle bool   // 使用路径风格地址（endpoint/bucket/key），MinIO通常需要开启
+	Prefix    string // 对象键前缀
+}
+
+// S3 S3兼容对象存储，使用AWS Signature Version 4签名
+type S3 struct {
+	opts     S3Options
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3 创建S3兼容对象存储
+func NewS3(opts S3Options) (*S3, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" {
+		return nil, errors.New("storage: S3需要配置endpoint和bucket")
+	}
+	endpoint, err := url.Parse(strings.TrimRight(opts.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("storage: S3 endpoint无效: %q", opts.Endpoint)
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	opts.Prefix = strings.Trim(opts.Prefix, "/")
+	return &S3{
+		opts:     opts,
+		endpoint: endpoint,
+		client:   newS3Client(),
+		now:      time.Now,
+	}, nil
+}
+
+// newS3Client 不设置整体超时，连接、TLS握手和等待响应头分别限时，传输过程由do中的空闲检测控制
+func newS3Client() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	transport.ResponseHeaderTimeout = time.Minute
+	return &http.Client{Transport: transport}
+}
+
+// Name 后端名称
+func (s *S3) Name() string {
+	return BackendS3
+}
+
+// Put 上传对象，大小未知时先写入临时文件以获得Content-Length
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if size < 0 {
+		tmp, err := os.CreateTemp("", "s3-put-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if size, err = io.Copy(tmp, r); err != nil {
+			return err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		r = tmp
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get 下载对象
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, s.objectInfo(key, resp), nil
+}
+
+// GetRange 通过Range请求头读取对象的指定范围
+func (s *S3) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if length >= 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	} else {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	// Range请求头参与签名，设置后重新签名
+	s.sign(req)
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	// 不支持Range的服务返回完整内容，跳过offset之前的部分
+	if resp.StatusCode != http.StatusPartialContent && offset > 0 {
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		return limitBody(resp.Body, length), nil
+	}
+	return resp.Body, nil
+}
+
+// Stat 获取对象元信息
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return s.objectInfo(key, resp), nil
+}
+
+// Delete 删除对象，S3删除不存在的对象同样返回成功
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// listBucketResult ListObjectsV2的响应
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		ETag         string    `xml:"ETag"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List 通过ListObjectsV2分页列出对象，对象键不含配置的前缀
+func (s *S3) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	base := ""
+	if s.opts.Prefix != "" {
+		base = s.opts.Prefix + "/"
+	}
+
+	token := ""
+	for {
+		u := *s.endpoint
+		if s.opts.PathStyle {
+			u.Path = strings.TrimRight(u.Path, "/") + "/" + s.opts.Bucket + "/"
+		} else {
+			u.Host = s.opts.Bucket + "." + u.Host
+			u.Path = strings.TrimRight(u.Path, "/") + "/"
+		}
+		u.RawPath = uriEncode(u.Path, false)
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", base+prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		u.RawQuery = canonicalQuery(query)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+		s.sign(req)
+		resp, err := s.do(req)
+		if err != nil {
+			return err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("storage: 解析S3对象列表失败: %w", err)
+		}
+
+		for _, obj := range result.Contents {
+			err := fn(ObjectInfo{
+				Key:          strings.TrimPrefix(obj.Key, base),
+				Size:         obj.Size,
+				ETag:         strings.Trim(obj.ETag, `"`),
+				LastModified: obj.LastModified,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// Presign 生成带签名的临时GET地址，opts中的响应头通过response-*查询参数一并签名
+func (s *S3) Presign(ctx context.Context, key string, expires time.Duration, opts *PresignOptions) (string, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	if expires <= 0 {
+		expires = 15 * time.Minute
+	}
+	if expires > 7*24*time.Hour {
+		expires = 7 * 24 * time.Hour
+	}
+
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.opts.AccessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if opts != nil && opts.ContentType != "" {
+		query.Set("response-content-type", opts.ContentType)
+	}
+	if opts != nil && opts.ContentDisposition != "" {
+		query.Set("response-content-disposition", opts.ContentDisposition)
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	header := http.Header{}
+	header.Set("Host", u.Host)
+	canonical, _ := canonicalRequest(http.MethodGet, u, header, unsignedPayload)
+	u.RawQuery += "&X-Amz-Signature=" + s.signature(now, canonical)
+	return u.String(), nil
+}
+
+// newRequest 构造并签名请求
+func (s *S3) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req)
+	return req, nil
+}
+
+// sign 以Authorization请求头方式签名
+func (s *S3) sign(req *http.Request) {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	header := req.Header.Clone()
+	header.Set("Host", req.URL.Host)
+	canonical, signed := canonicalRequest(req.Method, req.URL, header, unsignedPayload)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKey, s.scope(now), signed, s.signature(now, canonical)))
+}
+
+// signature 计算签名
+func (s *S3) signature(t time.Time, canonical string) string {
+	hash := sha256.Sum256([]byte(canonical))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		t.Format("20060102T150405Z"),
+		s.scope(t),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (s *S3) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.opts.Region + "/s3/aws4_request"
+}
+
+// objectURL 对象地址，路径风格为 endpoint/bucket/key，否则为 bucket.endpoint/key
+func (s *S3) objectURL(key string) (*url.URL, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if s.opts.Prefix != "" {
+		key = s.opts.Prefix + "/" + key
+	}
+
+	u := *s.endpoint
+	if s.opts.PathStyle {
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + s.opts.Bucket + "/" + key
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + key
+	}
+	u.RawPath = uriEncode(u.Path, false)
+	return &u, nil
+}
+
+// do 发送请求，非2xx响应转换为错误；请求体或响应体超过s3IdleTimeout没有进展时取消请求
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	req = req.WithContext(ctx)
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body = &uploadWatchdog{ReadCloser: req.Body, timer: time.AfterFunc(s3IdleTimeout, cancel)}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		resp.Body = &downloadWatchdog{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if xml.Unmarshal(body, &e) == nil && e.Code != "" {
+		return nil, fmt.Errorf("storage: S3 %s %s: %s", resp.Status, e.Code, e.Message)
+	}
+	return nil, fmt.Errorf("storage: S3 %s", resp.Status)
+}
+
+// uploadWatchdog 请求体每被读取一次（上一块已发出）就重新计时，读完后停止计时，之后由ResponseHeaderTimeout限制
+type uploadWatchdog struct {
+	io.ReadCloser
+	timer *time.Timer
+}
+
+func (w *uploadWatchdog) Read(p []byte) (int, error) {
+	n, err := w.ReadCloser.Read(p)
+	if err != nil {
+		w.timer.Stop()
+	} else {
+		w.timer.Reset(s3IdleTimeout)
+	}
+	return n, err
+}
+
+func (w *uploadWatchdog) Close() error {
+	w.timer.Stop()
+	return w.ReadCloser.Close()
+}
+
+// downloadWatchdog 只在等待数据时计时，调用方处理数据（如向慢速客户端转发）的时间不计入
+type downloadWatchdog struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (w *downloadWatchdog) Read(p []byte) (int, error) {
+	timer := time.AfterFunc(s3IdleTimeout, w.cancel)
+	defer timer.Stop()
+	return w.ReadCloser.Read(p)
+}
+
+func (w *downloadWatchdog) Close() error {
+	err := w.ReadCloser.Close()
+	w.cancel()
+	return err
+}
+
+func (s *S3) objectInfo(key string, resp *http.Response) *ObjectInfo {
+	info := &ObjectInfo{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = t
+	}
+	return info
+}
+
+// canonicalRequest 构造规范请求，签名Host、Content-Type、Range及全部x-amz-*请求头
+func canonicalRequest(method string, u *url.URL, header http.Header, payloadHash string) (string, string) {
+	var names []string
+	values := map[string]string{}
+	for name, vals := range header {
+		lower := strings.ToLower(name)
+		if lower != "host" && lower != "content-type" && lower != "range" && !strings.HasPrefix(lower, "x-amz-") {
+			continue
+		}
+		names = append(names, lower)
+		trimmed := make([]string, len(vals))
+		for i, v := range vals {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		values[lower] = strings.Join(trimmed, ",")
+	}
+	sort.Strings(names)
+
+	var headers strings.Builder
+	for _, name := range names {
+		headers.WriteString(name + ":" + values[name] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		method,
+		uriEncode(u.Path, false),
+		canonicalQuery(u.Query()),
+		headers.String(),
+		signed,
+		payloadHash,
+	}, "\n")
+	return canonical, signed
+}
+
+// canonicalQuery 按键排序并编码查询参数
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), query[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode 按SigV4规则编码，只保留非保留字符，encodeSlash为false时保留路径分隔符
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
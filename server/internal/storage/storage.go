@@ -0,0 +1,71 @@
+// Package storage 文件存储后端抽象，提供本地磁盘、S3兼容对象存储和内存实现
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// 存储后端名称，记录在文件记录的Backend字段中
+const (
+	BackendLocal  = "local"
+	BackendS3     = "s3"
+	BackendMemory = "memory"
+)
+
+var (
+	// ErrNotFound 对象不存在
+	ErrNotFound = errors.New("storage: 对象不存在")
+	// ErrNotSupported 后端不支持该操作
+	ErrNotSupported = errors.New("storage: 后端不支持该操作")
+	// ErrInvalidKey 对象键不合法
+	ErrInvalidKey = errors.New("storage: 对象键不合法")
+)
+
+// ObjectInfo 对象元信息
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// Storage 存储后端接口，对象键使用"/"分隔的相对路径
+type Storage interface {
+	// Name 后端名称
+	Name() string
+	// Put 写入对象，size未知时传-1
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，调用方负责关闭返回的ReadCloser
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Stat 获取对象元信息
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete 删除对象，对象不存在时不报错
+	Delete(ctx context.Context, key string) error
+	// Presign 生成带签名的临时下载地址，opts可为nil，不支持时返回ErrNotSupported
+	Presign(ctx context.Context, key string, expires time.Duration, opts *PresignOptions) (string, error)
+}
+
+// PresignOptions 通过临时地址下载时由存储服务返回的响应头，为空时使用对象自身的元信息
+type PresignOptions struct {
+	ContentType        string // Content-Type
+	ContentDisposition string // Content-Disposition，对象键为内容哈希时用于指定下载文件名
+}
+
+// CleanKey 规范化对象键，拒绝绝对路径和越级路径
+func CleanKey(key string) (string, error) {
+	key = strings.ReplaceAll(key, "\\", "/")
+	if key == "" || strings.HasPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
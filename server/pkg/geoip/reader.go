@@ -0,0 +1,444 @@
+// Package geoip 读取MaxMind DB（.mmdb）格式的离线IP地理位置库，
+// 兼容GeoLite2-City/GeoIP2-City等数据文件，不依赖第三方库。
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+)
+
+// metadataMarker 元数据区起始标记，位于文件末尾128KB以内
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// dataSectionSeparator 搜索树与数据区之间的16字节分隔
+const dataSectionSeparator = 16
+
+// Location 查询结果
+type Location struct {
+	Country     string // 国家名称
+	CountryCode string // ISO国家代码
+	Region      string // 省/州
+	City        string // 城市
+}
+
+// Metadata 数据库元数据
+type Metadata struct {
+	DatabaseType string
+	IPVersion    int
+	NodeCount    uint
+	RecordSize   uint
+	BuildEpoch   uint64
+}
+
+// Reader MaxMind DB读取器，文件整体加载到内存，查询并发安全
+type Reader struct {
+	buf       []byte
+	data      []byte
+	meta      Metadata
+	nodeSize  uint
+	ipv4Start uint
+	languages []string
+	treeSize  uint
+}
+
+// Open 打开数据库文件，languages为名称的语言优先级，如 zh-CN、en
+func Open(path string, languages ...string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromBytes(buf, languages...)
+}
+
+// FromBytes 从内存数据创建读取器
+func FromBytes(buf []byte, languages ...string) (*Reader, error) {
+	start := bytes.LastIndex(buf, metadataMarker)
+	if start == -1 {
+		return nil, errors.New("geoip: 不是有效的MaxMind DB文件")
+	}
+	metaStart := start + len(metadataMarker)
+
+	d := decoder{buf: buf[metaStart:]}
+	raw, _, err := d.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: 解析元数据失败: %w", err)
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("geoip: 元数据格式错误")
+	}
+
+	meta := Metadata{
+		DatabaseType: asString(m["database_type"]),
+		IPVersion:    int(asUint(m["ip_version"])),
+		NodeCount:    uint(asUint(m["node_count"])),
+		RecordSize:   uint(asUint(m["record_size"])),
+		BuildEpoch:   asUint(m["build_epoch"]),
+	}
+	if meta.RecordSize != 24 && meta.RecordSize != 28 && meta.RecordSize != 32 {
+		return nil, fmt.Errorf("geoip: 不支持的记录长度%d", meta.RecordSize)
+	}
+
+	nodeSize := meta.RecordSize / 4
+	treeSize := meta.NodeCount * nodeSize
+	if treeSize+dataSectionSeparator > uint(start) {
+		return nil, errors.New("geoip: 搜索树长度超出文件范围")
+	}
+
+	if len(languages) == 0 {
+		languages = []string{"zh-CN", "en"}
+	}
+	r := &Reader{
+		buf:       buf,
+		data:      buf[treeSize+dataSectionSeparator : start],
+		meta:      meta,
+		nodeSize:  nodeSize,
+		languages: languages,
+		treeSize:  treeSize,
+	}
+
+	// IPv6数据库中IPv4地址位于 ::/96 之下，预先走完前96位
+	if meta.IPVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < meta.NodeCount; i++ {
+			node, err = r.readNode(node, 0)
+			if err != nil {
+				return nil, err
+			}
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// Metadata 返回数据库元数据
+func (r *Reader) Metadata() Metadata {
+	return r.meta
+}
+
+// Lookup 查询IP对应的地理位置，未收录时返回nil
+func (r *Reader) Lookup(ip net.IP) (*Location, error) {
+	raw, err := r.lookupRaw(ip)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	loc := &Location{}
+	if country, ok := m["country"].(map[string]interface{}); ok {
+		loc.Country = r.name(country)
+		loc.CountryCode = asString(country["iso_code"])
+	}
+	if subdivisions, ok := m["subdivisions"].([]interface{}); ok && len(subdivisions) > 0 {
+		if region, ok := subdivisions[0].(map[string]interface{}); ok {
+			loc.Region = r.name(region)
+		}
+	}
+	if city, ok := m["city"].(map[string]interface{}); ok {
+		loc.City = r.name(city)
+	}
+	return loc, nil
+}
+
+// LookupString 查询字符串形式的IP
+func (r *Reader) LookupString(ip string) (*Location, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, fmt.Errorf("geoip: 无效的IP地址%q", ip)
+	}
+	return r.Lookup(parsed)
+}
+
+func (r *Reader) lookupRaw(ip net.IP) (interface{}, error) {
+	node, bits := uint(0), 0
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+		bits = len(ip) * 8
+		if r.meta.IPVersion == 6 {
+			node = r.ipv4Start
+		}
+	} else {
+		if r.meta.IPVersion == 4 {
+			return nil, nil
+		}
+		ip = ip.To16()
+		bits = 128
+	}
+
+	var err error
+	for i := 0; i < bits && node < r.meta.NodeCount; i++ {
+		bit := uint(ip[i>>3]>>(7-uint(i&7))) & 1
+		node, err = r.readNode(node, bit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case node == r.meta.NodeCount:
+		return nil, nil
+	case node < r.meta.NodeCount:
+		return nil, errors.New("geoip: 搜索树数据错误")
+	}
+
+	offset := node - r.meta.NodeCount - dataSectionSeparator
+	if offset >= uint(len(r.data)) {
+		return nil, errors.New("geoip: 数据指针超出范围")
+	}
+	d := decoder{buf: r.data}
+	value, _, err := d.decode(offset)
+	return value, err
+}
+
+// readNode 读取节点的左（bit=0）或右（bit=1）记录
+func (r *Reader) readNode(node, bit uint) (uint, error) {
+	base := node * r.nodeSize
+	if base+r.nodeSize > r.treeSize {
+		return 0, errors.New("geoip: 节点超出搜索树范围")
+	}
+	b := r.buf[base : base+r.nodeSize]
+
+	switch r.meta.RecordSize {
+	case 24:
+		o := bit * 3
+		return uint(b[o])<<16 | uint(b[o+1])<<8 | uint(b[o+2]), nil
+	case 28:
+		if bit == 0 {
+			return (uint(b[3])&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]), nil
+		}
+		return (uint(b[3])&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6]), nil
+	default:
+		o := bit * 4
+		return uint(binary.BigEndian.Uint32(b[o : o+4])), nil
+	}
+}
+
+// name 按语言优先级取names中的名称
+func (r *Reader) name(m map[string]interface{}) string {
+	names, ok := m["names"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	for _, lang := range r.languages {
+		if s := asString(names[lang]); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// 数据区字段类型
+const (
+	typeExtended  = 0
+	typePointer   = 1
+	typeString    = 2
+	typeDouble    = 3
+	typeBytes     = 4
+	typeUint16    = 5
+	typeUint32    = 6
+	typeMap       = 7
+	typeInt32     = 8
+	typeUint64    = 9
+	typeUint128   = 10
+	typeArray     = 11
+	typeContainer = 12
+	typeEnd       = 13
+	typeBool      = 14
+	typeFloat     = 15
+)
+
+// maxDepth 嵌套深度上限，防止损坏的文件导致无限递归
+const maxDepth = 32
+
+type decoder struct {
+	buf   []byte
+	depth int
+}
+
+var errCorrupt = errors.New("geoip: 数据区格式错误")
+
+// decode 解码offset处的值，返回值与下一个字段的偏移
+func (d *decoder) decode(offset uint) (interface{}, uint, error) {
+	if d.depth > maxDepth {
+		return nil, 0, errCorrupt
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+
+	typ, size, offset, err := d.controlByte(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typ == typePointer {
+		ptr, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(ptr)
+		return value, next, err
+	}
+	return d.decodeValue(typ, size, offset)
+}
+
+// controlByte 解析控制字节，返回类型、长度和数据起始偏移
+func (d *decoder) controlByte(offset uint) (int, uint, uint, error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, 0, errCorrupt
+	}
+	ctrl := d.buf[offset]
+	offset++
+
+	typ := int(ctrl >> 5)
+	if typ == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return 0, 0, 0, errCorrupt
+		}
+		typ = 7 + int(d.buf[offset])
+		offset++
+	}
+
+	size := uint(ctrl & 0x1F)
+	if typ == typePointer {
+		return typ, size, offset, nil
+	}
+	if size >= 29 {
+		n := size - 28
+		if offset+n > uint(len(d.buf)) {
+			return 0, 0, 0, errCorrupt
+		}
+		v := uint(0)
+		for _, b := range d.buf[offset : offset+n] {
+			v = v<<8 | uint(b)
+		}
+		switch size {
+		case 29:
+			size = 29 + v
+		case 30:
+			size = 285 + v
+		default:
+			size = 65821 + v
+		}
+		offset += n
+	}
+	return typ, size, offset, nil
+}
+
+// pointer 解析指针，size为控制字节低5位
+func (d *decoder) pointer(size, offset uint) (uint, uint, error) {
+	n := (size>>3)&0x3 + 1
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, errCorrupt
+	}
+	v := uint(0)
+	if n != 4 {
+		v = size & 0x7
+	}
+	for _, b := range d.buf[offset : offset+n] {
+		v = v<<8 | uint(b)
+	}
+	switch n {
+	case 2:
+		v += 2048
+	case 3:
+		v += 526336
+	}
+	return v, offset + n, nil
+}
+
+func (d *decoder) decodeValue(typ int, size, offset uint) (interface{}, uint, error) {
+	switch typ {
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			value, next, err := d.decode(next)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[asString(key)] = value
+			offset = next
+		}
+		return m, offset, nil
+	case typeArray:
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			value, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+			offset = next
+		}
+		return a, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	end := offset + size
+	if end > uint(len(d.buf)) {
+		return nil, 0, errCorrupt
+	}
+	b := d.buf[offset:end]
+
+	switch typ {
+	case typeString:
+		return string(b), end, nil
+	case typeBytes:
+		return append([]byte(nil), b...), end, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errCorrupt
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), end, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errCorrupt
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), end, nil
+	case typeUint16, typeUint32, typeUint64, typeInt32:
+		if size > 8 {
+			return nil, 0, errCorrupt
+		}
+		v := uint64(0)
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		if typ == typeInt32 {
+			return int64(int32(uint32(v))), end, nil
+		}
+		return v, end, nil
+	case typeUint128:
+		// 地理位置查询用不到128位整数，原样返回字节
+		return append([]byte(nil), b...), end, nil
+	case typeContainer, typeEnd:
+		return nil, end, nil
+	}
+	return nil, 0, errCorrupt
+}
+
+func asString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func asUint(v interface{}) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int64:
+		return uint64(n)
+	}
+	return 0
+}
@@ -0,0 +1,288 @@
+// Package imaging 纯Go实现的图片缩放、裁剪和格式转换，支持JPEG、PNG和GIF
+package imaging
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+)
+
+// 输出格式
+const (
+	JPEG = "jpeg"
+	PNG  = "png"
+	GIF  = "gif"
+)
+
+// ErrUnsupportedFormat 不支持的图片格式
+var ErrUnsupportedFormat = errors.New("imaging: 不支持的图片格式")
+
+// ErrTooLarge 图片像素数超过上限
+var ErrTooLarge = errors.New("imaging: 图片尺寸过大")
+
+// Decode 解码图片，maxPixels大于0时先读取尺寸，像素数超过上限则拒绝，防止解压炸弹
+// GIF只解码第一帧，返回的格式为jpeg、png或gif
+func Decode(r io.ReadSeeker, maxPixels int) (image.Image, string, error) {
+	if maxPixels > 0 {
+		cfg, _, err := image.DecodeConfig(r)
+		if err != nil {
+			return nil, "", decodeError(err)
+		}
+		if cfg.Width*cfg.Height > maxPixels {
+			return nil, "", ErrTooLarge
+		}
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return nil, "", err
+		}
+	}
+	img, format, err := image.Decode(r)
+	if err != nil {
+		return nil, "", decodeError(err)
+	}
+	return img, format, nil
+}
+
+// Encode 按格式编码图片，quality只对JPEG有效
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	switch NormalizeFormat(format) {
+	case JPEG:
+		if quality <= 0 || quality > 100 {
+			quality = 85
+		}
+		return jpeg.Encode(w, flatten(img), &jpeg.Options{Quality: quality})
+	case PNG:
+		return png.Encode(w, img)
+	case GIF:
+		return gif.Encode(w, img, nil)
+	}
+	return ErrUnsupportedFormat
+}
+
+// NormalizeFormat 统一格式名称，如 jpg -> jpeg，不支持时返回空字符串
+func NormalizeFormat(format string) string {
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "jpg", "jpeg":
+		return JPEG
+	case "png":
+		return PNG
+	case "gif":
+		return GIF
+	}
+	return ""
+}
+
+// ContentType 格式对应的MIME类型
+func ContentType(format string) string {
+	switch NormalizeFormat(format) {
+	case JPEG:
+		return "image/jpeg"
+	case PNG:
+		return "image/png"
+	case GIF:
+		return "image/gif"
+	}
+	return ""
+}
+
+// Fit 等比缩放到不超过width×height，宽高为0表示不限制该方向，不会放大原图
+func Fit(img image.Image, width, height int) image.Image {
+	b := img.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	if srcW == 0 || srcH == 0 {
+		return img
+	}
+	if width <= 0 {
+		width = srcW
+	}
+	if height <= 0 {
+		height = srcH
+	}
+	if srcW <= width && srcH <= height {
+		return img
+	}
+
+	ratio := math.Min(float64(width)/float64(srcW), float64(height)/float64(srcH))
+	dstW := int(math.Max(1, math.Round(float64(srcW)*ratio)))
+	dstH := int(math.Max(1, math.Round(float64(srcH)*ratio)))
+	return Resize(img, dstW, dstH)
+}
+
+// Fill 等比缩放并居中裁剪为width×height，原图小于目标尺寸时按原图比例裁剪而不放大
+func Fill(img image.Image, width, height int) image.Image {
+	b := img.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	if width <= 0 || height <= 0 || srcW == 0 || srcH == 0 {
+		return Fit(img, width, height)
+	}
+
+	// 按目标宽高比从原图中心截取最大区域
+	cropW, cropH := srcW, srcW*height/width
+	if cropH > srcH {
+		cropW, cropH = srcH*width/height, srcH
+	}
+	if cropW < 1 {
+		cropW = 1
+	}
+	if cropH < 1 {
+		cropH = 1
+	}
+	x0 := b.Min.X + (srcW-cropW)/2
+	y0 := b.Min.Y + (srcH-cropH)/2
+	cropped := Crop(img, image.Rect(x0, y0, x0+cropW, y0+cropH))
+
+	if cropW <= width && cropH <= height {
+		return cropped
+	}
+	return Resize(cropped, width, height)
+}
+
+// Crop 裁剪出指定区域，区域超出图片范围的部分被忽略
+func Crop(img image.Image, rect image.Rectangle) image.Image {
+	rect = rect.Intersect(img.Bounds())
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, rect.Min, draw.Src)
+	return dst
+}
+
+// Resize 缩放到指定尺寸，使用三角滤波的可分离重采样，缩小时按比例扩大采样范围以避免锯齿
+func Resize(img image.Image, width, height int) image.Image {
+	if width <= 0 || height <= 0 {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	if b.Dx() == width && b.Dy() == height {
+		return src
+	}
+
+	// 先横向再纵向，RGBA为预乘透明度格式，直接加权不会在透明边缘产生暗边
+	tmp := image.NewRGBA(image.Rect(0, 0, width, b.Dy()))
+	xw := weights(width, b.Dx())
+	for y := 0; y < b.Dy(); y++ {
+		srcRow := src.Pix[y*src.Stride:]
+		dstRow := tmp.Pix[y*tmp.Stride:]
+		for x, ws := range xw {
+			var r, g, bl, a float64
+			for _, w := range ws {
+				p := srcRow[w.index*4:]
+				r += float64(p[0]) * w.weight
+				g += float64(p[1]) * w.weight
+				bl += float64(p[2]) * w.weight
+				a += float64(p[3]) * w.weight
+			}
+			setPixel(dstRow[x*4:], r, g, bl, a)
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	yw := weights(height, b.Dy())
+	for y, ws := range yw {
+		dstRow := dst.Pix[y*dst.Stride:]
+		for x := 0; x < width; x++ {
+			var r, g, bl, a float64
+			for _, w := range ws {
+				p := tmp.Pix[w.index*tmp.Stride+x*4:]
+				r += float64(p[0]) * w.weight
+				g += float64(p[1]) * w.weight
+				bl += float64(p[2]) * w.weight
+				a += float64(p[3]) * w.weight
+			}
+			setPixel(dstRow[x*4:], r, g, bl, a)
+		}
+	}
+	return dst
+}
+
+type weight struct {
+	index  int
+	weight float64
+}
+
+// weights 计算目标每个像素对应的源像素及权重
+func weights(dstSize, srcSize int) [][]weight {
+	scale := float64(srcSize) / float64(dstSize)
+	support := math.Max(scale, 1)
+
+	result := make([][]weight, dstSize)
+	for i := range result {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Ceil(center - support))
+		end := int(math.Floor(center + support))
+
+		var ws []weight
+		var sum float64
+		for j := start; j <= end; j++ {
+			w := 1 - math.Abs(float64(j)-center)/support
+			if w <= 0 {
+				continue
+			}
+			index := j
+			if index < 0 {
+				index = 0
+			} else if index >= srcSize {
+				index = srcSize - 1
+			}
+			ws = append(ws, weight{index: index, weight: w})
+			sum += w
+		}
+		if sum == 0 {
+			index := int(math.Round(center))
+			if index < 0 {
+				index = 0
+			} else if index >= srcSize {
+				index = srcSize - 1
+			}
+			ws, sum = []weight{{index: index, weight: 1}}, 1
+		}
+		for k := range ws {
+			ws[k].weight /= sum
+		}
+		result[i] = ws
+	}
+	return result
+}
+
+func setPixel(p []uint8, r, g, b, a float64) {
+	a = clamp(a)
+	p[0] = uint8(math.Min(clamp(r), a))
+	p[1] = uint8(math.Min(clamp(g), a))
+	p[2] = uint8(math.Min(clamp(b), a))
+	p[3] = uint8(a)
+}
+
+func clamp(v float64) float64 {
+	v = math.Round(v)
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return v
+}
+
+// flatten JPEG不支持透明，透明部分合成到白色背景上
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+func decodeError(err error) error {
+	if errors.Is(err, image.ErrFormat) {
+		return ErrUnsupportedFormat
+	}
+	return err
+}
@@ -16,13 +16,15 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint, username, role string) (string, time.Time, error) {
+// GenerateToken 签发令牌，sessionID写入jti，用于会话撤销
+func GenerateToken(sessionID string, userID uint, username, role string) (string, time.Time, error) {
 	expiresAt := time.Now().Add(24 * time.Hour)
 	claims := Claims{
 		UserID:   userID,
 		Username: username,
 		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -37,6 +39,29 @@ func GenerateToken(userID uint, username, role string) (string, time.Time, error
 	return tokenString, expiresAt, nil
 }
 
+// GenerateURLToken 签发只能用于指定接口的短期令牌，接口路由路径写入aud，
+// 用于EventSource等无法设置请求头的场景，通过access_token查询参数传递
+func GenerateURLToken(sessionID string, userID uint, username, role, path string, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+	claims := Claims{
+		UserID:   userID,
+		Username: username,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
+			Audience:  jwt.ClaimStrings{path},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secretKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return tokenString, expiresAt, nil
+}
+
 func ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return secretKey, nil
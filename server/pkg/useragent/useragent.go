@@ -0,0 +1,134 @@
+// Package useragent 解析HTTP User-Agent，识别浏览器、操作系统和设备类型
+package useragent
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 设备类型
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceOther   = "other"
+)
+
+// Info 解析结果
+type Info struct {
+	Browser        string // 浏览器名称，如 Chrome
+	BrowserVersion string // 浏览器主版本号
+	OS             string // 操作系统名称，如 Windows
+	OSVersion      string // 操作系统版本
+	Device         string // 设备类型：desktop、mobile、tablet、bot、other
+}
+
+// BrowserString 浏览器名称及主版本，如 Chrome 120
+func (i Info) BrowserString() string {
+	return joinVersion(i.Browser, i.BrowserVersion)
+}
+
+// OSString 操作系统名称及版本，如 Windows 10
+func (i Info) OSString() string {
+	return joinVersion(i.OS, i.OSVersion)
+}
+
+func joinVersion(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + " " + version
+}
+
+type rule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// browserRules 按顺序匹配，基于Chromium的浏览器需排在Chrome之前，Chrome需排在Safari之前
+var browserRules = []rule{
+	{"Edge", regexp.MustCompile(`(?:Edg|Edge|EdgA|EdgiOS)/(\d+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|Opera)/(\d+)`)},
+	{"WeChat", regexp.MustCompile(`MicroMessenger/(\d+)`)},
+	{"QQBrowser", regexp.MustCompile(`QQBrowser/(\d+)`)},
+	{"UC Browser", regexp.MustCompile(`UCBrowser/(\d+)`)},
+	{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/(\d+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/(\d+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/(\d+)`)},
+	{"Safari", regexp.MustCompile(`Version/(\d+)[\d.]* (?:Mobile/\S+ )?Safari/`)},
+	{"IE", regexp.MustCompile(`(?:MSIE |Trident/.*rv:)(\d+)`)},
+	{"curl", regexp.MustCompile(`^curl/(\d+)`)},
+	{"Postman", regexp.MustCompile(`PostmanRuntime/(\d+)`)},
+	{"Go HTTP Client", regexp.MustCompile(`^Go-http-client/(\d+)`)},
+	{"Python Requests", regexp.MustCompile(`python-requests/(\d+)`)},
+}
+
+var osRules = []rule{
+	{"Windows Phone", regexp.MustCompile(`Windows Phone(?: OS)? ([\d.]+)`)},
+	{"Windows", regexp.MustCompile(`Windows NT ([\d.]+)`)},
+	{"iOS", regexp.MustCompile(`(?:iPhone|iPad|iPod).*? OS (\d+(?:_\d+)?)`)},
+	{"macOS", regexp.MustCompile(`Mac OS X (\d+(?:[_.]\d+)?)`)},
+	{"HarmonyOS", regexp.MustCompile(`HarmonyOS(?: (\d+))?`)},
+	{"Android", regexp.MustCompile(`Android (\d+(?:\.\d+)?)`)},
+	{"Chrome OS", regexp.MustCompile(`CrOS \S+ (\d+)`)},
+	{"Linux", regexp.MustCompile(`Linux()`)},
+}
+
+// windowsVersions Windows NT内核版本对应的发行版本
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.1":  "XP",
+}
+
+var botPattern = regexp.MustCompile(`(?i)bot|crawler|spider|slurp|headless`)
+
+// Parse 解析User-Agent，无法识别的部分留空
+func Parse(ua string) Info {
+	info := Info{}
+	if ua == "" {
+		return info
+	}
+
+	for _, r := range browserRules {
+		if m := r.re.FindStringSubmatch(ua); m != nil {
+			info.Browser, info.BrowserVersion = r.name, m[1]
+			break
+		}
+	}
+
+	for _, r := range osRules {
+		if m := r.re.FindStringSubmatch(ua); m != nil {
+			info.OS = r.name
+			if len(m) > 1 {
+				info.OSVersion = strings.ReplaceAll(m[1], "_", ".")
+			}
+			break
+		}
+	}
+	if info.OS == "Windows" {
+		info.OSVersion = windowsVersions[info.OSVersion]
+	}
+
+	info.Device = detectDevice(ua, info)
+	return info
+}
+
+func detectDevice(ua string, info Info) string {
+	switch {
+	case botPattern.MatchString(ua):
+		return DeviceBot
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet") ||
+		(info.OS == "Android" && !strings.Contains(ua, "Mobile")):
+		return DeviceTablet
+	case strings.Contains(ua, "Mobi") || strings.Contains(ua, "iPhone") || info.OS == "Windows Phone":
+		return DeviceMobile
+	case info.OS == "Windows" || info.OS == "macOS" || info.OS == "Linux" || info.OS == "Chrome OS":
+		return DeviceDesktop
+	}
+	return DeviceOther
+}